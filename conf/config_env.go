@@ -15,6 +15,19 @@ type AppConfig struct {
 	DBPass    string `env:"DB_PASS" envDefault:"1"`
 	DBName    string `env:"DB_NAME" envDefault:"postgres"`
 	EnableDB  string `env:"ENABLE_DB" envDefault:"true"`
+	EnableES  string `env:"ENABLE_ES" envDefault:"false"`
+	ESAddress string `env:"ES_ADDRESS" envDefault:"http://localhost:9200"`
+	ESIndex   string `env:"ES_INDEX" envDefault:"parking_lot"`
+	// NotificationProvider is log or file, NotificationFile is where the file provider writes.
+	NotificationProvider string `env:"NOTIFICATION_PROVIDER" envDefault:"log"`
+	NotificationFile     string `env:"NOTIFICATION_FILE" envDefault:"notifications.log"`
+	// EventBroker is none, log or http, the domain events are then also posted to EventBrokerURL.
+	EventBroker    string `env:"EVENT_BROKER" envDefault:"none"`
+	EventBrokerURL string `env:"EVENT_BROKER_URL"`
+	// MigrationDir is where "migrate create" writes the new migration files.
+	MigrationDir string `env:"MIGRATION_DIR" envDefault:"migrations"`
+	// CompanyVerifyURL is the email verification link sent to the companies, the token is appended to it.
+	CompanyVerifyURL string `env:"COMPANY_VERIFY_URL" envDefault:"http://localhost:8088/api/merchant/company/verify-email?token="`
 }
 
 var config AppConfig
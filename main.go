@@ -6,7 +6,6 @@ import (
 	"os"
 	"parkar-server/conf"
 	"parkar-server/pkg/route"
-	"parkar-server/pkg/utils"
 )
 
 const (
@@ -35,11 +34,25 @@ const (
 func main() {
 	conf.SetEnv()
 	logger.Init(APPNAME)
-	utils.LoadMessageError()
 
 	app := route.NewService()
 	ctx := context.Background()
-	err := app.Start(ctx)
+
+	var err error
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	switch command {
+	case "reindex":
+		err = app.Reindex(ctx)
+	case "migrate":
+		err = app.Migrate(ctx, os.Args[2:])
+	case "grant-admin":
+		err = app.GrantAdmin(ctx, os.Args[2:])
+	default:
+		err = app.Start(ctx)
+	}
 	if err != nil {
 		logger.Tag("main").Error(err)
 	}
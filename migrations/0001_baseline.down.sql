@@ -0,0 +1,23 @@
+drop table if exists webhook_delivery;
+drop table if exists webhook_subscription;
+drop table if exists processed_event;
+drop table if exists outbox;
+drop table if exists notification_preference;
+drop table if exists notification;
+drop table if exists device_token;
+drop table if exists waitlist;
+drop table if exists slot_hold;
+drop table if exists slot_downtime;
+drop table if exists setting;
+drop table if exists refresh_token;
+drop table if exists favorite;
+drop table if exists ticket_extend;
+drop table if exists ticket;
+drop table if exists long_term_ticket;
+drop table if exists vehicle;
+drop table if exists time_frame;
+drop table if exists parking_slot;
+drop table if exists block;
+drop table if exists parking_lot;
+drop table if exists users;
+drop table if exists company;
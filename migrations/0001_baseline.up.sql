@@ -0,0 +1,396 @@
+-- Baseline: the schema the models had when it was still created by AutoMigrate. The statements are
+-- idempotent so databases created that way adopt it as is.
+
+create extension if not exists "uuid-ossp";
+-- the searches use unaccent() and the distance filters ST_DistanceSphere(), the migration stops here when the
+-- server does not ship them or the role cannot create them.
+create extension if not exists unaccent;
+create extension if not exists postgis;
+
+create table if not exists company (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  name text,
+  phone_number text not null,
+  email text not null,
+  password text not null,
+  primary key (id)
+);
+
+create table if not exists users (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  social_id text,
+  display_name text,
+  email text,
+  image_url text,
+  password text not null,
+  phone_number text not null,
+  primary key (id)
+);
+
+create table if not exists parking_lot (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  name text not null,
+  description text,
+  address text,
+  start_time timestamptz,
+  end_time timestamptz,
+  lat decimal,
+  long decimal,
+  company_id uuid,
+  amenities text[],
+  primary key (id)
+);
+-- databases created by AutoMigrate before the column was added to the model
+alter table parking_lot add column if not exists amenities text[];
+
+create table if not exists block (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  code text,
+  description text,
+  slot bigint,
+  parking_lot_id uuid,
+  primary key (id)
+);
+
+create table if not exists parking_slot (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  name text,
+  description text,
+  vehicle_type text,
+  distance_to_entrance decimal,
+  block_id uuid,
+  primary key (id),
+  constraint fk_block_parking_s_lots foreign key (block_id) references block (id)
+);
+alter table parking_slot add column if not exists vehicle_type text;
+alter table parking_slot add column if not exists distance_to_entrance decimal;
+
+create table if not exists time_frame (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  duration bigint,
+  cost decimal,
+  parking_lot_id uuid not null,
+  primary key (id)
+);
+
+create table if not exists vehicle (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  name text,
+  number text,
+  type text,
+  user_id uuid not null,
+  primary key (id)
+);
+
+create table if not exists long_term_ticket (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  type text,
+  start_time timestamptz,
+  end_time timestamptz,
+  vehicle_id uuid,
+  parking_lot_id uuid,
+  parking_slot_id uuid,
+  time_frame_id uuid,
+  primary key (id)
+);
+
+create table if not exists ticket (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id text,
+  vehicle_id uuid,
+  parking_lot_id uuid,
+  parking_slot_id uuid,
+  time_frame_id uuid,
+  start_time timestamptz,
+  end_time timestamptz,
+  entry_time timestamptz,
+  exit_time timestamptz,
+  total decimal,
+  state text,
+  is_extend boolean,
+  long_term_ticket_id uuid,
+  primary key (id),
+  constraint fk_ticket_time_frame foreign key (time_frame_id) references time_frame (id),
+  constraint fk_ticket_vehicle foreign key (vehicle_id) references vehicle (id),
+  constraint fk_ticket_parking_lot foreign key (parking_lot_id) references parking_lot (id),
+  constraint fk_ticket_parking_slot foreign key (parking_slot_id) references parking_slot (id)
+);
+
+create table if not exists ticket_extend (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  ticket_id uuid,
+  ticket_extend_id uuid,
+  primary key (id)
+);
+
+create table if not exists favorite (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  parking_lot_id uuid,
+  primary key (id),
+  constraint fk_favorite_parking_lot foreign key (parking_lot_id) references parking_lot (id)
+);
+
+create table if not exists refresh_token (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  token text,
+  expired_date timestamptz,
+  primary key (id)
+);
+
+create table if not exists setting (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  company_id uuid,
+  parking_lot_id uuid,
+  key text,
+  value jsonb,
+  primary key (id),
+  constraint fk_setting_company foreign key (company_id) references company (id)
+);
+
+create table if not exists slot_downtime (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  parking_lot_id uuid not null,
+  block_id uuid,
+  parking_slot_id uuid,
+  start_time timestamptz,
+  end_time timestamptz,
+  reason text,
+  primary key (id)
+);
+create index if not exists idx_slot_downtime_parking_lot_id on slot_downtime (parking_lot_id);
+
+create table if not exists slot_hold (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  parking_lot_id uuid,
+  parking_slot_id uuid,
+  start_time timestamptz,
+  end_time timestamptz,
+  expires_at timestamptz,
+  ticket_id uuid,
+  primary key (id)
+);
+
+create table if not exists waitlist (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  vehicle_id uuid,
+  parking_lot_id uuid,
+  time_frame_id uuid,
+  start_time timestamptz,
+  end_time timestamptz,
+  state text,
+  parking_slot_id uuid,
+  offer_expires_at timestamptz,
+  ticket_id uuid,
+  primary key (id)
+);
+
+create table if not exists device_token (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  token text,
+  platform text,
+  primary key (id)
+);
+create unique index if not exists idx_device_token_token on device_token (token);
+create index if not exists idx_device_token_user_id on device_token (user_id);
+
+create table if not exists notification (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  ticket_id uuid,
+  event text,
+  channel text,
+  title text,
+  body text,
+  status text,
+  error text,
+  primary key (id)
+);
+create index if not exists idx_notification_user_id on notification (user_id);
+create index if not exists idx_notification_ticket_id on notification (ticket_id);
+
+create table if not exists notification_preference (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  user_id uuid,
+  push boolean,
+  sms boolean,
+  email boolean,
+  language text,
+  primary key (id)
+);
+create unique index if not exists idx_notification_preference_user_id on notification_preference (user_id);
+
+create table if not exists outbox (
+  id uuid default uuid_generate_v4(),
+  company_id uuid,
+  aggregate_type text,
+  aggregate_id uuid,
+  event_type text,
+  payload jsonb,
+  created_at timestamptz default current_timestamp,
+  published_at timestamptz,
+  next_attempt_at timestamptz default current_timestamp,
+  attempts bigint,
+  last_error text,
+  primary key (id)
+);
+create index if not exists idx_outbox_published_at on outbox (published_at);
+
+create table if not exists processed_event (
+  consumer text,
+  event_id uuid,
+  processed_at timestamptz default current_timestamp,
+  primary key (consumer,event_id)
+);
+
+create table if not exists webhook_subscription (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  company_id uuid,
+  url text,
+  secret text,
+  event_types text[],
+  active boolean,
+  primary key (id)
+);
+create index if not exists idx_webhook_subscription_company_id on webhook_subscription (company_id);
+
+create table if not exists webhook_delivery (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  subscription_id uuid,
+  event_id uuid,
+  event_type text,
+  payload jsonb,
+  status text,
+  attempts bigint,
+  next_attempt_at timestamptz,
+  last_status_code bigint,
+  last_error text,
+  delivered_at timestamptz,
+  primary key (id)
+);
+create index if not exists idx_webhook_delivery_next_attempt_at on webhook_delivery (next_attempt_at);
+create index if not exists idx_webhook_delivery_status on webhook_delivery (status);
+create unique index if not exists idx_webhook_delivery_event on webhook_delivery (subscription_id, event_id);
+
+-- AutoMigrate created the json columns as bytea, they hold the json text.
+do $$
+declare
+  col record;
+begin
+  for col in select table_name, column_name from information_schema.columns
+             where table_schema = current_schema()
+               and data_type = 'bytea'
+               and (table_name, column_name) in (('setting', 'value'), ('outbox', 'payload'), ('webhook_delivery', 'payload'))
+  loop
+    execute format('alter table %I alter column %I type jsonb using convert_from(%I, ''UTF8'')::jsonb',
+                   col.table_name, col.column_name, col.column_name);
+  end loop;
+end $$;
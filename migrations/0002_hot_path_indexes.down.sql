@@ -0,0 +1,35 @@
+-- migrate:no-transaction
+
+alter table waitlist drop constraint if exists fk_waitlist_parking_lot;
+alter table slot_hold drop constraint if exists fk_slot_hold_parking_slot;
+alter table slot_downtime drop constraint if exists fk_slot_downtime_parking_slot;
+alter table slot_downtime drop constraint if exists fk_slot_downtime_block;
+alter table slot_downtime drop constraint if exists fk_slot_downtime_parking_lot;
+alter table ticket_extend drop constraint if exists fk_ticket_extend_extend;
+alter table ticket_extend drop constraint if exists fk_ticket_extend_ticket;
+alter table time_frame drop constraint if exists fk_time_frame_parking_lot;
+alter table parking_lot drop constraint if exists fk_parking_lot_company;
+alter table block drop constraint if exists fk_block_parking_lot;
+
+drop index if exists uq_company_email;
+drop index if exists uq_users_phone_number;
+drop index if exists uq_favorite_user_lot;
+
+create index if not exists idx_outbox_published_at on outbox (published_at);
+drop index if exists idx_outbox_pending;
+drop index if exists idx_waitlist_waiting_lot;
+drop index if exists idx_waitlist_offered_slot;
+drop index if exists idx_slot_hold_slot;
+drop index if exists idx_slot_downtime_block;
+drop index if exists idx_slot_downtime_slot;
+drop index if exists idx_time_frame_parking_lot;
+drop index if exists idx_parking_slot_block;
+drop index if exists idx_block_parking_lot;
+
+drop index concurrently if exists idx_ticket_extend_extend;
+drop index concurrently if exists idx_ticket_extend_ticket;
+drop index concurrently if exists idx_ticket_no_show;
+drop index concurrently if exists idx_ticket_lot_start;
+drop index concurrently if exists idx_ticket_lot_created;
+drop index concurrently if exists idx_ticket_user_created;
+drop index concurrently if exists idx_ticket_slot_active_time;
@@ -0,0 +1,80 @@
+-- migrate:no-transaction
+-- The ticket indexes are built concurrently so bookings keep working while they are created. A failed
+-- build leaves an invalid index behind: drop it before running the migration again.
+
+-- availability: the tickets overlapping a time range on a slot
+create index concurrently if not exists idx_ticket_slot_active_time on ticket (parking_slot_id, start_time, end_time)
+  where deleted_at is null and state in ('new', 'extend', 'ongoing');
+-- ticket lists, paged by cursor in created_at desc, id desc order
+create index concurrently if not exists idx_ticket_user_created on ticket (user_id, created_at desc, id desc)
+  where deleted_at is null;
+create index concurrently if not exists idx_ticket_lot_created on ticket (parking_lot_id, created_at desc, id desc)
+  where deleted_at is null;
+-- reports and releases of a parking lot over a time range
+create index concurrently if not exists idx_ticket_lot_start on ticket (parking_lot_id, start_time)
+  where deleted_at is null;
+-- no-show sweep
+create index concurrently if not exists idx_ticket_no_show on ticket (start_time)
+  where deleted_at is null and state = 'new' and entry_time is null;
+create index concurrently if not exists idx_ticket_extend_ticket on ticket_extend (ticket_id) where deleted_at is null;
+create index concurrently if not exists idx_ticket_extend_extend on ticket_extend (ticket_extend_id) where deleted_at is null;
+
+create index if not exists idx_block_parking_lot on block (parking_lot_id) where deleted_at is null;
+create index if not exists idx_parking_slot_block on parking_slot (block_id) where deleted_at is null;
+create index if not exists idx_time_frame_parking_lot on time_frame (parking_lot_id) where deleted_at is null;
+create index if not exists idx_slot_downtime_slot on slot_downtime (parking_slot_id, start_time) where deleted_at is null;
+create index if not exists idx_slot_downtime_block on slot_downtime (block_id, start_time) where deleted_at is null;
+create index if not exists idx_slot_hold_slot on slot_hold (parking_slot_id, expires_at) where deleted_at is null and ticket_id is null;
+create index if not exists idx_waitlist_offered_slot on waitlist (parking_slot_id) where deleted_at is null and state = 'offered';
+create index if not exists idx_waitlist_waiting_lot on waitlist (parking_lot_id, created_at) where deleted_at is null and state = 'waiting';
+create index if not exists idx_outbox_pending on outbox (next_attempt_at) where published_at is null;
+drop index if exists idx_outbox_published_at;
+
+-- unique constraints, among the rows not deleted. Duplicated favorites are dropped, duplicated phones or
+-- emails make the migration fail and have to be merged by hand.
+update favorite f set deleted_at = now()
+  where deleted_at is null
+    and exists (select 1 from favorite o
+                where o.deleted_at is null
+                  and o.user_id = f.user_id
+                  and o.parking_lot_id = f.parking_lot_id
+                  and (o.created_at, o.id) < (f.created_at, f.id));
+create unique index if not exists uq_favorite_user_lot on favorite (user_id, parking_lot_id) where deleted_at is null;
+create unique index if not exists uq_users_phone_number on users (phone_number) where deleted_at is null;
+create unique index if not exists uq_company_email on company (email) where deleted_at is null;
+
+-- foreign keys, added without a scan and validated afterwards so the tables stay writable meanwhile. The
+-- migration runs outside a transaction, the ones a failed run already added are skipped.
+do $$
+declare
+  fk record;
+begin
+  for fk in select * from (values
+      ('block', 'fk_block_parking_lot', 'foreign key (parking_lot_id) references parking_lot (id)'),
+      ('parking_lot', 'fk_parking_lot_company', 'foreign key (company_id) references company (id)'),
+      ('time_frame', 'fk_time_frame_parking_lot', 'foreign key (parking_lot_id) references parking_lot (id)'),
+      ('ticket_extend', 'fk_ticket_extend_ticket', 'foreign key (ticket_id) references ticket (id)'),
+      ('ticket_extend', 'fk_ticket_extend_extend', 'foreign key (ticket_extend_id) references ticket (id)'),
+      ('slot_downtime', 'fk_slot_downtime_parking_lot', 'foreign key (parking_lot_id) references parking_lot (id)'),
+      ('slot_downtime', 'fk_slot_downtime_block', 'foreign key (block_id) references block (id)'),
+      ('slot_downtime', 'fk_slot_downtime_parking_slot', 'foreign key (parking_slot_id) references parking_slot (id)'),
+      ('slot_hold', 'fk_slot_hold_parking_slot', 'foreign key (parking_slot_id) references parking_slot (id)'),
+      ('waitlist', 'fk_waitlist_parking_lot', 'foreign key (parking_lot_id) references parking_lot (id)')
+    ) as v (table_name, name, definition)
+  loop
+    if not exists (select 1 from pg_constraint
+                   where conrelid = fk.table_name::regclass and conname = fk.name) then
+      execute format('alter table %I add constraint %I %s not valid', fk.table_name, fk.name, fk.definition);
+    end if;
+  end loop;
+end $$;
+alter table block validate constraint fk_block_parking_lot;
+alter table parking_lot validate constraint fk_parking_lot_company;
+alter table time_frame validate constraint fk_time_frame_parking_lot;
+alter table ticket_extend validate constraint fk_ticket_extend_ticket;
+alter table ticket_extend validate constraint fk_ticket_extend_extend;
+alter table slot_downtime validate constraint fk_slot_downtime_parking_lot;
+alter table slot_downtime validate constraint fk_slot_downtime_block;
+alter table slot_downtime validate constraint fk_slot_downtime_parking_slot;
+alter table slot_hold validate constraint fk_slot_hold_parking_slot;
+alter table waitlist validate constraint fk_waitlist_parking_lot;
@@ -0,0 +1 @@
+alter table parking_lot drop column if exists timezone;
@@ -0,0 +1 @@
+alter table parking_lot add column if not exists timezone text not null default 'Asia/Ho_Chi_Minh';
@@ -0,0 +1,15 @@
+create table if not exists idempotency_key (
+  user_id text,
+  key text,
+  method text,
+  path text,
+  request_hash text,
+  status_code bigint,
+  content_type text,
+  response bytea,
+  created_at timestamptz default current_timestamp,
+  completed_at timestamptz,
+  expires_at timestamptz,
+  primary key (user_id,key)
+);
+create index if not exists idx_idempotency_key_expires_at on idempotency_key (expires_at);
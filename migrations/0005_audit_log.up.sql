@@ -0,0 +1,18 @@
+create table if not exists audit_log (
+  id uuid default uuid_generate_v4(),
+  company_id uuid,
+  actor_id uuid,
+  actor_type text,
+  action text,
+  entity_type text,
+  entity_id text,
+  before jsonb,
+  after jsonb,
+  diff jsonb,
+  ip text,
+  request_id text,
+  created_at timestamptz default current_timestamp,
+  primary key (id)
+);
+create index if not exists idx_audit_log_company_created on audit_log (company_id, created_at desc);
+create index if not exists idx_audit_log_entity on audit_log (entity_type, entity_id, created_at desc);
@@ -0,0 +1,4 @@
+drop index if exists idx_company_status;
+alter table company drop column if exists status_reason;
+alter table company drop column if exists status;
+alter table users drop column if exists role;
@@ -0,0 +1,4 @@
+alter table users add column if not exists role integer not null default 1;
+alter table company add column if not exists status text not null default 'active';
+alter table company add column if not exists status_reason text;
+create index if not exists idx_company_status on company (status);
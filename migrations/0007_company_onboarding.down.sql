@@ -0,0 +1,4 @@
+drop table if exists company_document;
+drop table if exists company_verification_token;
+alter table company drop column if exists email_verified_at;
+update company set status = 'pending' where status in ('pending_email', 'pending_review');
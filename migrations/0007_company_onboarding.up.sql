@@ -0,0 +1,33 @@
+update company set status = 'pending_review' where status = 'pending';
+alter table company add column if not exists email_verified_at timestamptz;
+
+create table if not exists company_verification_token (
+  id uuid default uuid_generate_v4(),
+  company_id uuid not null,
+  token_hash text not null,
+  expires_at timestamptz not null,
+  used_at timestamptz,
+  created_at timestamptz default current_timestamp,
+  primary key (id),
+  constraint fk_company_verification_token_company foreign key (company_id) references company (id)
+);
+create unique index if not exists idx_company_verification_token_hash on company_verification_token (token_hash);
+
+create table if not exists company_document (
+  id uuid default uuid_generate_v4(),
+  creator_id uuid,
+  updater_id uuid,
+  created_at timestamptz default current_timestamp,
+  updated_at timestamptz default current_timestamp,
+  deleted_at timestamptz,
+  company_id uuid not null,
+  type text not null,
+  number text,
+  file_name text not null,
+  content_type text,
+  size bigint,
+  url text not null,
+  primary key (id),
+  constraint fk_company_document_company foreign key (company_id) references company (id)
+);
+create index if not exists idx_company_document_company_id on company_document (company_id);
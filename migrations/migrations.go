@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL migrations of the database. A migration is a pair of files
+// <version>_<name>.up.sql and <version>_<name>.down.sql, created with "parkar-server migrate create <name>".
+// A file starting with "-- migrate:no-transaction" runs its statements one by one outside a transaction,
+// for statements such as create index concurrently.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
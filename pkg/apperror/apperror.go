@@ -0,0 +1,138 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/goxp/cloud0/ginext"
+	"net/http"
+	"strings"
+)
+
+var _ ginext.ApiError = &Error{}
+
+// Error is an error with a Code, rendered by ginext as {"error":{"code":..,"detail":..},"code":..} with the
+// message of the code in the language of the request. The cause is logged, it is only shown to the client as
+// reason for the errors of the client, never for the errors of the server.
+type Error struct {
+	code   Code
+	args   []interface{}
+	cause  error
+	fields []FieldError
+	lang   string
+}
+
+// FieldError is a field of a request failing a validation rule, Param is the argument of the rule such as the
+// bound of gt.
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+type errorBody struct {
+	Code   Code             `json:"code"`
+	Detail string           `json:"detail"`
+	Reason string           `json:"reason,omitempty"`
+	Fields []fieldErrorBody `json:"fields,omitempty"`
+}
+
+type fieldErrorBody struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// New returns the error code, args fill the placeholders of its message.
+func New(code Code, args ...interface{}) error {
+	return &Error{code: code, args: args}
+}
+
+// Wrap returns the error code caused by err, err itself when it already has a code.
+func Wrap(code Code, err error, args ...interface{}) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{code: code, args: args, cause: err}
+}
+
+// Invalid returns the INVALID_INPUT error of the fields failing validation.
+func Invalid(fields ...FieldError) error {
+	return &Error{code: InvalidInput, fields: fields}
+}
+
+// From returns err as an Error, the errors with only an HTTP status get the generic code of the status.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var apiErr ginext.ApiError
+	if errors.As(err, &apiErr) {
+		if code, ok := statusCodes[apiErr.Code()]; ok {
+			return &Error{code: code, cause: err}
+		}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{code: InvalidInput, cause: err}
+	}
+	return &Error{code: Internal, cause: err}
+}
+
+// In returns the error with its message in lang.
+func (e *Error) In(lang string) *Error {
+	res := *e
+	res.lang = lang
+	return &res
+}
+
+func (e *Error) ErrorCode() Code {
+	return e.code
+}
+
+func (e *Error) Fields() []FieldError {
+	return e.fields
+}
+
+// Code is the HTTP status of the error.
+func (e *Error) Code() int {
+	return e.code.Status()
+}
+
+func (e *Error) ResponseCode() string {
+	return string(e.code)
+}
+
+func (e *Error) Message() string {
+	return Message(e.lang, e.code, e.args...)
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	body := errorBody{Code: e.code, Detail: e.Message()}
+	if e.cause != nil && e.Code() < http.StatusInternalServerError {
+		body.Reason = e.cause.Error()
+	}
+	for _, f := range e.fields {
+		body.Fields = append(body.Fields, fieldErrorBody{Field: f.Field, Rule: f.Rule, Message: FieldMessage(e.lang, f)})
+	}
+	return json.Marshal(body)
+}
+
+func (e *Error) Error() string {
+	if len(e.fields) > 0 {
+		fields := make([]string, 0, len(e.fields))
+		for _, f := range e.fields {
+			fields = append(fields, f.Field+": "+FieldMessage("en", f))
+		}
+		return string(e.code) + ": " + strings.Join(fields, "; ")
+	}
+	if e.cause != nil {
+		return string(e.code) + ": " + e.cause.Error()
+	}
+	return string(e.code) + ": " + Message("en", e.code, e.args...)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,102 @@
+package apperror
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used for the requests without a supported Accept-Language.
+const DefaultLanguage = "vi"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogue holds the message of every code per language, read from locales/<language>.json. A message is a
+// fmt format filled with the args of the error. The messages of the validation rules are under field.<rule>.
+var catalogue = loadCatalogue()
+
+func loadCatalogue() map[string]map[Code]string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	res := map[string]map[Code]string{}
+	for _, entry := range entries {
+		b, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[Code]string{}
+		if err := json.Unmarshal(b, &messages); err != nil {
+			panic(fmt.Sprintf("locales/%s: %s", entry.Name(), err))
+		}
+		res[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return res
+}
+
+// Message returns the message of code in lang, in DefaultLanguage when lang has none.
+func Message(lang string, code Code, args ...interface{}) string {
+	msg, ok := catalogue[lang][code]
+	if !ok {
+		if msg, ok = catalogue[DefaultLanguage][code]; !ok {
+			msg = catalogue[DefaultLanguage][Internal]
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// FieldMessage returns the message of the rule failed by a field in lang, the one of field.invalid for a rule
+// without message. The message may refer to the param of the rule as %[1]s.
+func FieldMessage(lang string, f FieldError) string {
+	for _, code := range []Code{Code("field." + f.Rule), "field.invalid"} {
+		for _, l := range []string{lang, DefaultLanguage} {
+			if msg, ok := catalogue[l][code]; ok {
+				if !strings.Contains(msg, "%") {
+					return msg
+				}
+				return fmt.Sprintf(msg, f.Param)
+			}
+		}
+	}
+	return f.Rule
+}
+
+// Language picks the supported language preferred by an Accept-Language header, DefaultLanguage when there is
+// none. Region subtags are ignored, en-US picks en.
+func Language(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogue[lang]; !ok {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if f, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
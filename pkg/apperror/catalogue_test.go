@@ -0,0 +1,175 @@
+package apperror
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// declaredCodes returns the Code constants of codes.go.
+func declaredCodes(t *testing.T) []Code {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []Code
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Code" {
+				continue
+			}
+			for _, v := range value.Values {
+				s, err := strconv.Unquote(v.(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				codes = append(codes, Code(s))
+			}
+		}
+	}
+	return codes
+}
+
+var verbPattern = regexp.MustCompile(`%(\[(\d+)\])?[a-z]`)
+
+// verbs returns the verb of every argument a message formats, by argument number.
+func verbs(msg string) map[int]string {
+	res := map[int]string{}
+	next := 1
+	for _, m := range verbPattern.FindAllStringSubmatch(msg, -1) {
+		arg := next
+		if m[2] != "" {
+			arg, _ = strconv.Atoi(m[2])
+		}
+		res[arg] = m[0][len(m[0])-1:]
+		next = arg + 1
+	}
+	return res
+}
+
+func TestCatalogue(t *testing.T) {
+	codes := declaredCodes(t)
+	if len(codes) < len(statuses) {
+		t.Fatalf("found %d codes in codes.go, %d have a status", len(codes), len(statuses))
+	}
+	for _, lang := range []string{"vi", "en"} {
+		if _, ok := catalogue[lang]; !ok {
+			t.Fatalf("no %s locale", lang)
+		}
+	}
+
+	known := map[Code]bool{}
+	for _, code := range codes {
+		known[code] = true
+		if _, ok := statuses[code]; !ok {
+			t.Errorf("%s has no status", code)
+		}
+		for lang, messages := range catalogue {
+			if strings.TrimSpace(messages[code]) == "" {
+				t.Errorf("%s has no %s message", code, lang)
+			}
+		}
+		// a message formats the same args in every language
+		if vi, en := verbs(catalogue["vi"][code]), verbs(catalogue["en"][code]); !reflect.DeepEqual(vi, en) {
+			t.Errorf("%s formats %v in vi and %v in en", code, vi, en)
+		}
+	}
+	for lang, messages := range catalogue {
+		for code := range messages {
+			if !known[code] && !strings.HasPrefix(string(code), "field.") {
+				t.Errorf("%s message of the unknown code %s", lang, code)
+			}
+			if _, ok := catalogue[DefaultLanguage][code]; !ok {
+				t.Errorf("%s has a %s message and no %s one", code, lang, DefaultLanguage)
+			}
+		}
+	}
+	for code := range statuses {
+		if !known[code] {
+			t.Errorf("status of the unknown code %s", code)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if got := Message("en", SlotCountOutOfRange, 200); got != "slot must be between 1 and 200 to generate slots" {
+		t.Errorf("Message(en) = %q", got)
+	}
+	if got, want := Message("fr", NotFound), catalogue[DefaultLanguage][NotFound]; got != want {
+		t.Errorf("Message(fr) = %q, want the %s message %q", got, DefaultLanguage, want)
+	}
+	if got, want := Message("en", Code("NO_SUCH_CODE")), catalogue[DefaultLanguage][Internal]; got != want {
+		t.Errorf("Message(unknown code) = %q, want %q", got, want)
+	}
+	if got := FieldMessage("en", FieldError{Field: "slot", Rule: "gt", Param: "0"}); got != "Must be greater than 0" {
+		t.Errorf("FieldMessage(gt) = %q", got)
+	}
+	if got := FieldMessage("en", FieldError{Field: "slot", Rule: "no_such_rule"}); got != catalogue["en"]["field.invalid"] {
+		t.Errorf("FieldMessage(unknown rule) = %q", got)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                         DefaultLanguage,
+		"fr":                       DefaultLanguage,
+		"en":                       "en",
+		"en-US,en;q=0.9":           "en",
+		"fr, en;q=0.8, vi;q=0.9":   "vi",
+		"vi;q=0.1, EN-gb;q=0.5":    "en",
+		"en;q=0, vi;q=0.2":         "vi",
+		"en;q=oops":                "en",
+		"de-DE, fr;q=0.9, *;q=0.1": DefaultLanguage,
+	}
+	for header, want := range tests {
+		if got := Language(header); got != want {
+			t.Errorf("Language(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	cause := errors.New("pq: connection refused")
+	tests := []struct {
+		err    error
+		code   Code
+		status int
+	}{
+		{New(SlotHeld), SlotHeld, http.StatusConflict},
+		{Wrap(NotFound, New(TicketSlotTaken)), TicketSlotTaken, http.StatusConflict},
+		{Wrap(NotFound, cause), NotFound, http.StatusNotFound},
+		{cause, Internal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		e := From(tt.err)
+		if e.ErrorCode() != tt.code || e.Code() != tt.status {
+			t.Errorf("From(%v) = %s %d, want %s %d", tt.err, e.ErrorCode(), e.Code(), tt.code, tt.status)
+		}
+	}
+
+	// the cause of a server error is not shown to the client
+	b, err := Wrap(Internal, cause).(*Error).In("en").MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "connection refused") {
+		t.Errorf("server error body %s shows its cause", b)
+	}
+	b, _ = Wrap(InvalidInput, cause).(*Error).In("en").MarshalJSON()
+	if !strings.Contains(string(b), `"reason":"pq: connection refused"`) {
+		t.Errorf("client error body %s has no reason", b)
+	}
+}
@@ -0,0 +1,148 @@
+package apperror
+
+import "net/http"
+
+// Code identifies an error for the clients, it is stable while the messages may change.
+type Code string
+
+const (
+	Internal        Code = "INTERNAL"
+	BadRequest      Code = "BAD_REQUEST"
+	InvalidInput    Code = "INVALID_INPUT"
+	InvalidID       Code = "INVALID_ID"
+	InvalidParam    Code = "INVALID_PARAMETER"
+	Unauthorized    Code = "UNAUTHORIZED"
+	Forbidden       Code = "FORBIDDEN"
+	NotFound        Code = "NOT_FOUND"
+	Conflict        Code = "CONFLICT"
+	TooManyRequests Code = "TOO_MANY_REQUESTS"
+	Timeout         Code = "TIMEOUT"
+	UserIDRequired  Code = "USER_ID_REQUIRED"
+
+	InvalidCredentials    Code = "AUTH_INVALID_CREDENTIALS"
+	IncorrectPassword     Code = "AUTH_INCORRECT_PASSWORD"
+	PhoneNumberTaken      Code = "USER_PHONE_NUMBER_TAKEN"
+	CompanyPending        Code = "COMPANY_PENDING_APPROVAL"
+	CompanySuspended      Code = "COMPANY_SUSPENDED"
+	CompanyStatus         Code = "COMPANY_STATUS_CONFLICT"
+	CompanyUnverified     Code = "COMPANY_EMAIL_NOT_VERIFIED"
+	CompanyNoDocument     Code = "COMPANY_DOCUMENT_REQUIRED"
+	CompanyTokenInvalid   Code = "COMPANY_VERIFICATION_TOKEN_INVALID"
+	InvalidTimeRange      Code = "INVALID_TIME_RANGE"
+	TimeRequired          Code = "TIME_REQUIRED"
+	TimeInPast            Code = "TIME_IN_PAST"
+	InvalidCursor         Code = "INVALID_CURSOR"
+	CursorSortUnsupported Code = "CURSOR_SORT_UNSUPPORTED"
+	InvalidSort           Code = "INVALID_SORT"
+	InvalidFilter         Code = "INVALID_FILTER"
+	SearchDisabled        Code = "SEARCH_DISABLED"
+	ReportScopeRequired   Code = "REPORT_SCOPE_REQUIRED"
+	LayoutFileRequired    Code = "LAYOUT_FILE_REQUIRED"
+	InvalidLayout         Code = "LAYOUT_INVALID"
+	LayoutSlotsInUse      Code = "LAYOUT_SLOTS_IN_USE"
+	TimeFrameCount        Code = "TIME_FRAME_COUNT_INVALID"
+	SlotCountOutOfRange   Code = "BLOCK_SLOT_COUNT_OUT_OF_RANGE"
+	DowntimeTarget        Code = "DOWNTIME_TARGET_INVALID"
+
+	TicketSlotTaken       Code = "TICKET_SLOT_TAKEN"
+	TicketNoFreeSlot      Code = "TICKET_NO_FREE_SLOT"
+	TicketSlotRequired    Code = "TICKET_SLOT_REQUIRED"
+	TicketNoShow          Code = "TICKET_NO_SHOW"
+	TicketAlreadyStarted  Code = "TICKET_ALREADY_STARTED"
+	TicketSameSlot        Code = "TICKET_SAME_SLOT"
+	SlotHeld              Code = "SLOT_HELD"
+	SlotHoldExpired       Code = "SLOT_HOLD_EXPIRED"
+	SlotHoldBooked        Code = "SLOT_HOLD_BOOKED"
+	SlotHoldMismatch      Code = "SLOT_HOLD_MISMATCH"
+	SlotHoldOutsideTime   Code = "SLOT_HOLD_OUTSIDE_TIME"
+	WaitlistOfferGone     Code = "WAITLIST_OFFER_UNAVAILABLE"
+	WaitlistClosed        Code = "WAITLIST_CLOSED"
+	DeleteVehiclesParked  Code = "DELETE_VEHICLES_PARKED"
+	DeleteUpcomingTickets Code = "DELETE_UPCOMING_TICKETS"
+	RelocateNoFreeSlot    Code = "RELOCATE_NO_FREE_SLOT"
+
+	IdempotencyKeyTooLong    Code = "IDEMPOTENCY_KEY_TOO_LONG"
+	IdempotencyKeyReused     Code = "IDEMPOTENCY_KEY_REUSED"
+	IdempotencyKeyInProgress Code = "IDEMPOTENCY_KEY_IN_PROGRESS"
+)
+
+var statuses = map[Code]int{
+	Internal:        http.StatusInternalServerError,
+	BadRequest:      http.StatusBadRequest,
+	InvalidInput:    http.StatusBadRequest,
+	InvalidID:       http.StatusBadRequest,
+	InvalidParam:    http.StatusBadRequest,
+	Unauthorized:    http.StatusUnauthorized,
+	Forbidden:       http.StatusForbidden,
+	NotFound:        http.StatusNotFound,
+	Conflict:        http.StatusConflict,
+	TooManyRequests: http.StatusTooManyRequests,
+	Timeout:         http.StatusGatewayTimeout,
+	UserIDRequired:  http.StatusBadRequest,
+
+	InvalidCredentials:    http.StatusUnauthorized,
+	IncorrectPassword:     http.StatusUnauthorized,
+	PhoneNumberTaken:      http.StatusConflict,
+	CompanyPending:        http.StatusForbidden,
+	CompanySuspended:      http.StatusForbidden,
+	CompanyStatus:         http.StatusConflict,
+	CompanyUnverified:     http.StatusForbidden,
+	CompanyNoDocument:     http.StatusConflict,
+	CompanyTokenInvalid:   http.StatusBadRequest,
+	InvalidTimeRange:      http.StatusBadRequest,
+	TimeRequired:          http.StatusBadRequest,
+	TimeInPast:            http.StatusBadRequest,
+	InvalidCursor:         http.StatusBadRequest,
+	CursorSortUnsupported: http.StatusBadRequest,
+	InvalidSort:           http.StatusBadRequest,
+	InvalidFilter:         http.StatusBadRequest,
+	SearchDisabled:        http.StatusBadRequest,
+	ReportScopeRequired:   http.StatusBadRequest,
+	LayoutFileRequired:    http.StatusBadRequest,
+	InvalidLayout:         http.StatusBadRequest,
+	LayoutSlotsInUse:      http.StatusConflict,
+	TimeFrameCount:        http.StatusBadRequest,
+	SlotCountOutOfRange:   http.StatusBadRequest,
+	DowntimeTarget:        http.StatusBadRequest,
+
+	TicketSlotTaken:       http.StatusConflict,
+	TicketNoFreeSlot:      http.StatusConflict,
+	TicketSlotRequired:    http.StatusBadRequest,
+	TicketNoShow:          http.StatusBadRequest,
+	TicketAlreadyStarted:  http.StatusBadRequest,
+	TicketSameSlot:        http.StatusBadRequest,
+	SlotHeld:              http.StatusConflict,
+	SlotHoldExpired:       http.StatusConflict,
+	SlotHoldBooked:        http.StatusBadRequest,
+	SlotHoldMismatch:      http.StatusBadRequest,
+	SlotHoldOutsideTime:   http.StatusBadRequest,
+	WaitlistOfferGone:     http.StatusConflict,
+	WaitlistClosed:        http.StatusBadRequest,
+	DeleteVehiclesParked:  http.StatusConflict,
+	DeleteUpcomingTickets: http.StatusConflict,
+	RelocateNoFreeSlot:    http.StatusConflict,
+
+	IdempotencyKeyTooLong:    http.StatusBadRequest,
+	IdempotencyKeyReused:     http.StatusUnprocessableEntity,
+	IdempotencyKeyInProgress: http.StatusConflict,
+}
+
+// Status is the HTTP status of code, 500 for an unknown code.
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// statusCodes are the codes of the errors that only carry an HTTP status.
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:          BadRequest,
+	http.StatusUnauthorized:        Unauthorized,
+	http.StatusForbidden:           Forbidden,
+	http.StatusNotFound:            NotFound,
+	http.StatusConflict:            Conflict,
+	http.StatusUnprocessableEntity: InvalidInput,
+	http.StatusTooManyRequests:     TooManyRequests,
+	http.StatusGatewayTimeout:      Timeout,
+}
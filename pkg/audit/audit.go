@@ -0,0 +1,413 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/model"
+	"reflect"
+	"strings"
+)
+
+// ActorKey is the context key of the Actor, a string like the x-request-id key so it is also found through
+// the gin context.
+const ActorKey = "audit-actor"
+
+const (
+	// maxRows bounds the rows recorded for one bulk update or delete.
+	maxRows = 1000
+	// beforeKey is where the rows read before an update or delete are kept on the statement.
+	beforeKey = "audit:before"
+)
+
+// skipTables are not audited, they are written by the server itself or hold secrets.
+var skipTables = map[string]bool{
+	"audit_log":         true,
+	"outbox":            true,
+	"processed_event":   true,
+	"webhook_delivery":  true,
+	"notification":      true,
+	"idempotency_key":   true,
+	"refresh_token":     true,
+	"device_token":      true,
+	"schema_migrations": true,
+
+	"company_verification_token": true,
+}
+
+// personalTables belong to a driver rather than to a company, their logs are not shown to merchants.
+var personalTables = map[string]bool{
+	"users":                   true,
+	"vehicle":                 true,
+	"favorite":                true,
+	"notification_preference": true,
+}
+
+// redactedColumns are recorded as changed without their value.
+var redactedColumns = map[string]bool{
+	"password": true,
+	"secret":   true,
+}
+
+// ignoredColumns are left out of the diff, they change on every update.
+var ignoredColumns = map[string]bool{
+	"updated_at": true,
+	"updater_id": true,
+}
+
+// companyLookups find the company of a row from its first column present, in order.
+var companyLookups = []struct {
+	column string
+	query  string
+}{
+	{"company_id", ""},
+	{"parking_lot_id", "select company_id from parking_lot where id = ?"},
+	{"block_id", "select pl.company_id from block b join parking_lot pl on pl.id = b.parking_lot_id where b.id = ?"},
+	{"parking_slot_id", `select pl.company_id from parking_slot sl join block b on b.id = sl.block_id
+							join parking_lot pl on pl.id = b.parking_lot_id where sl.id = ?`},
+	{"ticket_id", "select pl.company_id from ticket t join parking_lot pl on pl.id = t.parking_lot_id where t.id = ?"},
+}
+
+// Actor is who made the changes of a request, the system for the background jobs.
+type Actor struct {
+	ID        *uuid.UUID
+	Type      string
+	IP        string
+	RequestID string
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, ActorKey, actor)
+}
+
+func ActorFrom(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{Type: model.AuditActorSystem}
+	}
+	actor, ok := ctx.Value(ActorKey).(Actor)
+	if !ok {
+		actor = Actor{Type: model.AuditActorSystem}
+	}
+	if actor.RequestID == "" {
+		actor.RequestID, _ = ctx.Value("x-request-id").(string)
+	}
+	return actor
+}
+
+// Register records every create, update and delete made through the models of db in audit_log, in the
+// transaction of the change. Raw SQL statements are not seen by the callbacks and are not recorded.
+func Register(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("audit:before_create", beforeCreate); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", beforeUpdate); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", loadBefore); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", afterDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Schema != nil && !skipTables[db.Statement.Table]
+}
+
+// beforeCreate sets the creator and updater of the new rows to the actor when they are not set.
+func beforeCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	actor := ActorFrom(db.Statement.Context)
+	if actor.ID == nil {
+		return
+	}
+	for _, rv := range rowValues(db.Statement.ReflectValue) {
+		for _, name := range []string{"CreatorID", "UpdaterID"} {
+			field := db.Statement.Schema.LookUpField(name)
+			if field == nil {
+				continue
+			}
+			if _, zero := field.ValueOf(db.Statement.Context, rv); zero {
+				_ = field.Set(db.Statement.Context, rv, actor.ID)
+			}
+		}
+	}
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	for _, rv := range rowValues(db.Statement.ReflectValue) {
+		record(db, model.AuditActionCreate, nil, rowMap(db, rv))
+	}
+}
+
+func beforeUpdate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	if actor := ActorFrom(db.Statement.Context); actor.ID != nil && db.Statement.Schema.LookUpField("UpdaterID") != nil {
+		db.Statement.SetColumn("updater_id", actor.ID, true)
+	}
+	loadBefore(db)
+}
+
+// loadBefore reads the rows the update or delete is about to change.
+func loadBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+	stmt := db.Statement
+	tx := db.Session(&gorm.Session{NewDB: true}).Table(stmt.Table)
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+	conditions := false
+	if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+		tx = tx.Clauses(where.Expression)
+		conditions = true
+	}
+	if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+		var ids []interface{}
+		for _, rv := range rowValues(stmt.ReflectValue) {
+			if id, zero := pk.ValueOf(stmt.Context, rv); !zero {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			tx = tx.Where(clause.IN{Column: clause.Column{Table: stmt.Table, Name: pk.DBName}, Values: ids})
+			conditions = true
+		}
+	}
+	if !conditions {
+		// gorm refuses the update or delete without conditions
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := tx.Limit(maxRows).Find(rows.Interface()).Error; err != nil {
+		logger.WithCtx(stmt.Context, "audit").WithError(err).WithField("table", stmt.Table).Error("failed to read rows before change")
+		return
+	}
+	var before []map[string]interface{}
+	for i := 0; i < rows.Elem().Len(); i++ {
+		before = append(before, rowMap(db, rows.Elem().Index(i)))
+	}
+	db.InstanceSet(beforeKey, before)
+}
+
+func afterUpdate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	before := instanceRows(db)
+	pk := db.Statement.Schema.PrioritizedPrimaryField
+	if len(before) == 0 || pk == nil {
+		return
+	}
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk.DBName])
+	}
+	rows := reflect.New(reflect.SliceOf(db.Statement.Schema.ModelType))
+	if err := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table).Unscoped().
+		Where(clause.IN{Column: clause.Column{Name: pk.DBName}, Values: ids}).Find(rows.Interface()).Error; err != nil {
+		logger.WithCtx(db.Statement.Context, "audit").WithError(err).WithField("table", db.Statement.Table).Error("failed to read rows after change")
+		return
+	}
+	after := map[interface{}]map[string]interface{}{}
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rowMap(db, rows.Elem().Index(i))
+		after[row[pk.DBName]] = row
+	}
+	for _, row := range before {
+		if changed, ok := after[row[pk.DBName]]; ok {
+			record(db, model.AuditActionUpdate, row, changed)
+		}
+	}
+}
+
+func afterDelete(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	for _, row := range instanceRows(db) {
+		record(db, model.AuditActionDelete, row, nil)
+	}
+}
+
+func instanceRows(db *gorm.DB) []map[string]interface{} {
+	v, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return nil
+	}
+	rows, _ := v.([]map[string]interface{})
+	return rows
+}
+
+// rowValues returns the structs of a statement value, which may be a struct or a slice of them.
+func rowValues(rv reflect.Value) []reflect.Value {
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice, reflect.Array:
+		res := make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			res = append(res, reflect.Indirect(rv.Index(i)))
+		}
+		return res
+	}
+	return nil
+}
+
+// rowMap turns a model into its columns with their JSON values, so rows read back compare equal.
+func rowMap(db *gorm.DB, rv reflect.Value) map[string]interface{} {
+	row := map[string]interface{}{}
+	for _, field := range db.Statement.Schema.Fields {
+		if field.DBName == "" || !field.Readable {
+			continue
+		}
+		value, _ := field.ValueOf(db.Statement.Context, rv)
+		row[field.DBName] = value
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		return row
+	}
+	res := map[string]interface{}{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return row
+	}
+	for column := range redactedColumns {
+		if _, ok := res[column]; ok {
+			res[column] = redacted(res[column])
+		}
+	}
+	return res
+}
+
+// redacted hides a secret but keeps it comparable, so a change still shows in the diff.
+func redacted(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+	return "[redacted:" + hex.EncodeToString(sum[:4]) + "]"
+}
+
+func diff(before, after map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for column, to := range after {
+		if ignoredColumns[column] {
+			continue
+		}
+		if from := before[column]; !reflect.DeepEqual(from, to) {
+			res[column] = map[string]interface{}{"from": from, "to": to}
+		}
+	}
+	return res
+}
+
+func record(db *gorm.DB, action string, before, after map[string]interface{}) {
+	stmt := db.Statement
+	log := logger.WithCtx(stmt.Context, "audit").WithField("table", stmt.Table)
+
+	row := after
+	if row == nil {
+		row = before
+	}
+	entry := model.AuditLog{
+		Action:     action,
+		EntityType: stmt.Table,
+		EntityId:   entityID(stmt, row),
+		CompanyId:  companyID(db, row),
+	}
+	if action == model.AuditActionUpdate {
+		changes := diff(before, after)
+		if len(changes) == 0 {
+			return
+		}
+		entry.Diff = jsonb(changes)
+	}
+	entry.Before, entry.After = jsonb(before), jsonb(after)
+
+	actor := ActorFrom(stmt.Context)
+	entry.ActorId, entry.ActorType, entry.Ip, entry.RequestId = actor.ID, actor.Type, actor.IP, actor.RequestID
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&entry).Error; err != nil {
+		log.WithError(err).Error("failed to write audit log")
+	}
+}
+
+func entityID(stmt *gorm.Statement, row map[string]interface{}) string {
+	var ids []string
+	for _, field := range stmt.Schema.PrimaryFields {
+		ids = append(ids, fmt.Sprint(row[field.DBName]))
+	}
+	return strings.Join(ids, ",")
+}
+
+// companyID returns the company a row belongs to, nil for the rows of drivers.
+func companyID(db *gorm.DB, row map[string]interface{}) *uuid.UUID {
+	table := db.Statement.Table
+	if personalTables[table] {
+		return nil
+	}
+	if table == "company" {
+		return parseUUID(row["id"])
+	}
+	for _, lookup := range companyLookups {
+		value, ok := row[lookup.column]
+		if !ok || value == nil {
+			continue
+		}
+		if lookup.query == "" {
+			return parseUUID(value)
+		}
+		var id *uuid.UUID
+		if err := db.Session(&gorm.Session{NewDB: true}).Raw(lookup.query, value).Scan(&id).Error; err != nil {
+			logger.WithCtx(db.Statement.Context, "audit").WithError(err).WithField("table", table).Error("failed to find company")
+		}
+		return id
+	}
+	return nil
+}
+
+func parseUUID(v interface{}) *uuid.UUID {
+	s, _ := v.(string)
+	id, err := uuid.Parse(s)
+	if err != nil || id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+func jsonb(v map[string]interface{}) pgtype.JSONB {
+	if v == nil {
+		return pgtype.JSONB{Status: pgtype.Null}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return pgtype.JSONB{Status: pgtype.Null}
+	}
+	return pgtype.JSONB{Bytes: b, Status: pgtype.Present}
+}
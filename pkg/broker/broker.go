@@ -0,0 +1,83 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gitlab.com/goxp/cloud0/logger"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	KindNone = "none"
+	KindLog  = "log"
+	KindHTTP = "http"
+)
+
+// Message is an event as it leaves the service. ID is stable across retries so consumers can drop the
+// copies they already handled.
+type Message struct {
+	ID    string
+	Topic string
+	Body  []byte
+}
+
+// Broker publishes the domain events to the outside. An adapter for a message queue (Kafka, NATS...)
+// implements it next to the ones below.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// LogBroker only writes the messages to the application log.
+type LogBroker struct{}
+
+func (LogBroker) Publish(ctx context.Context, msg Message) error {
+	logger.WithCtx(ctx, "broker.LogBroker").WithField("topic", msg.Topic).WithField("id", msg.ID).Info(string(msg.Body))
+	return nil
+}
+
+// HTTPBroker posts every message to an ingestion endpoint, the topic and id go in the headers.
+type HTTPBroker struct {
+	URL    string
+	Client *http.Client
+}
+
+func (b *HTTPBroker) Publish(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, bytes.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Topic", msg.Topic)
+	req.Header.Set("X-Event-Id", msg.ID)
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("broker answered %s", resp.Status)
+	}
+	return nil
+}
+
+// NewBroker returns the broker of kind, nil when kind is none or empty as publishing outside is optional.
+func NewBroker(kind, url string) (Broker, error) {
+	switch kind {
+	case KindNone, "":
+		return nil, nil
+	case KindLog:
+		return LogBroker{}, nil
+	case KindHTTP:
+		if url == "" {
+			return nil, fmt.Errorf("the http broker needs an url")
+		}
+		return &HTTPBroker{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown event broker %s", kind)
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
+)
+
+type AdminHandler struct {
+	service service.AdminInterface
+}
+
+func NewAdminHandler(service service.AdminInterface) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// GetListCompany
+// @Tags		Admin
+// @Summary		Get the companies of the platform
+// @Description	status is one of pending_email, pending_review, active, suspended. search matches the name, email or phone number.
+// @Produce		json
+// @Param		data			query		model.AdminListCompanyReq	true	"data"
+// @Success		200				{object}	model.AdminListCompanyRes
+// @Router		/api/admin/company/get-list [get]
+func (h *AdminHandler) GetListCompany(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.AdminListCompanyReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListCompany(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// ApproveCompany
+// @Tags		Admin
+// @Summary		Approve a company pending review or reinstate a suspended one
+// @Description	The email of the company must be verified and a business_license document uploaded.
+// @Produce		json
+// @Param		id				path		string	true	"company id"
+// @Success		200				{object}	model.Company
+// @Router		/api/admin/company/approve/{id} [put]
+func (h *AdminHandler) ApproveCompany(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	res, err := h.service.ApproveCompany(r.Context(), valid.UUID(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// SuspendCompany
+// @Tags		Admin
+// @Summary		Suspend a company, it cannot log in and its parking lots are hidden until it is approved again
+// @Accept		json
+// @Produce		json
+// @Param		id				path		string					true	"company id"
+// @Param		data			body		model.CompanyStatusReq	false	"data"
+// @Success		200				{object}	model.Company
+// @Router		/api/admin/company/suspend/{id} [put]
+func (h *AdminHandler) SuspendCompany(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	var req model.CompanyStatusReq
+	if r.GinCtx.Request.ContentLength != 0 {
+		if err := r.GinCtx.BindJSON(&req); err != nil {
+			log.WithError(err).Error("error_400: Error when get parse req")
+			return nil, apperror.Wrap(apperror.InvalidInput, err)
+		}
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.SuspendCompany(r.Context(), valid.UUID(id), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListUser
+// @Tags		Admin
+// @Summary		Get the users of the platform
+// @Description	search matches the display name, email or phone number. role keeps the users having every flag of it.
+// @Produce		json
+// @Param		data			query		model.AdminListUserReq	true	"data"
+// @Success		200				{object}	model.AdminListUserRes
+// @Router		/api/admin/user/get-list [get]
+func (h *AdminHandler) GetListUser(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.AdminListUserReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListUser(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// ImpersonateUser
+// @Tags		Admin
+// @Summary		Get a token to act as a user for support
+// @Description	The token is valid for 15 minutes and carries the admin in its act claim. It is recorded in the audit log.
+// @Description	It is sent as Authorization: Bearer to /api/v1, the changes made with it are logged as made by the admin.
+// @Produce		json
+// @Param		id				path		string	true	"user id"
+// @Success		200				{object}	model.ImpersonateRes
+// @Router		/api/admin/user/impersonate/{id} [post]
+func (h *AdminHandler) ImpersonateUser(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	adminID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Wrong user id")
+		return nil, apperror.Wrap(apperror.Unauthorized, err)
+	}
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	res, err := h.service.ImpersonateUser(r.Context(), adminID, valid.UUID(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetPlatformStats
+// @Tags		Admin
+// @Summary		Get the counts of the platform
+// @Description	tickets and revenue are those of the tickets starting between from, inclusive, and to, exclusive,
+// @Description	the last 30 days by default.
+// @Produce		json
+// @Param		data			query		model.PlatformStatsReq	false	"data"
+// @Success		200				{object}	model.PlatformStats
+// @Router		/api/admin/stats [get]
+func (h *AdminHandler) GetPlatformStats(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.PlatformStatsReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetPlatformStats(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListGlobalSetting
+// @Tags		Admin
+// @Summary		Get the settings stored for every company
+// @Produce		json
+// @Success		200				{object}	[]model.Setting
+// @Router		/api/admin/setting/get-list [get]
+func (h *AdminHandler) GetListGlobalSetting(r *ginext.Request) (*ginext.Response, error) {
+	res, err := h.service.GetListGlobalSetting(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// UpsertGlobalSetting
+// @Tags		Admin
+// @Summary		Set a setting for every company
+// @Description	The settings of a company or a parking lot take precedence. The keys are those of /api/merchant/setting/upsert.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.GlobalSettingReq	true	"data"
+// @Success		200				{object}	model.Setting
+// @Router		/api/admin/setting/upsert [put]
+func (h *AdminHandler) UpsertGlobalSetting(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.GlobalSettingReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.UpsertGlobalSetting(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteGlobalSetting
+// @Tags		Admin
+// @Summary		Delete a setting stored for every company
+// @Produce		json
+// @Param		key				path		string	true	"setting key"
+// @Success		200
+// @Router		/api/admin/setting/delete/{key} [delete]
+func (h *AdminHandler) DeleteGlobalSetting(r *ginext.Request) (*ginext.Response, error) {
+	if err := h.service.DeleteGlobalSetting(r.Context(), r.GinCtx.Param("key")); err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponse(http.StatusOK), nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+)
+
+type AuditHandler struct {
+	service service.AuditInterface
+}
+
+func NewAuditHandler(service service.AuditInterface) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetListAuditLog
+// @Tags		Audit
+// @Summary		Get the changes made to the entities of a company
+// @Description	entityType is the table of the entity, such as parking_lot, parking_slot or ticket. action is create,
+// @Description	update or delete and actorType is user, merchant, admin or system. from is inclusive and to exclusive.
+// @Produce		json
+// @Param		data			query		model.ListAuditLogReq	true	"data"
+// @Success		200				{object}	model.ListAuditLogRes
+// @Router		/api/merchant/audit-log/get-list [get]
+func (h *AuditHandler) GetListAuditLog(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ListAuditLogReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListAuditLog(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
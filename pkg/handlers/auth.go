@@ -4,9 +4,11 @@ import (
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
 )
 
 type AuthHandler struct {
@@ -27,12 +29,12 @@ func (h *AuthHandler) Login(r *ginext.Request) (*ginext.Response, error) {
 	req := model.Credential{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Invalid input")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid input: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid req
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Cần nhập đầy đủ thông tin")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	rs, err := h.service.Login(r.GinCtx, req)
@@ -48,12 +50,12 @@ func (h *AuthHandler) ResetPassword(r *ginext.Request) (*ginext.Response, error)
 	req := model.Credential{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Invalid input")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid input: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid req
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Cần nhập đầy đủ thông tin")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	err := h.service.ResetPassword(r.GinCtx, req)
 	if err != nil {
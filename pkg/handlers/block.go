@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type BlockHandler struct {
@@ -26,11 +27,11 @@ func (h *BlockHandler) CreateBlock(r *ginext.Request) (*ginext.Response, error)
 	var req model.BlockReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateBlock(r.Context(), req)
@@ -49,11 +50,11 @@ func (h *BlockHandler) GetListBlock(r *ginext.Request) (*ginext.Response, error)
 	var req model.ListBlockReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetListBlock(r.Context(), req)
@@ -74,7 +75,7 @@ func (h *BlockHandler) GetOneBlock(r *ginext.Request) (*ginext.Response, error)
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneBlock(r.Context(), valid.UUID(id))
@@ -92,18 +93,18 @@ func (h *BlockHandler) UpdateBlock(r *ginext.Request) (*ginext.Response, error)
 	var req model.BlockReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	// parse id
 	req.ID = utils.ParseIDFromUri(r.GinCtx)
 	if req.ID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateBlock(r.Context(), req)
@@ -123,15 +124,50 @@ func (h *BlockHandler) DeleteBlock(r *ginext.Request) (*ginext.Response, error)
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "ID không hợp lệ")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
-	err := h.service.DeleteBlock(r.Context(), valid.UUID(id))
+	var req model.DeleteReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.DeleteBlock(r.Context(), valid.UUID(id), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
 		Data: "Xóa bản ghi thành công",
+		Meta: res.Meta(),
+	}}, nil
+}
+
+func (h *BlockHandler) GetBlockSlotDrift(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.BlockSlotDriftReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetBlockSlotDrift(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res,
 	}}, nil
 }
@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
+	"github.com/google/uuid"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type CompanyHandler struct {
@@ -26,11 +28,11 @@ func (h *CompanyHandler) CreateCompany(r *ginext.Request) (*ginext.Response, err
 	var req model.CompanyReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateCompany(r.Context(), req)
@@ -48,11 +50,11 @@ func (h *CompanyHandler) Login(r *ginext.Request) (*ginext.Response, error) {
 	var req model.LoginReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.LoginCompany(r.Context(), valid.String(req.Email), valid.String(req.Password))
@@ -70,7 +72,7 @@ func (h *CompanyHandler) GetOneCompany(r *ginext.Request) (*ginext.Response, err
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneCompany(r.Context(), valid.UUID(id))
@@ -87,17 +89,17 @@ func (h *CompanyHandler) UpdateCompany(r *ginext.Request) (*ginext.Response, err
 	var req model.CompanyReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateCompany(r.Context(), valid.UUID(id), req)
@@ -114,17 +116,17 @@ func (h *CompanyHandler) UpdateCompanyPassword(r *ginext.Request) (*ginext.Respo
 	var req model.PasswordChangeReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateCompanyPassword(r.Context(), valid.UUID(id), req)
@@ -134,3 +136,148 @@ func (h *CompanyHandler) UpdateCompanyPassword(r *ginext.Request) (*ginext.Respo
 
 	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
 }
+
+// VerifyCompanyEmail
+// @Tags		Company
+// @Summary		Verify the email of a company with the token of the link sent to it
+// @Description	The company is then pending the review of an admin. The token is single-use and valid for 24 hours.
+// @Produce		json
+// @Param		data			query		model.VerifyCompanyEmailReq	true	"data"
+// @Success		200				{object}	model.Company
+// @Router		/api/merchant/company/verify-email [get]
+func (h *CompanyHandler) VerifyCompanyEmail(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.VerifyCompanyEmailReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.VerifyCompanyEmail(r.Context(), valid.String(req.Token))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// ResendCompanyVerification
+// @Tags		Company
+// @Summary		Send a new email verification link to a company
+// @Description	The answer is the same whether the email is registered or not.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.ResendCompanyVerificationReq	true	"data"
+// @Success		200
+// @Router		/api/merchant/company/resend-verification [post]
+func (h *CompanyHandler) ResendCompanyVerification(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ResendCompanyVerificationReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	if err := h.service.ResendCompanyVerification(r.Context(), valid.String(req.Email)); err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponse(http.StatusOK), nil
+}
+
+// CreateCompanyDocument
+// @Tags		Company
+// @Summary		Record the metadata of a document of a company
+// @Description	The file is uploaded to url by the client. type is business_license, tax_registration or other, a
+// @Description	business license is required for the company to be approved. size is in bytes, at most 10 MB.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.CompanyDocumentReq	true	"data"
+// @Success		200				{object}	model.CompanyDocument
+// @Router		/api/merchant/company/document/create [post]
+func (h *CompanyHandler) CreateCompanyDocument(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.CompanyDocumentReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.CreateCompanyDocument(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListCompanyDocument
+// @Tags		Company
+// @Summary		Get the documents of a company
+// @Produce		json
+// @Param		data			query		model.ListCompanyDocumentReq	true	"data"
+// @Success		200				{object}	[]model.CompanyDocument
+// @Router		/api/merchant/company/document/get-list [get]
+func (h *CompanyHandler) GetListCompanyDocument(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ListCompanyDocumentReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListCompanyDocument(r.Context(), uuid.MustParse(valid.String(req.CompanyId)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteCompanyDocument
+// @Tags		Company
+// @Summary		Delete a document of a company
+// @Produce		json
+// @Param		id				path		string	true	"document id"
+// @Success		200
+// @Router		/api/merchant/company/document/delete/{id} [delete]
+func (h *CompanyHandler) DeleteCompanyDocument(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	if err := h.service.DeleteCompanyDocument(r.Context(), valid.UUID(id)); err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponse(http.StatusOK), nil
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+	"time"
+)
+
+type ExportHandler struct {
+	service service.ExportInterface
+}
+
+func NewExportHandler(service service.ExportInterface) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// export writes the file straight to the response. Once the first bytes are out the status can no
+// longer change, so an error after that only aborts the stream.
+func (h *ExportHandler) export(r *ginext.Request, name string, format string, fn func(w utils.TableWriter) error) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	writer := r.GinCtx.Writer
+	filename := fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102150405"), format)
+	writer.Header().Set("Content-Type", utils.ExportContentType(format))
+	writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	w := utils.NewTableWriter(writer, format)
+	if err := fn(w); err != nil {
+		w.Abort()
+		if !writer.Written() {
+			writer.Header().Del("Content-Type")
+			writer.Header().Del("Content-Disposition")
+			return nil, err
+		}
+		log.WithError(err).Error("error_500: export aborted after streaming started")
+		r.GinCtx.Abort()
+	}
+	return nil, nil
+}
+
+// ExportTicketCompany
+// @Tags		Export
+// @Summary		Export tickets of a parking lot as csv or xlsx
+// @Produce		octet-stream
+// @Param		data			query		model.ExportTicketReq		true	"data"
+// @Success		200				{file}		file
+// @Router		/api/merchant/ticket/export [get]
+func (h *ExportHandler) ExportTicketCompany(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	var req model.ExportTicketReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	format, err := h.service.CheckFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.export(r, "tickets", format, func(w utils.TableWriter) error {
+		return h.service.ExportTicketCompany(r.Context(), req, w)
+	})
+}
+
+// ExportReport
+// @Tags		Export
+// @Summary		Export a report (revenue, occupancy, ticket-stats, top-time-frames) as csv or xlsx
+// @Produce		octet-stream
+// @Param		data			query		model.ExportReportReq		true	"data"
+// @Success		200				{file}		file
+// @Router		/api/merchant/reports/export [get]
+func (h *ExportHandler) ExportReport(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	var req model.ExportReportReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	format, err := h.service.CheckFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.export(r, "report_"+req.Report, format, func(w utils.TableWriter) error {
+		return h.service.ExportReport(r.Context(), req, w)
+	})
+}
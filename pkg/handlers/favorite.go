@@ -4,10 +4,12 @@ import (
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type FavoriteHandler struct {
@@ -30,12 +32,12 @@ func (h *FavoriteHandler) Create(r *ginext.Request) (*ginext.Response, error) {
 	req := model.FavoriteRequest{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.Create(r.Context(), req)
 	if err != nil {
@@ -48,12 +50,12 @@ func (h *FavoriteHandler) GetOneFavoriteParking(r *ginext.Request) (*ginext.Resp
 	req := model.FavoriteRequestV2{}
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.GetOne(r.Context(), req)
 	if err != nil {
@@ -66,7 +68,11 @@ func (h *FavoriteHandler) GetAllFavoriteParkingByUser(r *ginext.Request) (*ginex
 	req := model.FavoriteRequestV2{}
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.GetAllFavoriteParkingByUser(r.Context(), valid.String(req.UserId))
 	if err != nil {
@@ -79,7 +85,7 @@ func (h *FavoriteHandler) DeleteOne(r *ginext.Request) (*ginext.Response, error)
 	favoriteID := utils.ParseIDFromUri(r.GinCtx)
 	if favoriteID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	if err := h.service.DeleteOne(r.Context(), valid.UUID(favoriteID)); err != nil {
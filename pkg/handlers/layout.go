@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"io"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+	"path/filepath"
+	"strings"
+)
+
+const maxLayoutFileSize = 5 << 20
+
+type LayoutHandler struct {
+	service service.LayoutInterface
+}
+
+func NewLayoutHandler(service service.LayoutInterface) *LayoutHandler {
+	return &LayoutHandler{service: service}
+}
+
+// layoutFormat guesses the format of an upload from its file name or content type.
+func layoutFormat(name string, contentType string) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")) {
+	case "csv":
+		return model.LayoutFormatCSV
+	case "json":
+		return model.LayoutFormatJSON
+	case "yaml", "yml":
+		return model.LayoutFormatYAML
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		return model.LayoutFormatJSON
+	case strings.Contains(contentType, "yaml"):
+		return model.LayoutFormatYAML
+	}
+	return model.LayoutFormatCSV
+}
+
+// ImportLayout
+// @Tags		Layout
+// @Summary		Import the blocks and slots of a parking lot from a csv, json or yaml file
+// @Description	The file is sent as the multipart field "file" or as the raw body. With dry_run the diff is returned without applying it.
+// @Accept		mpfd
+// @Produce		json
+// @Param		data			query		model.ImportLayoutReq		true	"data"
+// @Param		file			formData	file						false	"layout file"
+// @Success		200				{object}	model.ImportLayoutRes
+// @Router		/api/merchant/parking-lot/import-layout [post]
+func (h *LayoutHandler) ImportLayout(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	var req model.ImportLayoutReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	var (
+		body io.Reader
+		name string
+	)
+	r.GinCtx.Request.Body = http.MaxBytesReader(r.GinCtx.Writer, r.GinCtx.Request.Body, maxLayoutFileSize)
+	if strings.HasPrefix(r.GinCtx.ContentType(), "multipart/") {
+		file, err := r.GinCtx.FormFile("file")
+		if err != nil {
+			log.WithError(err).Error("error_400: missing layout file")
+			return nil, apperror.Wrap(apperror.LayoutFileRequired, err)
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, apperror.Wrap(apperror.InvalidInput, err)
+		}
+		defer f.Close()
+		body, name = f, file.Filename
+	} else {
+		body = r.GinCtx.Request.Body
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		log.WithError(err).Error("error_400: failed to read layout file")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	format := req.Format
+	if format == "" {
+		format = layoutFormat(name, r.GinCtx.ContentType())
+	}
+	layout, err := h.service.ParseLayout(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.service.ImportLayout(r.Context(), req, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+)
+
+type NotificationHandler struct {
+	service service.NotificationInterface
+}
+
+func NewNotificationHandler(service service.NotificationInterface) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetListNotification
+// @Tags		Notification
+// @Summary		List the notifications sent to the current user
+// @Produce		json
+// @Param		data			query		model.ListNotificationReq	true	"data"
+// @Success		200				{object}	model.ListNotificationRes
+// @Router		/api/v1/notification/get-list [get]
+func (h *NotificationHandler) GetListNotification(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.ListNotificationReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.GetListNotification(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// GetNotificationPreference
+// @Tags		Notification
+// @Summary		Get the notification channels and language of the current user
+// @Produce		json
+// @Success		200				{object}	model.NotificationPreference
+// @Router		/api/v1/notification/preference [get]
+func (h *NotificationHandler) GetNotificationPreference(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	res, err := h.service.GetNotificationPreference(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// UpdateNotificationPreference
+// @Tags		Notification
+// @Summary		Choose the notification channels and language of the current user
+// @Description	language is vi or en.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.NotificationPreferenceReq	true	"data"
+// @Success		200				{object}	model.NotificationPreference
+// @Router		/api/v1/notification/preference [put]
+func (h *NotificationHandler) UpdateNotificationPreference(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.NotificationPreferenceReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.UpdateNotificationPreference(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// RegisterDeviceToken
+// @Tags		Notification
+// @Summary		Register a push token of a device of the current user
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.DeviceTokenReq	true	"data"
+// @Success		200				{object}	model.DeviceToken
+// @Router		/api/v1/notification/device-token [post]
+func (h *NotificationHandler) RegisterDeviceToken(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.DeviceTokenReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.RegisterDeviceToken(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteDeviceToken
+// @Tags		Notification
+// @Summary		Stop push notifications to a device, on logout
+// @Produce		json
+// @Param		data			query		model.DeviceTokenReq	true	"data"
+// @Success		200				{string}	success
+// @Router		/api/v1/notification/device-token [delete]
+func (h *NotificationHandler) DeleteDeviceToken(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.DeviceTokenReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	if err := h.service.DeleteDeviceToken(r.Context(), req); err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: "Xóa bản ghi thành công",
+	}}, nil
+}
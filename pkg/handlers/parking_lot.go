@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type ParkingLotHandler struct {
@@ -26,11 +27,11 @@ func (h *ParkingLotHandler) CreateParkingLot(r *ginext.Request) (*ginext.Respons
 	var req model.ParkingLotReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateParkingLot(r.Context(), req)
@@ -49,11 +50,11 @@ func (h *ParkingLotHandler) GetListParkingLot(r *ginext.Request) (*ginext.Respon
 	var req model.ListParkingLotReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetListParkingLot(r.Context(), req)
@@ -74,7 +75,7 @@ func (h *ParkingLotHandler) GetOneParkingLot(r *ginext.Request) (*ginext.Respons
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneParkingLot(r.Context(), valid.UUID(id))
@@ -92,18 +93,18 @@ func (h *ParkingLotHandler) UpdateParkingLot(r *ginext.Request) (*ginext.Respons
 	var req model.ParkingLotReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	// parse id
 	req.ID = utils.ParseIDFromUri(r.GinCtx)
 	if req.ID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateParkingLot(r.Context(), req)
@@ -123,16 +124,27 @@ func (h *ParkingLotHandler) DeleteParkingLot(r *ginext.Request) (*ginext.Respons
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "ID không hợp lệ")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
-	err := h.service.DeleteParkingLot(r.Context(), valid.UUID(id))
+	var req model.DeleteReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.DeleteParkingLot(r.Context(), valid.UUID(id), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
 		Data: "Xóa bản ghi thành công",
+		Meta: res.Meta(),
 	}}, nil
 }
 
@@ -143,11 +155,11 @@ func (h *ParkingLotHandler) GetListParkingLotCompany(r *ginext.Request) (*ginext
 	var req model.GetListParkingLotReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetListParkingLotCompany(r.Context(), req)
@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type ParkingSlotHandler struct {
@@ -35,18 +36,18 @@ func (h *ParkingSlotHandler) CreateParkingSlot(r *ginext.Request) (*ginext.Respo
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.ParkingSlotReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateParkingSlot(r.Context(), req)
@@ -75,18 +76,18 @@ func (h *ParkingSlotHandler) GetListParkingSlot(r *ginext.Request) (*ginext.Resp
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.ListParkingSlotReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetListParkingSlot(r.Context(), req)
@@ -117,14 +118,14 @@ func (h *ParkingSlotHandler) GetOneParkingSlot(r *ginext.Request) (*ginext.Respo
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneParkingSlot(r.Context(), valid.UUID(id))
@@ -153,25 +154,25 @@ func (h *ParkingSlotHandler) UpdateParkingSlot(r *ginext.Request) (*ginext.Respo
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.ParkingSlotReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	// parse id
 	req.ID = utils.ParseIDFromUri(r.GinCtx)
 	if req.ID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateParkingSlot(r.Context(), req)
@@ -192,6 +193,7 @@ func (h *ParkingSlotHandler) UpdateParkingSlot(r *ginext.Request) (*ginext.Respo
 // @Produce		json
 // @Param		x-user-id		header		string	true	"user id"
 // @Param		id				path		string	true	"id"
+// @Param		force			query		bool	false	"relocate or cancel the future tickets of the slot"
 // @Success		200				{string}	success
 // @Router		/api/v1/parking-slot/delete/:id 	[delete]
 func (h *ParkingSlotHandler) DeleteParkingSlot(r *ginext.Request) (*ginext.Response, error) {
@@ -201,23 +203,34 @@ func (h *ParkingSlotHandler) DeleteParkingSlot(r *ginext.Request) (*ginext.Respo
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "ID không hợp lệ")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
-	err = h.service.DeleteParkingSlot(r.Context(), valid.UUID(id))
+	var req model.DeleteReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.DeleteParkingSlot(r.Context(), valid.UUID(id), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
 		Data: "Xóa bản ghi thành công",
+		Meta: res.Meta(),
 	}}, nil
 }
 
@@ -225,22 +238,23 @@ func (h *ParkingSlotHandler) GetAvailableParkingSlot(r *ginext.Request) (*ginext
 	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
 
 	// check x-user-id
-	_, err := utils.CurrentUser(r.GinCtx.Request)
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.AvailableParkingSlotReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
+	req.UserId = &userID
 
 	res, err := h.service.GetAvailableParkingSlot(r.Context(), req)
 	if err != nil {
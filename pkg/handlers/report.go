@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+)
+
+type ReportHandler struct {
+	service service.ReportInterface
+}
+
+func NewReportHandler(service service.ReportInterface) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+func (h *ReportHandler) bindReportReq(r *ginext.Request) (req model.ReportReq, err error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return req, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return req, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	return req, nil
+}
+
+// GetRevenueReport
+// @Tags		Report
+// @Summary		Revenue per day/week/month, optionally grouped by parking lot or block
+// @Produce		json
+// @Param		data			query		model.ReportReq				true	"data"
+// @Success		200				{object}	[]model.RevenueReportItem
+// @Router		/api/merchant/reports/revenue [get]
+func (h *ReportHandler) GetRevenueReport(r *ginext.Request) (*ginext.Response, error) {
+	req, err := h.bindReportReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.service.GetRevenueReport(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
+
+// GetOccupancyReport
+// @Tags		Report
+// @Summary		Occupancy rate per day/week/month
+// @Produce		json
+// @Param		data			query		model.ReportReq				true	"data"
+// @Success		200				{object}	[]model.OccupancyReportItem
+// @Router		/api/merchant/reports/occupancy [get]
+func (h *ReportHandler) GetOccupancyReport(r *ginext.Request) (*ginext.Response, error) {
+	req, err := h.bindReportReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.service.GetOccupancyReport(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
+
+// GetTicketStatsReport
+// @Tags		Report
+// @Summary		Average stay, extension, cancellation and no-show rates per day/week/month
+// @Produce		json
+// @Param		data			query		model.ReportReq				true	"data"
+// @Success		200				{object}	[]model.TicketStatsReportItem
+// @Router		/api/merchant/reports/ticket-stats [get]
+func (h *ReportHandler) GetTicketStatsReport(r *ginext.Request) (*ginext.Response, error) {
+	req, err := h.bindReportReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.service.GetTicketStatsReport(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
+
+// GetTopTimeFrameReport
+// @Tags		Report
+// @Summary		Most booked time frames
+// @Produce		json
+// @Param		data			query		model.ReportReq				true	"data"
+// @Success		200				{object}	[]model.TimeFrameReportItem
+// @Router		/api/merchant/reports/top-time-frames [get]
+func (h *ReportHandler) GetTopTimeFrameReport(r *ginext.Request) (*ginext.Response, error) {
+	req, err := h.bindReportReq(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := h.service.GetTopTimeFrameReport(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+)
+
+type SearchHandler struct {
+	service service.SearchServiceInterface
+}
+
+func NewSearchHandler(service service.SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// SearchParkingLot
+// @Tags		ParkingLot
+// @Summary		Search ParkingLot by text and location
+// @Accept		json
+// @Produce		json
+// @Param		data			query		model.SearchParkingLotReq	true	"data"
+// @Success		200				{object}	model.SearchParkingLotRes
+// @Router		/api/v1/parking-lot/search [get]
+func (h *SearchHandler) SearchParkingLot(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.SearchParkingLotReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.SearchParkingLot(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/validation"
+)
+
+type SettingHandler struct {
+	service service.SettingInterface
+}
+
+func NewSettingHandler(service service.SettingInterface) *SettingHandler {
+	return &SettingHandler{service: service}
+}
+
+// GetSetting
+// @Tags		Setting
+// @Summary		Get the setting in effect for a parking lot
+// @Produce		json
+// @Param		data			query		model.GetSettingReq	true	"data"
+// @Success		200				{object}	model.Setting
+// @Router		/api/merchant/setting/get-one [get]
+func (h *SettingHandler) GetSetting(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.GetSettingReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetSetting(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// UpsertSetting
+// @Tags		Setting
+// @Summary		Set a setting of a parking lot
+// @Description	slot_assignment_strategy is one of fill_by_block, nearest_entrance, balance_wear, vehicle_type_fit.
+// @Description	slot_hold_ttl is the checkout hold time in seconds, 300 by default.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.SettingReq	true	"data"
+// @Success		200				{object}	model.Setting
+// @Router		/api/merchant/setting/upsert [put]
+func (h *SettingHandler) UpsertSetting(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.SettingReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.UpsertSetting(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
+)
+
+type SlotDowntimeHandler struct {
+	service service.SlotDowntimeInterface
+}
+
+func NewSlotDowntimeHandler(service service.SlotDowntimeInterface) *SlotDowntimeHandler {
+	return &SlotDowntimeHandler{service: service}
+}
+
+// CreateSlotDowntime
+// @Tags		SlotDowntime
+// @Summary		Close a slot or a block for a time window
+// @Description	Bookings inside the window are refused with 409 unless relocate is set.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.SlotDowntimeReq	true	"data"
+// @Success		200				{object}	model.SlotDowntimeRes
+// @Router		/api/merchant/slot-downtime/create [post]
+func (h *SlotDowntimeHandler) CreateSlotDowntime(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.SlotDowntimeReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.CreateSlotDowntime(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListSlotDowntime
+// @Tags		SlotDowntime
+// @Summary		List the downtime windows of a parking lot
+// @Produce		json
+// @Param		data			query		model.ListSlotDowntimeReq	true	"data"
+// @Success		200				{object}	model.ListSlotDowntimeRes
+// @Router		/api/merchant/slot-downtime/get-list [get]
+func (h *SlotDowntimeHandler) GetListSlotDowntime(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ListSlotDowntimeReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListSlotDowntime(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// UpdateSlotDowntime
+// @Tags		SlotDowntime
+// @Summary		Change the window, target or reason of a downtime
+// @Accept		json
+// @Produce		json
+// @Param		id				path		string					true	"id"
+// @Param		data			body		model.SlotDowntimeReq	true	"data"
+// @Success		200				{object}	model.SlotDowntimeRes
+// @Router		/api/merchant/slot-downtime/update/:id [put]
+func (h *SlotDowntimeHandler) UpdateSlotDowntime(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.SlotDowntimeReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	// parse id
+	req.ID = utils.ParseIDFromUri(r.GinCtx)
+	if req.ID == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	res, err := h.service.UpdateSlotDowntime(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteSlotDowntime
+// @Tags		SlotDowntime
+// @Summary		Reopen the slots of a downtime
+// @Produce		json
+// @Param		id				path		string	true	"id"
+// @Success		200				{string}	success
+// @Router		/api/merchant/slot-downtime/delete/:id [delete]
+func (h *SlotDowntimeHandler) DeleteSlotDowntime(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	if err := h.service.DeleteSlotDowntime(r.Context(), valid.UUID(id)); err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: "Xóa bản ghi thành công",
+	}}, nil
+}
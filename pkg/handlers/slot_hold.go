@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
+)
+
+type SlotHoldHandler struct {
+	service service.SlotHoldInterface
+}
+
+func NewSlotHoldHandler(service service.SlotHoldInterface) *SlotHoldHandler {
+	return &SlotHoldHandler{service: service}
+}
+
+// CreateSlotHold
+// @Tags		SlotHold
+// @Summary		Hold a slot while the user checks out
+// @Description	Without parkingSlotId the slot is chosen by the assignment strategy of the parking lot.
+// @Description	Pass the id of the hold as slotHoldId when creating the ticket.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.SlotHoldReq	true	"data"
+// @Success		200				{object}	model.SlotHold
+// @Router		/api/v1/slot-hold/create [post]
+func (h *SlotHoldHandler) CreateSlotHold(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	// parse & check valid request
+	var req model.SlotHoldReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.CreateSlotHold(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteSlotHold
+// @Tags		SlotHold
+// @Summary		Give back a held slot
+// @Produce		json
+// @Param		id				path		string	true	"id"
+// @Success		200				{string}	success
+// @Router		/api/v1/slot-hold/delete/:id [delete]
+func (h *SlotHoldHandler) DeleteSlotHold(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	if err := h.service.DeleteSlotHold(r.Context(), valid.UUID(id), userID); err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: "Xóa bản ghi thành công",
+	}}, nil
+}
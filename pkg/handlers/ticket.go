@@ -4,10 +4,12 @@ import (
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type TicketHandler struct {
@@ -26,6 +28,8 @@ type TicketHandlerInterface interface {
 	CancelTicket(r *ginext.Request) (*ginext.Response, error)
 	ExtendTicket(r *ginext.Request) (*ginext.Response, error)
 	GetAllTicketCompany(r *ginext.Request) (*ginext.Response, error)
+	ReassignTicket(r *ginext.Request) (*ginext.Response, error)
+	ReassignTicketCompany(r *ginext.Request) (*ginext.Response, error)
 }
 
 func (h *TicketHandler) CreateTicket(r *ginext.Request) (*ginext.Response, error) {
@@ -34,12 +38,17 @@ func (h *TicketHandler) CreateTicket(r *ginext.Request) (*ginext.Response, error
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 	req := model.TicketReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	//check valid
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.CreateTicket(r.Context(), &req)
 	if err != nil {
@@ -52,7 +61,12 @@ func (h *TicketHandler) ProcedureWithTicket(r *ginext.Request) (*ginext.Response
 	req := model.ProcedureReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	//check valid
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.ProcedureWithTicket(r.Context(), &req)
 	if err != nil {
@@ -66,12 +80,17 @@ func (h *TicketHandler) ExtendTicket(r *ginext.Request) (*ginext.Response, error
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 	req := model.ExtendTicketReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	//check valid
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.ExtendTicket(r.Context(), &req)
 	if err != nil {
@@ -84,18 +103,21 @@ func (h *TicketHandler) GetAllTicket(r *ginext.Request) (*ginext.Response, error
 	req := model.GetListTicketParam{}
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.GetAllTicket(r.Context(), req)
 	if err != nil {
 		return nil, err
 	}
-	return ginext.NewResponseData(http.StatusOK, res), nil
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
 }
 func (h *TicketHandler) GetOneTicketWithExtend(r *ginext.Request) (*ginext.Response, error) {
 	log := logger.WithCtx(r.GinCtx, utils.GetCurrentCaller(h, 0))
@@ -104,7 +126,7 @@ func (h *TicketHandler) GetOneTicketWithExtend(r *ginext.Request) (*ginext.Respo
 
 	if ticketId == nil {
 		log.Error("Ticket id is required")
-		return nil, ginext.NewError(http.StatusBadRequest, "Ticket id is required")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 	res, err := h.service.GetOneTicketWithExtend(r.Context(), valid.UUID(ticketId).String())
 	if err != nil {
@@ -117,7 +139,12 @@ func (h *TicketHandler) CancelTicket(r *ginext.Request) (*ginext.Response, error
 	req := model.CancelTicketRequest{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	//check valid
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	err := h.service.CancelTicket(r.Context(), req.TicketId)
 	if err != nil {
@@ -126,18 +153,86 @@ func (h *TicketHandler) CancelTicket(r *ginext.Request) (*ginext.Response, error
 	return ginext.NewResponseData(http.StatusOK, req.TicketId), nil
 }
 
+// ReassignTicket
+// @Tags		Ticket
+// @Summary		Move a future ticket of the user and its extensions to another slot
+// @Description	Without parkingSlotId the slot is chosen by the assignment strategy of the parking lot.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.ReassignTicketReq	true	"data"
+// @Success		200				{object}	[]model.RelocatedTicket
+// @Router		/api/v1/ticket/reassign [put]
+func (h *TicketHandler) ReassignTicket(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.GinCtx, utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	req := model.ReassignTicketReq{}
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("Error when parse req!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId, req.CompanyId = &userID, nil
+	res, err := h.service.ReassignTicket(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
+
+// ReassignTicketCompany
+// @Tags		Ticket
+// @Summary		Move a future ticket and its extensions to another slot
+// @Description	Without parkingSlotId the slot is chosen by the assignment strategy of the parking lot.
+// @Description	companyId is required, the ticket must be in one of its parking lots.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.ReassignTicketReq	true	"data"
+// @Success		200				{object}	[]model.RelocatedTicket
+// @Router		/api/merchant/ticket/reassign [put]
+func (h *TicketHandler) ReassignTicketCompany(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.GinCtx, utils.GetCurrentCaller(h, 0))
+
+	req := model.ReassignTicketReq{}
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("Error when parse req!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("Invalid data!")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if req.CompanyId == nil {
+		log.Error("Invalid data!")
+		return nil, apperror.Invalid(apperror.FieldError{Field: "companyId", Rule: "required"})
+	}
+	res, err := h.service.ReassignTicket(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+	return ginext.NewResponseData(http.StatusOK, res), nil
+}
+
 func (h *TicketHandler) GetAllTicketCompany(r *ginext.Request) (*ginext.Response, error) {
 	log := logger.WithCtx(r.GinCtx, utils.GetCurrentCaller(h, 0))
 
 	req := model.GetListTicketReq{}
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetAllTicketCompany(r.Context(), req)
@@ -145,5 +240,8 @@ func (h *TicketHandler) GetAllTicketCompany(r *ginext.Request) (*ginext.Response
 		return nil, err
 	}
 
-	return ginext.NewResponseData(http.StatusOK, res), nil
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
 }
@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type TimeFrameHandler struct {
@@ -35,12 +36,12 @@ func (h *TimeFrameHandler) GetAllTimeFrame(r *ginext.Request) (*ginext.Response,
 	var req model.GetListTimeFrameParam
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.GetAllTimeFrame(r.GinCtx, req)
 	if err != nil {
@@ -55,11 +56,15 @@ func (h *TimeFrameHandler) Create(r *ginext.Request) (*ginext.Response, error) {
 	req := model.ListTimeFrameReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	if len(req.Data) <= 0 {
 		log.Error("Số lượng khung giờ phải lớn hơn 0")
-		return nil, ginext.NewError(http.StatusBadRequest, "Số lượng khung giờ phải lớn hơn 0")
+		return nil, apperror.New(apperror.TimeFrameCount)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, err
 	}
 	err := h.service.CreateMultiTimeFrame(r.GinCtx, req)
 	if err != nil {
@@ -75,11 +80,15 @@ func (h *TimeFrameHandler) Update(r *ginext.Request) (*ginext.Response, error) {
 	req := model.ListTimeFrameReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	if len(req.Data) <= 0 {
 		log.Error("Số lượng khung giờ phải lớn hơn 0")
-		return nil, ginext.NewError(http.StatusBadRequest, "Số lượng khung giờ phải lớn hơn 0")
+		return nil, apperror.New(apperror.TimeFrameCount)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, err
 	}
 	err := h.service.UpdateMultiTimeFrame(r.GinCtx, req)
 	if err != nil {
@@ -96,11 +105,11 @@ func (h *TimeFrameHandler) CreateTimeFrame(r *ginext.Request) (*ginext.Response,
 	var req model.TimeFrameReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateTimeFrame(r.Context(), req)
@@ -119,7 +128,7 @@ func (h *TimeFrameHandler) GetOneTimeFrame(r *ginext.Request) (*ginext.Response,
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneTimeFrame(r.Context(), valid.UUID(id))
@@ -137,18 +146,18 @@ func (h *TimeFrameHandler) UpdateTimeFrame(r *ginext.Request) (*ginext.Response,
 	var req model.TimeFrameRequest
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateTimeFrame(r.Context(), valid.UUID(id), req)
@@ -168,7 +177,7 @@ func (h *TimeFrameHandler) DeleteTimeFrame(r *ginext.Request) (*ginext.Response,
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "ID không hợp lệ")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	err := h.service.DeleteTimeFrame(r.Context(), valid.UUID(id))
@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type UserHandler struct {
@@ -32,7 +33,7 @@ func (h *UserHandler) GetOneUserById(r *ginext.Request) (*ginext.Response, error
 	userID := utils.ParseIDFromUri(r.GinCtx)
 	if userID == nil {
 		log.Error("Miss id!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Bắt buộc phải có id user")
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 	res, err := h.service.GetUserById(r.GinCtx, valid.UUID(userID))
 	if err != nil {
@@ -45,12 +46,12 @@ func (h *UserHandler) CheckDuplicatePhone(r *ginext.Request) (*ginext.Response,
 	req := model.CheckPhoneReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Error when parse req!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Error when parse req: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Invalid data!")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid data: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, _ := h.service.CheckDuplicatePhone(r.GinCtx, req.PhoneNumber)
 	return ginext.NewResponseData(http.StatusOK, res), nil
@@ -62,17 +63,17 @@ func (h *UserHandler) UpdateUser(r *ginext.Request) (*ginext.Response, error) {
 	// parse & check valid request
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	// parse id
 	req.ID = utils.ParseIDFromUri(r.GinCtx)
 	if req.ID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	res, err := h.service.UpdateUser(r.GinCtx, req)
 	if err != nil {
@@ -86,7 +87,7 @@ func (h *UserHandler) DeleteUser(r *ginext.Request) (*ginext.Response, error) {
 	userID := utils.ParseIDFromUri(r.GinCtx)
 	if userID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 	err := h.service.DeleteUser(r.GinCtx, valid.UUID(userID).String())
 	if err != nil {
@@ -99,12 +100,12 @@ func (h *UserHandler) CreateUser(r *ginext.Request) (*ginext.Response, error) {
 	req := model.CreateUserReq{}
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("Invalid input")
-		return nil, ginext.NewError(http.StatusBadRequest, "Invalid input: "+err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 	//check valid req
-	if err := utils.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("Cần nhập đầy đủ thông tin")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	rs, err := h.service.CreateUser(r.GinCtx, req)
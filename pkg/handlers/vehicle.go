@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"github.com/praslar/lib/common"
 	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/service"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
 )
 
 type VehicleHandler struct {
@@ -35,18 +36,18 @@ func (h *VehicleHandler) CreateVehicle(r *ginext.Request) (*ginext.Response, err
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.VehicleReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.CreateVehicle(r.Context(), req)
@@ -73,18 +74,18 @@ func (h *VehicleHandler) GetListVehicle(r *ginext.Request) (*ginext.Response, er
 	//_, err := utils.CurrentUser(r.GinCtx.Request)
 	//if err != nil {
 	//	log.WithError(err).Error("error_401: Error when get current user")
-	//	return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+	//	return nil, apperror.New(apperror.UserIDRequired)
 	//}
 
 	// parse & check valid request
 	var req model.ListVehicleReq
 	if err := r.GinCtx.BindQuery(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	res, err := h.service.GetListVehicle(r.Context(), req)
@@ -115,14 +116,14 @@ func (h *VehicleHandler) GetOneVehicle(r *ginext.Request) (*ginext.Response, err
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.GetOneVehicle(r.Context(), valid.UUID(id))
@@ -151,25 +152,25 @@ func (h *VehicleHandler) UpdateVehicle(r *ginext.Request) (*ginext.Response, err
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse & check valid request
 	var req model.VehicleReq
 	if err := r.GinCtx.BindJSON(&req); err != nil {
 		log.WithError(err).Error("error_400: Error when get parse req")
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
-	if err := common.CheckRequireValid(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
-		return nil, ginext.NewError(http.StatusBadRequest, err.Error())
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
 	}
 
 	// parse id
 	req.ID = utils.ParseIDFromUri(r.GinCtx)
 	if req.ID == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "Wrong id")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	res, err := h.service.UpdateVehicle(r.Context(), req)
@@ -199,14 +200,14 @@ func (h *VehicleHandler) DeleteVehicle(r *ginext.Request) (*ginext.Response, err
 	_, err := utils.CurrentUser(r.GinCtx.Request)
 	if err != nil {
 		log.WithError(err).Error("error_401: Error when get current user")
-		return nil, ginext.NewError(http.StatusBadRequest, utils.MessageError()[http.StatusUnauthorized])
+		return nil, apperror.New(apperror.UserIDRequired)
 	}
 
 	// parse id
 	id := utils.ParseIDFromUri(r.GinCtx)
 	if id == nil {
 		log.Error("error_400: Wrong id ")
-		return nil, ginext.NewError(http.StatusBadRequest, "ID không hợp lệ")
+		return nil, apperror.New(apperror.InvalidID)
 	}
 
 	err = h.service.DeleteVehicle(r.Context(), valid.UUID(id))
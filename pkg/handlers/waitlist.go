@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
+)
+
+type WaitlistHandler struct {
+	service service.WaitlistInterface
+}
+
+func NewWaitlistHandler(service service.WaitlistInterface) *WaitlistHandler {
+	return &WaitlistHandler{service: service}
+}
+
+// JoinWaitlist
+// @Tags		Waitlist
+// @Summary		Wait for a slot of a full parking lot
+// @Description	When a slot frees up it is offered to the oldest waiting user for a few minutes.
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.WaitlistReq	true	"data"
+// @Success		200				{object}	model.Waitlist
+// @Router		/api/v1/waitlist/create [post]
+func (h *WaitlistHandler) JoinWaitlist(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	// parse & check valid request
+	var req model.WaitlistReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.JoinWaitlist(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListWaitlist
+// @Tags		Waitlist
+// @Summary		List the waitlist entries of the current user
+// @Produce		json
+// @Param		data			query		model.ListWaitlistReq	true	"data"
+// @Success		200				{object}	model.ListWaitlistRes
+// @Router		/api/v1/waitlist/get-list [get]
+func (h *WaitlistHandler) GetListWaitlist(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.ListWaitlistReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.GetListWaitlist(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// AcceptWaitlistOffer
+// @Tags		Waitlist
+// @Summary		Book the slot offered to a waitlist entry
+// @Accept		json
+// @Produce		json
+// @Param		id				path		string					true	"id"
+// @Param		data			body		model.AcceptWaitlistReq	true	"data"
+// @Param		Idempotency-Key	header		string					false	"makes retries replay the first response"
+// @Success		200				{object}	model.Ticket
+// @Router		/api/v1/waitlist/accept/:id [put]
+func (h *WaitlistHandler) AcceptWaitlistOffer(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	var req model.AcceptWaitlistReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	// parse id
+	req.ID = utils.ParseIDFromUri(r.GinCtx)
+	if req.ID == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+	req.UserId = &userID
+
+	res, err := h.service.AcceptWaitlistOffer(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// CancelWaitlist
+// @Tags		Waitlist
+// @Summary		Leave the waitlist, an offered slot goes to the next user
+// @Produce		json
+// @Param		id				path		string	true	"id"
+// @Success		200				{string}	success
+// @Router		/api/v1/waitlist/cancel/:id [put]
+func (h *WaitlistHandler) CancelWaitlist(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	userID, err := utils.CurrentUser(r.GinCtx.Request)
+	if err != nil {
+		log.WithError(err).Error("error_401: Error when get current user")
+		return nil, apperror.New(apperror.UserIDRequired)
+	}
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	if err := h.service.CancelWaitlist(r.Context(), valid.UUID(id), userID); err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: "Hủy đăng ký chờ thành công"}}, nil
+}
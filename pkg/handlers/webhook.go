@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"parkar-server/pkg/validation"
+)
+
+type WebhookHandler struct {
+	service service.WebhookInterface
+}
+
+func NewWebhookHandler(service service.WebhookInterface) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhookSubscription
+// @Tags		Webhook
+// @Summary		Subscribe a company to ticket events
+// @Description	eventTypes are among ticket.created, ticket.extended, ticket.cancelled, ticket.relocated,
+// @Description	ticket.checked_in, ticket.checked_out and ticket.no_show, all of them when empty.
+// @Description	The calls are signed with X-Parkar-Signature: sha256=hex(hmac_sha256(secret, X-Parkar-Timestamp + "." + body)).
+// @Accept		json
+// @Produce		json
+// @Param		data			body		model.WebhookSubscriptionReq	true	"data"
+// @Success		200				{object}	model.WebhookSubscription
+// @Router		/api/merchant/webhook/create [post]
+func (h *WebhookHandler) CreateWebhookSubscription(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.WebhookSubscriptionReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.CreateWebhookSubscription(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// GetListWebhookSubscription
+// @Tags		Webhook
+// @Summary		Get the webhook subscriptions of a company
+// @Produce		json
+// @Param		data			query		model.ListWebhookSubscriptionReq	true	"data"
+// @Success		200				{object}	[]model.WebhookSubscription
+// @Router		/api/merchant/webhook/get-list [get]
+func (h *WebhookHandler) GetListWebhookSubscription(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ListWebhookSubscriptionReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListWebhookSubscription(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// UpdateWebhookSubscription
+// @Tags		Webhook
+// @Summary		Update a webhook subscription, a secret rotates it
+// @Accept		json
+// @Produce		json
+// @Param		id				path		string							true	"id"
+// @Param		data			body		model.WebhookSubscriptionReq	true	"data"
+// @Success		200				{object}	model.WebhookSubscription
+// @Router		/api/merchant/webhook/update/:id [put]
+func (h *WebhookHandler) UpdateWebhookSubscription(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.WebhookSubscriptionReq
+	if err := r.GinCtx.BindJSON(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	// parse id
+	req.ID = utils.ParseIDFromUri(r.GinCtx)
+	if req.ID == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	res, err := h.service.UpdateWebhookSubscription(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
+
+// DeleteWebhookSubscription
+// @Tags		Webhook
+// @Summary		Delete a webhook subscription
+// @Produce		json
+// @Param		id				path		string						true	"id"
+// @Param		data			query		model.WebhookCompanyReq	true	"data"
+// @Success		200				{string}	success
+// @Router		/api/merchant/webhook/delete/:id [delete]
+func (h *WebhookHandler) DeleteWebhookSubscription(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.WebhookCompanyReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	if err := h.service.DeleteWebhookSubscription(r.Context(), valid.UUID(id), uuid.MustParse(valid.String(req.CompanyId))); err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: "Xóa bản ghi thành công"}}, nil
+}
+
+// GetListWebhookDelivery
+// @Tags		Webhook
+// @Summary		Get the delivery log of a webhook subscription
+// @Produce		json
+// @Param		data			query		model.ListWebhookDeliveryReq	true	"data"
+// @Success		200				{object}	model.ListWebhookDeliveryRes
+// @Router		/api/merchant/webhook/deliveries [get]
+func (h *WebhookHandler) GetListWebhookDelivery(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.ListWebhookDeliveryReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	res, err := h.service.GetListWebhookDelivery(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{
+		Data: res.Data,
+		Meta: res.Meta,
+	}}, nil
+}
+
+// ReplayWebhookDelivery
+// @Tags		Webhook
+// @Summary		Send a webhook delivery again
+// @Produce		json
+// @Param		id				path		string						true	"delivery id"
+// @Param		data			query		model.WebhookCompanyReq	true	"data"
+// @Success		200				{object}	model.WebhookDelivery
+// @Router		/api/merchant/webhook/replay/:id [post]
+func (h *WebhookHandler) ReplayWebhookDelivery(r *ginext.Request) (*ginext.Response, error) {
+	log := logger.WithCtx(r.Context(), utils.GetCurrentCaller(h, 0))
+
+	// parse & check valid request
+	var req model.WebhookCompanyReq
+	if err := r.GinCtx.BindQuery(&req); err != nil {
+		log.WithError(err).Error("error_400: Error when get parse req")
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := validation.Struct(req); err != nil {
+		log.WithError(err).Error("error_400: Fail to check require valid: ", err)
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	// parse id
+	id := utils.ParseIDFromUri(r.GinCtx)
+	if id == nil {
+		log.Error("error_400: Wrong id ")
+		return nil, apperror.New(apperror.InvalidID)
+	}
+
+	res, err := h.service.ReplayWebhookDelivery(r.Context(), valid.UUID(id), uuid.MustParse(valid.String(req.CompanyId)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ginext.Response{Code: http.StatusOK, GeneralBody: &ginext.GeneralBody{Data: res}}, nil
+}
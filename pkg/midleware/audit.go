@@ -0,0 +1,34 @@
+package midleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"parkar-server/pkg/audit"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+)
+
+// Audit puts the actor of the request, of actorType, in its context for the audit log of the changes it makes.
+// An admin impersonating the user through BearerUser is the actor of the request.
+func Audit(actorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := audit.Actor{
+			Type:      actorType,
+			IP:        c.ClientIP(),
+			RequestID: c.GetString("x-request-id"),
+		}
+		if actor.RequestID == "" {
+			actor.RequestID = c.GetHeader("x-request-id")
+		}
+		if id, err := utils.CurrentUser(c.Request); err == nil {
+			actor.ID = &id
+		}
+		if adminID, ok := c.Get(impersonatorKey); ok {
+			id := adminID.(uuid.UUID)
+			actor.ID, actor.Type = &id, model.AuditActorAdmin
+		}
+		c.Set(audit.ActorKey, actor)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
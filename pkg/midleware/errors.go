@@ -0,0 +1,35 @@
+package midleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/goxp/cloud0/logger"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"runtime/debug"
+)
+
+// LocalizeErrors turns the error of a request into an apperror.Error with its message in the language of the
+// Accept-Language header, before the error handler of ginext renders it. The errors of the server are logged
+// and answered with INTERNAL so no SQL or stack reaches the client.
+func LocalizeErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.WithCtx(c, "LocalizeErrors").WithField("stack", string(debug.Stack())).Errorf("panic: %v", r)
+				_ = c.Error(apperror.Wrap(apperror.Internal, fmt.Errorf("panic: %v", r)))
+				c.Abort()
+			}
+			if len(c.Errors) == 0 {
+				return
+			}
+			last := c.Errors.Last()
+			err := apperror.From(last.Err)
+			if err.Code() >= http.StatusInternalServerError {
+				logger.WithCtx(c, "LocalizeErrors").WithError(last.Err).Error("error_500: request failed")
+			}
+			last.Err = err.In(apperror.Language(c.GetHeader("Accept-Language")))
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,178 @@
+package midleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/goxp/cloud0/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"strings"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on the responses replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyTTL      = 24 * time.Hour
+	idempotencyKeyMaxLen   = 255
+	idempotencyPrunePeriod = time.Hour
+)
+
+// IdempotencyStore keeps the first response per user and Idempotency-Key, it is implemented by repo.RepoPG.
+type IdempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) (*model.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+	DeleteExpiredIdempotencyKey(ctx context.Context) (int64, error)
+}
+
+type Idempotency struct {
+	store IdempotencyStore
+}
+
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	return &Idempotency{store: store}
+}
+
+// Handler makes the requests sent with an Idempotency-Key header safe to retry. The first successful response
+// per user and key is stored and replayed to the retries for 24 hours, a retry arriving while the first request
+// is still running gets 409 and the key cannot be reused for another request. A failed request releases the
+// key so it can be retried. Requests without the header are not affected.
+func (m *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		log := logger.WithCtx(c, "Idempotency").WithField("idempotency_key", key)
+		if len(key) > idempotencyKeyMaxLen {
+			abortWithError(c, apperror.New(apperror.IdempotencyKeyTooLong, idempotencyKeyMaxLen))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, apperror.Wrap(apperror.InvalidInput, err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &model.IdempotencyKey{
+			UserId:      idempotencyUser(c.Request),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.FullPath(),
+			RequestHash: requestHash(c.Request.Method, c.Request.URL, body),
+			ExpiresAt:   time.Now().Add(idempotencyKeyTTL),
+		}
+		existing, err := m.store.ClaimIdempotencyKey(c.Request.Context(), record)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				abortWithError(c, apperror.New(apperror.IdempotencyKeyReused))
+			case existing.CompletedAt == nil:
+				abortWithError(c, apperror.New(apperror.IdempotencyKeyInProgress))
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		completed := false
+		// the key stays claimed only for a response worth replaying, a failure or a panic releases it. The store
+		// is updated even when the client went away as its retry is the one the key is for.
+		defer func() {
+			if completed {
+				return
+			}
+			if err := m.store.DeleteIdempotencyKey(context.Background(), record.UserId, record.Key); err != nil {
+				log.WithError(err).Error("failed to release idempotency key")
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 || !writer.Written() || writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		record.StatusCode = writer.Status()
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.Response = writer.body.Bytes()
+		if err := m.store.CompleteIdempotencyKey(context.Background(), record); err != nil {
+			log.WithError(err).Error("failed to store idempotent response")
+			return
+		}
+		completed = true
+	}
+}
+
+// Run drops the expired keys until ctx is done.
+func (m *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPrunePeriod)
+	defer ticker.Stop()
+	for {
+		if n, err := m.store.DeleteExpiredIdempotencyKey(ctx); err != nil {
+			logger.WithCtx(ctx, "Idempotency.Run").WithError(err).Error("failed to drop expired idempotency keys")
+		} else if n > 0 {
+			logger.WithCtx(ctx, "Idempotency.Run").Infof("dropped %d expired idempotency keys", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// idempotencyUser scopes the keys to the x-user-id of the request, keys of anonymous requests share one scope.
+func idempotencyUser(r *http.Request) string {
+	return strings.Split(r.Header.Get("x-user-id"), "|")[0]
+}
+
+// requestHash identifies a request by its method, its path with the ids in it, its query and its body, so a
+// key sent again for another resource is refused.
+func requestHash(method string, u *url.URL, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + u.Path + "?" + u.RawQuery + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// abortWithError stops the request with err, rendered by the error handler of ginext like the handler errors.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,209 @@
+package midleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/goxp/cloud0/ginext"
+)
+
+// memoryIdempotencyStore claims the keys like repo.RepoPG does, without expiry.
+type memoryIdempotencyStore struct {
+	keys map[string]model.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) ClaimIdempotencyKey(_ context.Context, req *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	if existing, ok := s.keys[req.UserId+"|"+req.Key]; ok {
+		return &existing, nil
+	}
+	s.keys[req.UserId+"|"+req.Key] = *req
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) CompleteIdempotencyKey(_ context.Context, req *model.IdempotencyKey) error {
+	now := time.Now()
+	req.CompletedAt = &now
+	s.keys[req.UserId+"|"+req.Key] = *req
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteIdempotencyKey(_ context.Context, userID, key string) error {
+	delete(s.keys, userID+"|"+key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpiredIdempotencyKey(context.Context) (int64, error) {
+	return 0, nil
+}
+
+type idempotencyTest struct {
+	t      *testing.T
+	store  *memoryIdempotencyStore
+	router *gin.Engine
+	// calls counts the requests reaching the handlers
+	calls int
+	// during is served by /ticket/create while the first request is running
+	during *http.Request
+	inner  *httptest.ResponseRecorder
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	gin.SetMode(gin.TestMode)
+	it := &idempotencyTest{t: t, store: &memoryIdempotencyStore{keys: map[string]model.IdempotencyKey{}}}
+	it.router = gin.New()
+	it.router.Use(ginext.CreateErrorHandler(), LocalizeErrors())
+	idempotent := NewIdempotency(it.store).Handler()
+	it.router.POST("/ticket/create", idempotent, func(c *gin.Context) {
+		it.calls++
+		if it.during != nil {
+			req := it.during
+			it.during = nil
+			it.inner = httptest.NewRecorder()
+			it.router.ServeHTTP(it.inner, req)
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": gin.H{"call": it.calls}})
+	})
+	it.router.PUT("/ticket/cancel/:id", idempotent, func(c *gin.Context) {
+		it.calls++
+		if c.Param("id") == "missing" {
+			_ = c.Error(apperror.New(apperror.NotFound))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"call": it.calls}})
+	})
+	return it
+}
+
+func newIdempotentRequest(method, target, key, user, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req.Header.Set("x-user-id", user)
+	return req
+}
+
+func (it *idempotencyTest) do(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	it.router.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode returns the code of an error response.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) apperror.Code {
+	t.Helper()
+	var body struct {
+		Code apperror.Code `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %s: %v", w.Body.String(), err)
+	}
+	return body.Code
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	it := newIdempotencyTest(t)
+	first := it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"a"}`))
+	if first.Code != http.StatusCreated || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("first request = %d %v", first.Code, first.Header())
+	}
+
+	retry := it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"a"}`))
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retry has no %s header", IdempotentReplayedHeader)
+	}
+	if ct := retry.Header().Get("Content-Type"); ct != first.Header().Get("Content-Type") {
+		t.Errorf("retry content type = %q, want %q", ct, first.Header().Get("Content-Type"))
+	}
+	if it.calls != 1 {
+		t.Errorf("handler called %d times, want 1", it.calls)
+	}
+
+	// the keys are per user and the requests without a key are not stored
+	if w := it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u2", `{"slot":"a"}`)); w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("the response of another user was replayed")
+	}
+	it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "", "u1", `{"slot":"a"}`))
+	it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "", "u1", `{"slot":"a"}`))
+	if it.calls != 4 || len(it.store.keys) != 2 {
+		t.Errorf("handler called %d times with %d keys, want 4 and 2", it.calls, len(it.store.keys))
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	it := newIdempotencyTest(t)
+	it.during = newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"a"}`)
+	if w := it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"a"}`)); w.Code != http.StatusCreated {
+		t.Fatalf("first request = %d %s", w.Code, w.Body)
+	}
+	if it.inner.Code != http.StatusConflict || errorCode(t, it.inner) != apperror.IdempotencyKeyInProgress {
+		t.Errorf("retry while running = %d %s, want 409 %s", it.inner.Code, it.inner.Body, apperror.IdempotencyKeyInProgress)
+	}
+	if it.calls != 1 {
+		t.Errorf("handler called %d times, want 1", it.calls)
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	tests := map[string]*http.Request{
+		"other body":  newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"b"}`),
+		"other query": newIdempotentRequest(http.MethodPost, "/ticket/create?force=1", "k1", "u1", `{"slot":"a"}`),
+		"other route": newIdempotentRequest(http.MethodPut, "/ticket/cancel/1", "k1", "u1", `{"slot":"a"}`),
+	}
+	for name, req := range tests {
+		it := newIdempotencyTest(t)
+		it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", "k1", "u1", `{"slot":"a"}`))
+		w := it.do(req)
+		if w.Code != http.StatusUnprocessableEntity || errorCode(t, w) != apperror.IdempotencyKeyReused {
+			t.Errorf("%s = %d %s, want 422 %s", name, w.Code, w.Body, apperror.IdempotencyKeyReused)
+		}
+		if it.calls != 1 {
+			t.Errorf("%s: handler called %d times, want 1", name, it.calls)
+		}
+	}
+
+	// the ids in the path are part of the request
+	it := newIdempotencyTest(t)
+	it.do(newIdempotentRequest(http.MethodPut, "/ticket/cancel/1", "k1", "u1", ""))
+	if w := it.do(newIdempotentRequest(http.MethodPut, "/ticket/cancel/2", "k1", "u1", "")); errorCode(t, w) != apperror.IdempotencyKeyReused {
+		t.Errorf("key sent again for another ticket = %d %s, want %s", w.Code, w.Body, apperror.IdempotencyKeyReused)
+	}
+}
+
+func TestIdempotencyFailureReleasesKey(t *testing.T) {
+	it := newIdempotencyTest(t)
+	for i := 1; i <= 2; i++ {
+		w := it.do(newIdempotentRequest(http.MethodPut, "/ticket/cancel/missing", "k1", "u1", ""))
+		if w.Code != http.StatusNotFound || w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("attempt %d = %d %v, want 404 not replayed", i, w.Code, w.Header())
+		}
+		if len(it.store.keys) != 0 {
+			t.Errorf("attempt %d kept the key", i)
+		}
+	}
+	if it.calls != 2 {
+		t.Errorf("handler called %d times, want 2", it.calls)
+	}
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	it := newIdempotencyTest(t)
+	w := it.do(newIdempotentRequest(http.MethodPost, "/ticket/create", strings.Repeat("k", idempotencyKeyMaxLen+1), "u1", ""))
+	if w.Code != http.StatusBadRequest || errorCode(t, w) != apperror.IdempotencyKeyTooLong {
+		t.Errorf("long key = %d %s, want 400 %s", w.Code, w.Body, apperror.IdempotencyKeyTooLong)
+	}
+	if it.calls != 0 {
+		t.Errorf("handler called %d times, want 0", it.calls)
+	}
+}
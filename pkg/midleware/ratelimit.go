@@ -0,0 +1,127 @@
+package midleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"io"
+	"math"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitPolicy limits the requests of a route per client IP and per account. The account is the JSON body
+// field AccountField, such as the phone number of a login.
+type RateLimitPolicy struct {
+	Name         string
+	PerIP        ratelimit.Limit
+	PerAccount   ratelimit.Limit
+	AccountField string
+	// Lockout, when set, locks the account, or the IP of requests without one, after failed requests in a row.
+	Lockout *ratelimit.Lockout
+}
+
+type keyedLimit struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+// RateLimit answers 429 with a Retry-After header to the requests over the limits of policy or for a locked
+// account. The store failing does not block the requests.
+func RateLimit(store ratelimit.Store, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		log := logger.WithCtx(ctx, "RateLimit").WithField("policy", policy.Name)
+
+		ip := c.ClientIP()
+		account := ""
+		if policy.AccountField != "" {
+			account = requestAccount(c, policy.AccountField)
+		}
+		lockKey := policy.Name + ":lock:ip:" + ip
+		if account != "" {
+			lockKey = policy.Name + ":lock:account:" + account
+		}
+
+		if policy.Lockout != nil {
+			locked, err := store.Locked(ctx, lockKey)
+			if err != nil {
+				log.WithError(err).Error("failed to check lockout")
+			} else if locked > 0 {
+				abortTooManyRequests(c, locked)
+				return
+			}
+		}
+
+		limits := []keyedLimit{{policy.Name + ":ip:" + ip, policy.PerIP}}
+		if account != "" {
+			limits = append(limits, keyedLimit{policy.Name + ":account:" + account, policy.PerAccount})
+		}
+		for _, l := range limits {
+			allowed, retryAfter, err := store.Allow(ctx, l.key, l.limit)
+			if err != nil {
+				log.WithError(err).Error("failed to check rate limit")
+				continue
+			}
+			if !allowed {
+				abortTooManyRequests(c, retryAfter)
+				return
+			}
+		}
+
+		c.Next()
+
+		if policy.Lockout == nil {
+			return
+		}
+		if requestFailed(c) {
+			if locked, err := store.Fail(ctx, lockKey, *policy.Lockout); err != nil {
+				log.WithError(err).Error("failed to count failure")
+			} else if locked > 0 {
+				log.WithField("ip", ip).Warnf("locked %s for %s after failed attempts", lockKey, locked)
+			}
+		} else if len(c.Errors) == 0 && c.Writer.Status() < http.StatusBadRequest {
+			if err := store.Reset(ctx, lockKey); err != nil {
+				log.WithError(err).Error("failed to reset failures")
+			}
+		}
+	}
+}
+
+// requestAccount reads field from the JSON body and puts the body back for the handler.
+func requestAccount(c *gin.Context, field string) string {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	value, _ := fields[field].(string)
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// requestFailed tells whether the handler rejected the request as a client error, such as a wrong password.
+func requestFailed(c *gin.Context) bool {
+	if len(c.Errors) == 0 {
+		return c.Writer.Status() >= http.StatusBadRequest && c.Writer.Status() < http.StatusInternalServerError
+	}
+	if err, ok := c.Errors.Last().Err.(ginext.ApiError); ok {
+		return err.Code() >= http.StatusBadRequest && err.Code() < http.StatusInternalServerError
+	}
+	return false
+}
+
+func abortTooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	abortWithError(c, apperror.New(apperror.TooManyRequests))
+}
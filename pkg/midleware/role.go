@@ -0,0 +1,95 @@
+package midleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"strings"
+)
+
+// UserStore finds the user of a request, it is implemented by repo.RepoPG.
+type UserStore interface {
+	GetOneUserById(ctx context.Context, id uuid.UUID, tx *gorm.DB) (*model.User, error)
+}
+
+// RequireRole answers 401 to the requests without a valid bearer token and 403 to those of a user without
+// every flag of role or acting through an impersonation token. The role is read from the database so revoking
+// it takes effect at once. The x-user-id header is replaced by the user of the token for the next handlers.
+func RequireRole(store UserStore, role int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.ParseToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if err != nil {
+			abortWithError(c, apperror.Wrap(apperror.Unauthorized, err))
+			return
+		}
+		if _, ok := claims["act"]; ok {
+			abortWithError(c, apperror.New(apperror.Forbidden))
+			return
+		}
+		sub, _ := claims["id"].(string)
+		id, err := uuid.Parse(sub)
+		if err != nil {
+			abortWithError(c, apperror.Wrap(apperror.Unauthorized, errors.New("token without user id")))
+			return
+		}
+		user, err := store.GetOneUserById(c.Request.Context(), id, nil)
+		if err != nil {
+			if apperror.From(err).ErrorCode() == apperror.NotFound {
+				err = apperror.New(apperror.Unauthorized)
+			}
+			abortWithError(c, err)
+			return
+		}
+		if !user.HasRole(role) {
+			abortWithError(c, apperror.New(apperror.Forbidden))
+			return
+		}
+		c.Request.Header.Set("x-user-id", id.String())
+		c.Next()
+	}
+}
+
+// impersonatorKey holds, on the gin context, the admin acting through an impersonation token.
+const impersonatorKey = "impersonator-id"
+
+// BearerUser takes the user of a request sent with a bearer token from the token instead of the x-user-id
+// header, and answers 401 when the token is invalid or expired. The admin in the act claim of an impersonation
+// token is kept for Audit, which records the changes as made by that admin. Requests without a token are left
+// to the x-user-id header.
+func BearerUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			c.Next()
+			return
+		}
+		claims, err := utils.ParseToken(strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			abortWithError(c, apperror.Wrap(apperror.Unauthorized, err))
+			return
+		}
+		sub, _ := claims["id"].(string)
+		id, err := uuid.Parse(sub)
+		if err != nil {
+			abortWithError(c, apperror.Wrap(apperror.Unauthorized, errors.New("token without user id")))
+			return
+		}
+		if act, ok := claims["act"]; ok {
+			actor, _ := act.(map[string]interface{})
+			adminSub, _ := actor["sub"].(string)
+			adminID, err := uuid.Parse(adminSub)
+			if err != nil {
+				abortWithError(c, apperror.Wrap(apperror.Unauthorized, errors.New("impersonation token without admin id")))
+				return
+			}
+			c.Set(impersonatorKey, adminID)
+		}
+		c.Request.Header.Set("x-user-id", id.String())
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package midleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"parkar-server/pkg/audit"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+)
+
+func TestBearerUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv(utils.JWT_SECRET_KEY, "test-secret")
+	userID, adminID, headerID := uuid.New(), uuid.New(), uuid.New()
+
+	userToken, err := utils.GenerateToken(userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonation, _, err := utils.GenerateImpersonationToken(userID.String(), adminID.String(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := utils.GenerateImpersonationToken(userID.String(), adminID.String(), -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actor audit.Actor
+	var user string
+	router := gin.New()
+	router.Use(ginext.CreateErrorHandler(), LocalizeErrors(), BearerUser(), Audit(model.AuditActorUser))
+	router.GET("/", func(c *gin.Context) {
+		actor, user = audit.ActorFrom(c.Request.Context()), c.GetHeader("x-user-id")
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		token     string
+		status    int
+		user      uuid.UUID
+		actor     uuid.UUID
+		actorType string
+	}{
+		{"no token", "", http.StatusOK, headerID, headerID, model.AuditActorUser},
+		{"user token", userToken, http.StatusOK, userID, userID, model.AuditActorUser},
+		{"impersonation token", impersonation, http.StatusOK, userID, adminID, model.AuditActorAdmin},
+		{"expired impersonation token", expired, http.StatusUnauthorized, uuid.Nil, uuid.Nil, ""},
+		{"garbage", "not-a-token", http.StatusUnauthorized, uuid.Nil, uuid.Nil, ""},
+	}
+	for _, tt := range tests {
+		actor, user = audit.Actor{}, ""
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("x-user-id", headerID.String())
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if user != tt.user.String() {
+			t.Errorf("%s: x-user-id = %s, want %s", tt.name, user, tt.user)
+		}
+		if actor.ID == nil || *actor.ID != tt.actor || actor.Type != tt.actorType {
+			t.Errorf("%s: actor = %v %s, want %s %s", tt.name, actor.ID, actor.Type, tt.actor, tt.actorType)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// lockKey is the key of the advisory lock held while migrating, so two instances never migrate at once.
+	lockKey = 7311240129
+	// noTransaction marks the migrations that must not run inside a transaction.
+	noTransaction = "-- migrate:no-transaction"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema with the SQL to apply and to revert it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration was applied, and when.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load reads the migrations of fsys sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		res = append(res, *mig)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies the pending migrations in order, at most steps of them when steps is positive.
+func (m *Migrator) Up(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *gorm.DB, applied map[int64]time.Time) error {
+		done := 0
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if steps > 0 && done == steps {
+				break
+			}
+			logger.Tag("Migrator.Up").Infof("applying %d_%s", mig.Version, mig.Name)
+			err := run(conn, mig.Up, func(tx *gorm.DB) error {
+				return tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done++
+		}
+		logger.Tag("Migrator.Up").Infof("applied %d migration(s)", done)
+		return nil
+	})
+}
+
+// Down reverts the last applied migrations, steps of them, one when steps is not positive.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		steps = 1
+	}
+	return m.locked(ctx, func(conn *gorm.DB, applied map[int64]time.Time) error {
+		done := 0
+		for i := len(m.migrations) - 1; i >= 0 && done < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("migration %d_%s cannot be reverted, it has no down file", mig.Version, mig.Name)
+			}
+			logger.Tag("Migrator.Down").Infof("reverting %d_%s", mig.Version, mig.Name)
+			err := run(conn, mig.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&schemaMigration{}, mig.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done++
+		}
+		logger.Tag("Migrator.Down").Infof("reverted %d migration(s)", done)
+		return nil
+	})
+}
+
+// Status lists the known migrations with the time they were applied.
+func (m *Migrator) Status(ctx context.Context) (res []Status, err error) {
+	err = m.locked(ctx, func(_ *gorm.DB, applied map[int64]time.Time) error {
+		for _, mig := range m.migrations {
+			status := Status{Migration: mig}
+			if at, ok := applied[mig.Version]; ok {
+				status.AppliedAt = &at
+			}
+			res = append(res, status)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// locked runs f on a single connection holding the migration lock, with the applied versions.
+func (m *Migrator) locked(ctx context.Context, f func(conn *gorm.DB, applied map[int64]time.Time) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("select pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("select pg_advisory_unlock(?)", lockKey)
+
+		if err := conn.Exec(`create table if not exists schema_migrations (
+								version bigint primary key,
+								name text not null,
+								applied_at timestamptz not null default current_timestamp)`).Error; err != nil {
+			return err
+		}
+		var rows []schemaMigration
+		if err := conn.Order("version").Find(&rows).Error; err != nil {
+			return err
+		}
+		applied := make(map[int64]time.Time, len(rows))
+		for _, row := range rows {
+			applied[row.Version] = row.AppliedAt
+		}
+		return f(conn, applied)
+	})
+}
+
+// run executes the SQL of a migration and then record, in one transaction unless the migration opts out,
+// in which case its statements run one by one and a failure leaves the ones before it applied.
+func run(conn *gorm.DB, sql string, record func(tx *gorm.DB) error) error {
+	if !strings.HasPrefix(strings.TrimSpace(sql), noTransaction) {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+			return record(tx)
+		})
+	}
+	for _, stmt := range splitStatements(sql) {
+		if err := conn.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return record(conn)
+}
+
+// splitStatements splits on the semicolons ending a line outside a $$ quoted body, which is enough for the
+// statements that have to run outside a transaction, do blocks included.
+func splitStatements(sql string) (res []string) {
+	var stmt strings.Builder
+	quoted := false
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (!quoted && strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		if strings.Count(line, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+		if !quoted && strings.HasSuffix(trimmed, ";") {
+			res = append(res, stmt.String())
+			stmt.Reset()
+		}
+	}
+	if strings.TrimSpace(stmt.String()) != "" {
+		res = append(res, stmt.String())
+	}
+	return res
+}
+
+// Create writes the empty up and down files of a new migration to dir, numbered after the last one.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(strings.ToLower(regexp.MustCompile(`\W+`).ReplaceAllString(name, "_")), "_")
+	if name == "" {
+		return nil, fmt.Errorf("the migration needs a name")
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return files, err
+		}
+		_, err = fmt.Fprintf(f, "-- %s %04d_%s\n", direction, version, name)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,152 @@
+package migration
+
+import (
+	"os"
+	"parkar-server/migrations"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_b.up.sql":   {Data: []byte("create table b ();")},
+		"0002_add_b.down.sql": {Data: []byte("drop table b;")},
+		"0010_add_c.up.sql":   {Data: []byte("create table c ();")},
+		"0001_add_a.up.sql":   {Data: []byte("create table a ();")},
+		"0001_add_a.down.sql": {Data: []byte("drop table a;")},
+		"README.md":           {Data: []byte("not a migration")},
+		"0003_x.sql":          {Data: []byte("no direction")},
+	}
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "add_a", Up: "create table a ();", Down: "drop table a;"},
+		{Version: 2, Name: "add_b", Up: "create table b ();", Down: "drop table b;"},
+		{Version: 10, Name: "add_c", Up: "create table c ();"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"two names": {
+			"0001_add_a.up.sql":   {Data: []byte("select 1;")},
+			"0001_add_b.down.sql": {Data: []byte("select 1;")},
+		},
+		"no up file": {
+			"0001_add_a.down.sql": {Data: []byte("select 1;")},
+		},
+		"empty up file": {
+			"0001_add_a.up.sql":   {Data: []byte(" \n")},
+			"0001_add_a.down.sql": {Data: []byte("select 1;")},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("Load(%s) succeeded", name)
+		}
+	}
+}
+
+// TestLoadEmbedded checks the migrations shipped with the binary: numbered without gaps, each with a down.
+func TestLoadEmbedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 {
+		t.Fatal("no migrations")
+	}
+	for i, mig := range got {
+		if mig.Version != int64(i+1) {
+			t.Errorf("migration %d_%s is numbered after %d", mig.Version, mig.Name, i)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := `-- migrate:no-transaction
+-- a comment; with a semicolon
+create index concurrently if not exists idx_a on a (x)
+  where deleted_at is null;
+
+create index concurrently if not exists idx_b on b (y);
+do $$
+declare
+  r record;
+begin
+  -- a comment inside the body
+  for r in select 1 loop
+    perform 1;
+  end loop;
+end $$;
+do $$ begin perform 1; end $$;
+alter table a validate constraint fk_a`
+
+	want := []string{
+		"create index concurrently if not exists idx_a on a (x)\n  where deleted_at is null;\n",
+		"create index concurrently if not exists idx_b on b (y);\n",
+		"do $$\ndeclare\n  r record;\nbegin\n  -- a comment inside the body\n  for r in select 1 loop\n    perform 1;\n  end loop;\nend $$;\n",
+		"do $$ begin perform 1; end $$;\n",
+		"alter table a validate constraint fk_a\n",
+	}
+	if got := splitStatements(sql); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q, want %q", got, want)
+	}
+}
+
+// TestSplitEmbedded checks that every statement of the no-transaction migrations is complete.
+func TestSplitEmbedded(t *testing.T) {
+	all, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mig := range all {
+		for _, sql := range []string{mig.Up, mig.Down} {
+			if !strings.HasPrefix(strings.TrimSpace(sql), noTransaction) {
+				continue
+			}
+			for _, stmt := range splitStatements(sql) {
+				if !strings.HasSuffix(strings.TrimSpace(stmt), ";") || strings.Count(stmt, "$$")%2 != 0 {
+					t.Errorf("migration %d_%s has an incomplete statement: %s", mig.Version, mig.Name, stmt)
+				}
+			}
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/0007_last.up.sql", []byte("select 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Create(dir, "Add Company-Notes!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{dir + "/0008_add_company_notes.up.sql", dir + "/0008_add_company_notes.down.sql"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Create() = %v, want %v", files, want)
+	}
+	got, err := Load(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Version != 8 || got[1].Name != "add_company_notes" {
+		t.Errorf("Load() after Create = %+v", got)
+	}
+
+	if _, err := Create(dir, "!!"); err == nil {
+		t.Error("Create() without a name succeeded")
+	}
+}
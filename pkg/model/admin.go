@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+type AdminListCompanyReq struct {
+	Status   *string `json:"status" form:"status" valid:"omitempty,oneof=pending_email pending_review active suspended"`
+	Search   *string `json:"search" form:"search"`
+	Page     int     `json:"page" form:"page"`
+	PageSize int     `json:"pageSize" form:"pageSize"`
+}
+
+type AdminListCompanyRes struct {
+	Data []Company       `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
+
+type CompanyStatusReq struct {
+	Reason *string `json:"reason"`
+}
+
+type AdminListUserReq struct {
+	Search   *string `json:"search" form:"search"`
+	Role     *int    `json:"role" form:"role"`
+	Page     int     `json:"page" form:"page"`
+	PageSize int     `json:"pageSize" form:"pageSize"`
+}
+
+type AdminListUserRes struct {
+	Data []User          `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
+
+// ImpersonateRes is a short-lived token to act as User, it carries the id of the admin who asked for it.
+type ImpersonateRes struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	User        User      `json:"user"`
+}
+
+type PlatformStatsReq struct {
+	From *time.Time `json:"from" form:"from"`
+	To   *time.Time `json:"to" form:"to"`
+}
+
+// PlatformStats counts the entities of every company, Tickets and Revenue are those of the tickets starting
+// between From and To.
+type PlatformStats struct {
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	Users        int64            `json:"users"`
+	Companies    map[string]int64 `json:"companies"`
+	ParkingLots  int64            `json:"parkingLots"`
+	ParkingSlots int64            `json:"parkingSlots"`
+	Tickets      int64            `json:"tickets"`
+	Revenue      float64          `json:"revenue"`
+}
+
+type GlobalSettingReq struct {
+	Key   *string         `json:"key" valid:"required"`
+	Value json.RawMessage `json:"value" swaggertype:"object"`
+}
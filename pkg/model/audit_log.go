@@ -0,0 +1,60 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	// AuditActionImpersonate is an admin getting a token to act as a user.
+	AuditActionImpersonate = "impersonate"
+
+	AuditActorUser     = "user"
+	AuditActorMerchant = "merchant"
+	AuditActorSystem   = "system"
+	AuditActorAdmin    = "admin"
+)
+
+// AuditLog records one created, updated or deleted row. Before and After hold the row by column, Diff the
+// columns that changed as {"column": {"from": .., "to": ..}}.
+type AuditLog struct {
+	ID         uuid.UUID    `json:"id" gorm:"primary_key;type:uuid;default:uuid_generate_v4()"`
+	CompanyId  *uuid.UUID   `json:"companyId" gorm:"type:uuid"`
+	ActorId    *uuid.UUID   `json:"actorId" gorm:"type:uuid"`
+	ActorType  string       `json:"actorType"`
+	Action     string       `json:"action"`
+	EntityType string       `json:"entityType"`
+	EntityId   string       `json:"entityId"`
+	Before     pgtype.JSONB `json:"before" gorm:"type:jsonb" swaggertype:"object"`
+	After      pgtype.JSONB `json:"after" gorm:"type:jsonb" swaggertype:"object"`
+	Diff       pgtype.JSONB `json:"diff" gorm:"type:jsonb" swaggertype:"object"`
+	Ip         string       `json:"ip"`
+	RequestId  string       `json:"requestId"`
+	CreatedAt  time.Time    `json:"createdAt" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+type ListAuditLogReq struct {
+	CompanyId  *string    `json:"companyId" form:"companyId" valid:"required"`
+	EntityType *string    `json:"entityType" form:"entityType"`
+	EntityId   *string    `json:"entityId" form:"entityId"`
+	ActorId    *string    `json:"actorId" form:"actorId"`
+	Action     *string    `json:"action" form:"action"`
+	From       *time.Time `json:"from" form:"from"`
+	To         *time.Time `json:"to" form:"to"`
+	Page       int        `json:"page" form:"page"`
+	PageSize   int        `json:"pageSize" form:"pageSize"`
+}
+
+type ListAuditLogRes struct {
+	Data []AuditLog      `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
@@ -18,23 +18,44 @@ func (Block) TableName() string {
 	return "block"
 }
 
+// BlockReq.Slot is only read on create with GenerateSlots, Block.Slot is otherwise derived from the slots of the block.
 type BlockReq struct {
 	ID           *uuid.UUID `json:"id"`
 	Code         *string    `json:"code"`
 	Description  *string    `json:"description"`
 	Slot         *int       `json:"slot"`
 	ParkingLotID *uuid.UUID `json:"parking_lot_id"`
+	// GenerateSlots creates Slot slots named with SlotNamePattern, a printf pattern with one integer verb
+	// numbered from 1. It defaults to "<code>-%03d" which gives A-001, A-002...
+	GenerateSlots   *bool   `json:"generate_slots"`
+	SlotNamePattern *string `json:"slot_name_pattern"`
+	SlotVehicleType *string `json:"slot_vehicle_type"`
 }
 
 type ListBlockReq struct {
-	Code         *string `json:"code" form:"code"`
-	ParkingLotID *string `json:"parking_lot_id" form:"parking_lot_id"`
-	Sort         string  `json:"sort" form:"sort"`
-	Page         int     `json:"page" form:"page"`
-	PageSize     int     `json:"page_size" form:"page_size"`
+	Code         *string  `json:"code" form:"code"`
+	ParkingLotID *string  `json:"parking_lot_id" form:"parking_lot_id"`
+	Sort         string   `json:"sort" form:"sort"`
+	Filter       []string `json:"filter" form:"filter"`
+	Cursor       *string  `json:"cursor" form:"cursor"`
+	Page         int      `json:"page" form:"page"`
+	PageSize     int      `json:"page_size" form:"page_size"`
 }
 
 type ListBlockRes struct {
 	Data []Block         `json:"data,omitempty"`
 	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
 }
+
+type BlockSlotDriftReq struct {
+	ParkingLotID *string `json:"parking_lot_id" form:"parking_lot_id" valid:"required"`
+}
+
+// BlockSlotDrift is a block whose recorded slot count does not match its slots.
+type BlockSlotDrift struct {
+	BlockID      uuid.UUID `json:"blockId"`
+	Code         string    `json:"code"`
+	ParkingLotID uuid.UUID `json:"parkingLotId"`
+	Slot         int       `json:"slot"`
+	ActualSlot   int       `json:"actualSlot"`
+}
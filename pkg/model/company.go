@@ -1,13 +1,34 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
+// A company starts pending_email, verifying its email puts it pending_review and an admin approving it makes
+// it active. An admin can suspend it at any time.
+const (
+	CompanyStatusPendingEmail  = "pending_email"
+	CompanyStatusPendingReview = "pending_review"
+	CompanyStatusActive        = "active"
+	CompanyStatusSuspended     = "suspended"
+
+	CompanyDocumentBusinessLicense = "business_license"
+	CompanyDocumentTaxRegistration = "tax_registration"
+	CompanyDocumentOther           = "other"
+)
+
+// Company is a merchant, its parking lots are only created and listed while it is active. StatusReason is the
+// reason given for the last suspension.
 type Company struct {
 	BaseModel
-	Name        string `json:"name"`
-	PhoneNumber string `json:"phoneNumber" gorm:"not null"`
-	Email       string `json:"email" gorm:"not null"`
-	Password    string `json:"password" gorm:"not null"`
+	Name            string     `json:"name"`
+	PhoneNumber     string     `json:"phoneNumber" gorm:"not null"`
+	Email           string     `json:"email" gorm:"not null"`
+	Password        string     `json:"password" gorm:"not null"`
+	Status          string     `json:"status" gorm:"not null;default:active"`
+	StatusReason    string     `json:"statusReason"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
 }
 
 func (company *Company) TableName() string {
@@ -16,18 +37,71 @@ func (company *Company) TableName() string {
 
 type CompanyReq struct {
 	ID          *uuid.UUID `json:"id"`
-	Name        *string    `json:"companyName" valid:"Required"`
-	PhoneNumber *string    `json:"phoneNumber" valid:"Required"`
-	Email       *string    `json:"email" valid:"Required"`
+	Name        *string    `json:"companyName" valid:"required"`
+	PhoneNumber *string    `json:"phoneNumber" valid:"required,phone"`
+	Email       *string    `json:"email" valid:"required,email"`
 	Password    *string    `json:"password"`
 }
 
 type LoginReq struct {
-	Email    *string `json:"email" valid:"Required"`
-	Password *string `json:"password" valid:"Required"`
+	Email    *string `json:"email" valid:"required"`
+	Password *string `json:"password" valid:"required"`
 }
 
 type PasswordChangeReq struct {
 	Old *string `json:"old"`
 	New *string `json:"new"`
 }
+
+// CompanyVerificationToken is a single-use token sent to the email of a company, only its hash is stored.
+type CompanyVerificationToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"primary_key;type:uuid;default:uuid_generate_v4()"`
+	CompanyId uuid.UUID  `json:"companyId" gorm:"type:uuid"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (CompanyVerificationToken) TableName() string {
+	return "company_verification_token"
+}
+
+type VerifyCompanyEmailReq struct {
+	Token *string `json:"token" form:"token" valid:"required"`
+}
+
+type ResendCompanyVerificationReq struct {
+	Email *string `json:"email" valid:"required,email"`
+}
+
+// CompanyDocument is the metadata of a document proving a company, such as its business license. The file
+// itself is uploaded to the storage at Url by the client.
+type CompanyDocument struct {
+	BaseModel
+	CompanyId   uuid.UUID `json:"companyId" gorm:"type:uuid"`
+	Type        string    `json:"type"`
+	Number      string    `json:"number"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Url         string    `json:"url"`
+}
+
+func (CompanyDocument) TableName() string {
+	return "company_document"
+}
+
+type CompanyDocumentReq struct {
+	CompanyId   *uuid.UUID `json:"companyId" valid:"required"`
+	Type        *string    `json:"type" valid:"required,oneof=business_license tax_registration other"`
+	Number      *string    `json:"number"`
+	FileName    *string    `json:"fileName" valid:"required"`
+	ContentType *string    `json:"contentType" valid:"omitempty,oneof=application/pdf image/jpeg image/png"`
+	Size        *int64     `json:"size" valid:"omitempty,gt=0,lte=10485760"`
+	Url         *string    `json:"url" valid:"required,url"`
+}
+
+type ListCompanyDocumentReq struct {
+	CompanyId *string `json:"companyId" form:"companyId" valid:"required,uuid"`
+}
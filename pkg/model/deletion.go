@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+// DeleteReq is the query of the delete endpoints of parking lots, blocks and slots. Without Force a delete
+// is refused while future tickets use the deleted slots, with it they are relocated or cancelled.
+type DeleteReq struct {
+	Force bool `json:"force" form:"force"`
+}
+
+// TicketScope selects the tickets of a parking lot, a block or some slots, overlapping Start to End when set.
+type TicketScope struct {
+	ParkingLotID   *uuid.UUID
+	BlockID        *uuid.UUID
+	ParkingSlotIDs []uuid.UUID
+	Start          *time.Time
+	End            *time.Time
+}
+
+// FreeSlotReq looks for a slot of a parking lot that is free from Start to End. PreferSlotID and slots of
+// VehicleType are tried first, ExcludeBlockID and ExcludeSlotIDs are never returned. With StrictVehicleType
+// slots kept for another vehicle type are never returned either. With SlotID only that slot is looked at.
+type FreeSlotReq struct {
+	ParkingLotID      uuid.UUID
+	Start             time.Time
+	End               time.Time
+	VehicleType       string
+	StrictVehicleType bool
+	// HolderID is the user whose own slot holds do not count, the holds of everyone else always do.
+	HolderID       *uuid.UUID
+	PreferSlotID   *uuid.UUID
+	ExcludeBlockID *uuid.UUID
+	ExcludeSlotIDs []uuid.UUID
+	SlotID         *uuid.UUID
+}
+
+type RelocatedTicket struct {
+	TicketID   uuid.UUID `json:"ticketId"`
+	FromSlotID uuid.UUID `json:"fromSlotId"`
+	ToSlotID   uuid.UUID `json:"toSlotId"`
+}
+
+type DeleteRes struct {
+	CancelledTickets []uuid.UUID       `json:"cancelledTickets"`
+	RelocatedTickets []RelocatedTicket `json:"relocatedTickets"`
+}
+
+func (res DeleteRes) Meta() ginext.BodyMeta {
+	return ginext.BodyMeta{
+		"cancelled_tickets": res.CancelledTickets,
+		"relocated_tickets": res.RelocatedTickets,
+	}
+}
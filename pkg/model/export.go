@@ -0,0 +1,46 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ReportRevenue       = "revenue"
+	ReportOccupancy     = "occupancy"
+	ReportTicketStats   = "ticket-stats"
+	ReportTopTimeFrames = "top-time-frames"
+)
+
+// ExportTicketReq takes the filters of GetListTicketReq, the whole result is exported so there is no paging.
+type ExportTicketReq struct {
+	ParkingLotID *string `json:"parking_lot_id" form:"parking_lot_id" valid:"required"`
+	State        *string `json:"state" form:"state"`
+	Format       string  `json:"format" form:"format"`
+}
+
+type ExportReportReq struct {
+	ReportReq
+	Report string `json:"report" form:"report" valid:"required"`
+	Format string `json:"format" form:"format"`
+}
+
+// ExportTicketRow is a ticket with its vehicle, slot and the totals of its extension chain.
+type ExportTicketRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	State         string
+	StartTime     *time.Time
+	EndTime       *time.Time
+	EntryTime     *time.Time
+	ExitTime      *time.Time
+	VehicleName   string
+	VehicleNumber string
+	VehicleType   string
+	BlockCode     string
+	SlotName      string
+	Total         float64
+	ExtendCount   int
+	ExtendTotal   float64
+	ExtendedUntil *time.Time
+}
@@ -14,10 +14,10 @@ func (f *Favorite) TableName() string {
 }
 
 type FavoriteRequest struct {
-	UserId       uuid.UUID `json:"userId" form:"userId" valid:"Required"`
-	ParkingLotId uuid.UUID `json:"parkingLotId" form:"parkingLotId" valid:"Required"`
+	UserId       uuid.UUID `json:"userId" form:"userId" valid:"required"`
+	ParkingLotId uuid.UUID `json:"parkingLotId" form:"parkingLotId" valid:"required"`
 }
 type FavoriteRequestV2 struct {
-	UserId       *string `json:"userId" form:"userId" valid:"Required"`
+	UserId       *string `json:"userId" form:"userId" valid:"required"`
 	ParkingLotId *string `json:"parkingLotId" form:"parkingLotId"`
 }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// IdempotencyKey is the first response to a request sent with an Idempotency-Key header, replayed to the
+// retries of the same user with the same key until ExpiresAt.
+type IdempotencyKey struct {
+	UserId string `json:"userId" gorm:"primaryKey"`
+	Key    string `json:"key" gorm:"primaryKey"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// RequestHash tells apart a retry from another request reusing the key.
+	RequestHash string `json:"requestHash"`
+	// StatusCode is 0 while the first request is still being handled.
+	StatusCode  int        `json:"statusCode"`
+	ContentType string     `json:"contentType"`
+	Response    []byte     `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"default:CURRENT_TIMESTAMP"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"index"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
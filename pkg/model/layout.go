@@ -0,0 +1,60 @@
+package model
+
+import "github.com/google/uuid"
+
+const (
+	LayoutFormatCSV  = "csv"
+	LayoutFormatJSON = "json"
+	LayoutFormatYAML = "yaml"
+
+	LayoutActionCreate = "create"
+	LayoutActionUpdate = "update"
+	LayoutActionDelete = "delete"
+
+	LayoutTypeBlock = "block"
+	LayoutTypeSlot  = "slot"
+)
+
+// Layout is the blocks and slots of a parking lot as written in an import file.
+// Blocks are matched by code and slots by name inside their block.
+type Layout struct {
+	Blocks []LayoutBlock `json:"blocks" yaml:"blocks"`
+}
+
+type LayoutBlock struct {
+	Code        string       `json:"code" yaml:"code"`
+	Description string       `json:"description" yaml:"description"`
+	Slots       []LayoutSlot `json:"slots" yaml:"slots"`
+}
+
+type LayoutSlot struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	VehicleType string `json:"vehicle_type" yaml:"vehicle_type"`
+}
+
+type ImportLayoutReq struct {
+	ParkingLotID *string `json:"parking_lot_id" form:"parking_lot_id" valid:"required"`
+	Format       string  `json:"format" form:"format"`
+	DryRun       bool    `json:"dry_run" form:"dry_run"`
+	// Prune deletes the blocks and slots that are not in the file, without it they are left as they are.
+	Prune bool `json:"prune" form:"prune"`
+}
+
+type LayoutChange struct {
+	Action    string     `json:"action"`
+	Type      string     `json:"type"`
+	ID        *uuid.UUID `json:"id,omitempty"`
+	BlockCode string     `json:"blockCode"`
+	SlotName  string     `json:"slotName,omitempty"`
+	Before    *string    `json:"before,omitempty"`
+	After     *string    `json:"after,omitempty"`
+}
+
+type ImportLayoutRes struct {
+	DryRun  bool           `json:"dryRun"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Deleted int            `json:"deleted"`
+	Changes []LayoutChange `json:"changes"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+const (
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+	NotificationStatusSkipped = "skipped"
+)
+
+// NotificationPreference is how a user wants to be told about their bookings, users without one get
+// push and email in Vietnamese.
+type NotificationPreference struct {
+	BaseModel
+	UserId   uuid.UUID `json:"userId" gorm:"type:uuid;uniqueIndex"`
+	Push     bool      `json:"push"`
+	SMS      bool      `json:"sms"`
+	Email    bool      `json:"email"`
+	Language string    `json:"language"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preference"
+}
+
+type NotificationPreferenceReq struct {
+	UserId   *uuid.UUID `json:"-"`
+	Push     *bool      `json:"push"`
+	SMS      *bool      `json:"sms"`
+	Email    *bool      `json:"email"`
+	Language *string    `json:"language" valid:"omitempty,oneof=vi en"`
+}
+
+// DeviceToken is a push token of one of the devices of a user.
+type DeviceToken struct {
+	BaseModel
+	UserId   uuid.UUID `json:"userId" gorm:"type:uuid;index"`
+	Token    string    `json:"token" gorm:"uniqueIndex"`
+	Platform string    `json:"platform"`
+}
+
+func (DeviceToken) TableName() string {
+	return "device_token"
+}
+
+type DeviceTokenReq struct {
+	UserId   *uuid.UUID `json:"-" form:"-"`
+	Token    *string    `json:"token" form:"token" valid:"required"`
+	Platform *string    `json:"platform" form:"platform"`
+}
+
+// Notification is a message sent, or not, to a user on one channel.
+type Notification struct {
+	BaseModel
+	UserId   uuid.UUID  `json:"userId" gorm:"type:uuid;index"`
+	TicketId *uuid.UUID `json:"ticketId,omitempty" gorm:"type:uuid;index"`
+	Event    string     `json:"event"`
+	Channel  string     `json:"channel"`
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+}
+
+func (Notification) TableName() string {
+	return "notification"
+}
+
+type ListNotificationReq struct {
+	UserId   *uuid.UUID `json:"-" form:"-"`
+	Page     int        `json:"page" form:"page"`
+	PageSize int        `json:"pageSize" form:"pageSize"`
+}
+
+type ListNotificationRes struct {
+	Data []Notification  `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
+
+// TicketToNotify is an ongoing ticket with DueTime, the end of its last extension.
+type TicketToNotify struct {
+	Ticket
+	DueTime time.Time `json:"dueTime"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"time"
+)
+
+const (
+	EventTicketCreated    = "ticket.created"
+	EventTicketExtended   = "ticket.extended"
+	EventTicketCancelled  = "ticket.cancelled"
+	EventTicketRelocated  = "ticket.relocated"
+	EventTicketCheckedIn  = "ticket.checked_in"
+	EventTicketCheckedOut = "ticket.checked_out"
+	EventTicketNoShow     = "ticket.no_show"
+)
+
+// TicketEventTypes are the events merchants can subscribe to.
+var TicketEventTypes = []string{
+	EventTicketCreated,
+	EventTicketExtended,
+	EventTicketCancelled,
+	EventTicketRelocated,
+	EventTicketCheckedIn,
+	EventTicketCheckedOut,
+	EventTicketNoShow,
+}
+
+// OutboxEvent is a domain event written in the transaction of the change it describes. The dispatcher
+// hands it to the subscribers once the transaction is committed and retries until they all succeed, so
+// no event is lost when the process stops in between but a subscriber may see it more than once.
+type OutboxEvent struct {
+	ID            uuid.UUID    `gorm:"primary_key;type:uuid;default:uuid_generate_v4()" json:"id"`
+	CompanyId     *uuid.UUID   `json:"companyId" gorm:"type:uuid"`
+	AggregateType string       `json:"aggregateType"`
+	AggregateId   uuid.UUID    `json:"aggregateId" gorm:"type:uuid"`
+	EventType     string       `json:"eventType"`
+	Payload       pgtype.JSONB `json:"payload" gorm:"type:jsonb" swaggertype:"object"`
+	CreatedAt     time.Time    `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	PublishedAt   *time.Time   `json:"publishedAt" gorm:"index"`
+	// NextAttemptAt keeps a claimed event from the other dispatchers, then delays the retry after a failure.
+	NextAttemptAt time.Time `json:"nextAttemptAt" gorm:"default:CURRENT_TIMESTAMP"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError,omitempty"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// ProcessedEvent records that a consumer handled an event, it is written in the transaction of the
+// consumer so an event redelivered after a crash is skipped.
+type ProcessedEvent struct {
+	Consumer    string    `json:"consumer" gorm:"primaryKey"`
+	EventId     uuid.UUID `json:"eventId" gorm:"primaryKey;type:uuid"`
+	ProcessedAt time.Time `json:"processedAt" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (ProcessedEvent) TableName() string {
+	return "processed_event"
+}
@@ -2,20 +2,24 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gitlab.com/goxp/cloud0/ginext"
 	"time"
 )
 
 type ParkingLot struct {
 	BaseModel
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	Address     string    `json:"address"`
-	StartTime   time.Time `json:"startTime"`
-	EndTime     time.Time `json:"endTime"`
-	Lat         float64   `json:"lat"`
-	Long        float64   `json:"long"`
-	CompanyID   uuid.UUID `json:"companyID" gorm:"type:uuid"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Address     string         `json:"address"`
+	StartTime   time.Time      `json:"startTime"`
+	EndTime     time.Time      `json:"endTime"`
+	Lat         float64        `json:"lat"`
+	Long        float64        `json:"long"`
+	CompanyID   uuid.UUID      `json:"companyID" gorm:"type:uuid"`
+	Amenities   pq.StringArray `json:"amenities" gorm:"type:text[]" swaggertype:"array,string"`
+	// Timezone is the IANA zone the parking lot is in, its times are shown and its days counted in it.
+	Timezone string `json:"timezone" gorm:"not null;default:Asia/Ho_Chi_Minh"`
 }
 
 func (ParkingLot) TableName() string {
@@ -24,7 +28,7 @@ func (ParkingLot) TableName() string {
 
 type ParkingLotReq struct {
 	ID          *uuid.UUID `json:"id"`
-	Name        *string    `json:"name" valid:"Required"`
+	Name        *string    `json:"name" valid:"required"`
 	Description *string    `json:"description"`
 	Address     *string    `json:"address"`
 	StartTime   *time.Time `json:"startTime"`
@@ -32,6 +36,8 @@ type ParkingLotReq struct {
 	Lat         *float64   `json:"lat"`
 	Long        *float64   `json:"long"`
 	CompanyID   *uuid.UUID `json:"companyID"`
+	Amenities   *[]string  `json:"amenities"`
+	Timezone    *string    `json:"timezone" valid:"omitempty,timezone"`
 }
 
 type ListParkingLotReq struct {
@@ -40,6 +46,8 @@ type ListParkingLotReq struct {
 	Long     *float64 `json:"long" form:"long"`
 	Distance *float64 `json:"distance" form:"distance"`
 	Sort     string   `json:"sort" form:"sort"`
+	Filter   []string `json:"filter" form:"filter"`
+	Cursor   *string  `json:"cursor" form:"cursor"`
 	Page     int      `json:"page" form:"page"`
 	PageSize int      `json:"pageSize" form:"pageSize"`
 }
@@ -55,6 +63,33 @@ type GetListParkingLotReq struct {
 	Lat       *float64 `json:"lat" form:"lat"`
 	Long      *float64 `json:"long" form:"long"`
 	Sort      string   `json:"sort" form:"sort"`
+	Filter    []string `json:"filter" form:"filter"`
+	Cursor    *string  `json:"cursor" form:"cursor"`
 	Page      int      `json:"page" form:"page"`
 	PageSize  int      `json:"pageSize" form:"pageSize"`
 }
+
+type SearchParkingLotReq struct {
+	Query     *string  `json:"q" form:"q"`
+	CompanyID *string  `json:"companyID" form:"companyID" valid:"omitempty,uuid"`
+	Lat       *float64 `json:"lat" form:"lat"`
+	Long      *float64 `json:"long" form:"long"`
+	Distance  *float64 `json:"distance" form:"distance"`
+	Page      int      `json:"page" form:"page"`
+	PageSize  int      `json:"pageSize" form:"pageSize"`
+}
+
+// ParkingLotSearchItem is a parking lot as returned by the search endpoint,
+// it is also the document stored in the Elasticsearch index.
+type ParkingLotSearchItem struct {
+	ParkingLot
+	CompanyName string   `json:"companyName"`
+	Distance    *float64 `json:"distance,omitempty"`
+	// CompanyStatus keeps the parking lots of the companies that are not active out of the index.
+	CompanyStatus string `json:"-"`
+}
+
+type SearchParkingLotRes struct {
+	Data []ParkingLotSearchItem `json:"data,omitempty"`
+	Meta ginext.BodyMeta        `json:"meta" swaggertype:"object"`
+}
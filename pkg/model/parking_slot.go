@@ -8,10 +8,13 @@ import (
 
 type ParkingSlot struct {
 	BaseModel
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	BlockID     uuid.UUID `json:"blockID" gorm:"type:uuid"`
-	Block       *Block    `json:"block,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	VehicleType string `json:"vehicleType"`
+	// DistanceToEntrance is used by the nearest_entrance assignment strategy, in meters.
+	DistanceToEntrance float64   `json:"distanceToEntrance"`
+	BlockID            uuid.UUID `json:"blockID" gorm:"type:uuid"`
+	Block              *Block    `json:"block,omitempty"`
 }
 
 func (ParkingSlot) TableName() string {
@@ -19,23 +22,29 @@ func (ParkingSlot) TableName() string {
 }
 
 type ParkingSlotReq struct {
-	ID          *uuid.UUID `json:"id"`
-	Name        *string    `json:"name"`
-	Description *string    `json:"description"`
-	BlockID     *uuid.UUID `json:"block_id"`
+	ID                 *uuid.UUID `json:"id"`
+	Name               *string    `json:"name"`
+	Description        *string    `json:"description"`
+	VehicleType        *string    `json:"vehicle_type"`
+	DistanceToEntrance *float64   `json:"distance_to_entrance"`
+	BlockID            *uuid.UUID `json:"block_id"`
 }
 
 type ListParkingSlotReq struct {
-	BlockID      *string `json:"blockID" form:"blockID"`
-	ParkingLotId *string `json:"parkingLotId"`
-	Sort         string  `json:"sort" form:"sort"`
-	Page         int     `json:"page" form:"page"`
-	PageSize     int     `json:"pageSize" form:"pageSize"`
+	BlockID      *string  `json:"blockID" form:"blockID"`
+	ParkingLotId *string  `json:"parkingLotId"`
+	Sort         string   `json:"sort" form:"sort"`
+	Filter       []string `json:"filter" form:"filter"`
+	Cursor       *string  `json:"cursor" form:"cursor"`
+	Page         int      `json:"page" form:"page"`
+	PageSize     int      `json:"pageSize" form:"pageSize"`
 }
 type AvailableParkingSlotReq struct {
-	ParkingLotId *string    `json:"parkingLotId" form:"parkingLotId" valid:"Required"`
-	Start        *time.Time `json:"start" form:"start" valid:"Required"`
-	End          *time.Time `json:"end" form:"end" valid:"Required"`
+	ParkingLotId *string    `json:"parkingLotId" form:"parkingLotId" valid:"required"`
+	Start        *time.Time `json:"start" form:"start" valid:"required"`
+	End          *time.Time `json:"end" form:"end" valid:"required"`
+	// UserId sees the slots they hold as available.
+	UserId *uuid.UUID `json:"-" form:"-"`
 }
 
 type ListParkingSlotRes struct {
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ReportGranularityDay   = "day"
+	ReportGranularityWeek  = "week"
+	ReportGranularityMonth = "month"
+
+	ReportGroupByParkingLot = "parking_lot"
+	ReportGroupByBlock      = "block"
+)
+
+type ReportReq struct {
+	CompanyID    *string    `json:"company_id" form:"company_id"`
+	ParkingLotID *string    `json:"parking_lot_id" form:"parking_lot_id"`
+	From         *time.Time `json:"from" form:"from" valid:"required"`
+	To           *time.Time `json:"to" form:"to" valid:"required"`
+	Granularity  string     `json:"granularity" form:"granularity"`
+	GroupBy      string     `json:"group_by" form:"group_by"`
+	Limit        int        `json:"limit" form:"limit"`
+	// Timezone is the zone the buckets are cut in, the one of the parking lot when the report has one.
+	Timezone string `json:"-" form:"-"`
+}
+
+type RevenueReportItem struct {
+	Bucket         time.Time  `json:"bucket"`
+	ParkingLotID   *uuid.UUID `json:"parkingLotId,omitempty"`
+	ParkingLotName string     `json:"parkingLotName,omitempty"`
+	BlockID        *uuid.UUID `json:"blockId,omitempty"`
+	BlockCode      string     `json:"blockCode,omitempty"`
+	Revenue        float64    `json:"revenue"`
+	TicketCount    int        `json:"ticketCount"`
+}
+
+type OccupancyReportItem struct {
+	Bucket          time.Time `json:"bucket"`
+	BookedSeconds   float64   `json:"bookedSeconds"`
+	CapacitySeconds float64   `json:"capacitySeconds"`
+	OccupancyRate   float64   `json:"occupancyRate"`
+}
+
+type TicketStatsReportItem struct {
+	Bucket           time.Time `json:"bucket"`
+	TotalTickets     int       `json:"totalTickets"`
+	CancelledTickets int       `json:"cancelledTickets"`
+	NoShowTickets    int       `json:"noShowTickets"`
+	ExtendedTickets  int       `json:"extendedTickets"`
+	AvgStaySeconds   float64   `json:"avgStaySeconds"`
+	CancellationRate float64   `json:"cancellationRate"`
+	NoShowRate       float64   `json:"noShowRate"`
+	ExtensionRate    float64   `json:"extensionRate"`
+}
+
+type TimeFrameReportItem struct {
+	TimeFrameID  uuid.UUID `json:"timeFrameId"`
+	ParkingLotID uuid.UUID `json:"parkingLotId"`
+	Duration     int       `json:"duration"`
+	Cost         float64   `json:"cost"`
+	TicketCount  int       `json:"ticketCount"`
+	Revenue      float64   `json:"revenue"`
+}
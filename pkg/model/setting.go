@@ -1,19 +1,37 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 )
 
+const (
+	SettingSlotAssignment = "slot_assignment_strategy"
+)
+
+// Setting is a value stored for a parking lot, for every lot of a company when ParkingLotId is empty
+// or for every company when both ids are empty.
 type Setting struct {
 	BaseModel
-	CompanyId    uuid.UUID `json:"company_id"`
+	CompanyId    *uuid.UUID `json:"company_id" gorm:"type:uuid"`
 	Company      *Company
-	ParkingLotId uuid.UUID    `json:"parking_lot_id"`
+	ParkingLotId *uuid.UUID   `json:"parking_lot_id" gorm:"type:uuid"`
 	Key          string       `json:"key"`
-	Value        pgtype.JSONB `json:"value"`
+	Value        pgtype.JSONB `json:"value" gorm:"type:jsonb"`
 }
 
 func (s *Setting) TableName() string {
 	return "setting"
 }
+
+type SettingReq struct {
+	ParkingLotId *uuid.UUID      `json:"parking_lot_id" valid:"required"`
+	Key          *string         `json:"key" valid:"required"`
+	Value        json.RawMessage `json:"value" swaggertype:"object"`
+}
+
+type GetSettingReq struct {
+	ParkingLotId *string `json:"parking_lot_id" form:"parking_lot_id" valid:"required"`
+	Key          *string `json:"key" form:"key" valid:"required"`
+}
@@ -0,0 +1,16 @@
+package model
+
+const (
+	SlotAssignFillByBlock     = "fill_by_block"
+	SlotAssignNearestEntrance = "nearest_entrance"
+	SlotAssignBalanceWear     = "balance_wear"
+	SlotAssignVehicleTypeFit  = "vehicle_type_fit"
+)
+
+// SlotCandidate is a free slot offered to an assignment strategy, in block code and slot name order.
+type SlotCandidate struct {
+	ParkingSlot
+	BlockCode string `json:"blockCode"`
+	// UsageCount is the number of tickets of the slot over the last 30 days.
+	UsageCount int `json:"usageCount"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+// SlotDowntime closes a slot, or every slot of a block, from StartTime to EndTime.
+type SlotDowntime struct {
+	BaseModel
+	ParkingLotID  uuid.UUID  `json:"parkingLotId" gorm:"type:uuid;not null;index"`
+	BlockID       *uuid.UUID `json:"blockId,omitempty" gorm:"type:uuid"`
+	ParkingSlotID *uuid.UUID `json:"parkingSlotId,omitempty" gorm:"type:uuid"`
+	StartTime     time.Time  `json:"startTime"`
+	EndTime       time.Time  `json:"endTime"`
+	Reason        string     `json:"reason"`
+}
+
+func (SlotDowntime) TableName() string {
+	return "slot_downtime"
+}
+
+// SlotDowntimeReq closes either ParkingSlotID or BlockID. Bookings inside the window are refused
+// unless Relocate is set, in which case they are moved to another free slot of the parking lot.
+type SlotDowntimeReq struct {
+	ID            *uuid.UUID `json:"id"`
+	ParkingSlotID *uuid.UUID `json:"parking_slot_id"`
+	BlockID       *uuid.UUID `json:"block_id"`
+	StartTime     *time.Time `json:"start_time" valid:"required"`
+	EndTime       *time.Time `json:"end_time" valid:"required,gtfield=StartTime"`
+	Reason        *string    `json:"reason"`
+	Relocate      *bool      `json:"relocate"`
+}
+
+type ListSlotDowntimeReq struct {
+	ParkingLotID  *string    `json:"parking_lot_id" form:"parking_lot_id" valid:"required"`
+	BlockID       *string    `json:"block_id" form:"block_id"`
+	ParkingSlotID *string    `json:"parking_slot_id" form:"parking_slot_id"`
+	From          *time.Time `json:"from" form:"from"`
+	To            *time.Time `json:"to" form:"to"`
+	Page          int        `json:"page" form:"page"`
+	PageSize      int        `json:"page_size" form:"page_size"`
+}
+
+type ListSlotDowntimeRes struct {
+	Data []SlotDowntime  `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
+
+type SlotDowntimeRes struct {
+	SlotDowntime
+	RelocatedTickets []RelocatedTicket `json:"relocatedTickets"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	SettingSlotHoldTTL = "slot_hold_ttl"
+	// DefaultSlotHoldTTL is the hold time in seconds when the parking lot has no slot_hold_ttl setting.
+	DefaultSlotHoldTTL = 300
+)
+
+// SlotHold keeps a slot for a user from StartTime to EndTime while they check out. Other users cannot see
+// or book the slot until ExpiresAt, or until the hold becomes the ticket TicketId.
+type SlotHold struct {
+	BaseModel
+	UserId        *uuid.UUID `json:"userId" gorm:"type:uuid"`
+	ParkingLotId  uuid.UUID  `json:"parkingLotId" gorm:"type:uuid"`
+	ParkingSlotId uuid.UUID  `json:"parkingSlotId" gorm:"type:uuid"`
+	StartTime     time.Time  `json:"startTime"`
+	EndTime       time.Time  `json:"endTime"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	TicketId      *uuid.UUID `json:"ticketId,omitempty" gorm:"type:uuid"`
+}
+
+func (SlotHold) TableName() string {
+	return "slot_hold"
+}
+
+type SlotHoldReq struct {
+	UserId *uuid.UUID `json:"-"`
+	// VehicleId is used to pick a slot for its vehicle type when ParkingSlotId is empty.
+	VehicleId     *uuid.UUID `json:"vehicleId"`
+	ParkingLotId  *uuid.UUID `json:"parkingLotId" valid:"required"`
+	ParkingSlotId *uuid.UUID `json:"parkingSlotId"`
+	StartTime     *time.Time `json:"startTime" valid:"required"`
+	EndTime       *time.Time `json:"endTime" valid:"required,gtfield=StartTime"`
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
 	"time"
 )
 
@@ -31,32 +32,54 @@ func (t *Ticket) TableName() string {
 }
 
 type CancelTicketRequest struct {
-	TicketId string `json:"ticketId"`
+	TicketId string `json:"ticketId" valid:"required,uuid"`
 }
 type GetListTicketParam struct {
-	UserId *string `json:"userId" form:"userId" valid:"Required"`
-	State  *string `json:"state" form:"state"`
+	UserId   *string `json:"userId" form:"userId" valid:"required"`
+	State    *string `json:"state" form:"state"`
+	Cursor   string  `json:"cursor" form:"cursor"`
+	PageSize int     `json:"pageSize" form:"pageSize"`
+}
+
+type ListTicketRes struct {
+	Data []Ticket        `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
 }
 type TicketReq struct {
-	VehicleId     *uuid.UUID `json:"vehicleId" valid:"Required"`
-	UserId        *uuid.UUID `json:"userId" valid:"Required"`
-	ParkingSlotId *uuid.UUID `json:"parkingSlotId" valid:"Required"`
-	ParkingLotId  *uuid.UUID `json:"parkingLotId" valid:"Required"`
-	TimeFrameId   *uuid.UUID `json:"timeFrameId" valid:"Required"`
-	StartTime     *time.Time `json:"startTime" valid:"Required"`
-	EndTime       *time.Time `json:"endTime" valid:"Required"`
-	EntryTime     *time.Time `json:"entryTime"`
-	ExitTime      *time.Time `json:"exitTime"`
-	Total         *float64   `json:"total"`
-	IsLongTerm    bool       `json:"isLongTerm"`
-	Type          string     `json:"type"`
+	VehicleId *uuid.UUID `json:"vehicleId" valid:"required"`
+	UserId    *uuid.UUID `json:"userId" valid:"required"`
+	// ParkingSlotId is chosen by the assignment strategy of the parking lot when empty.
+	ParkingSlotId *uuid.UUID `json:"parkingSlotId"`
+	// SlotHoldId books the slot held by the user during checkout.
+	SlotHoldId   *uuid.UUID `json:"slotHoldId"`
+	ParkingLotId *uuid.UUID `json:"parkingLotId" valid:"required"`
+	TimeFrameId  *uuid.UUID `json:"timeFrameId" valid:"required"`
+	StartTime    *time.Time `json:"startTime" valid:"required"`
+	EndTime      *time.Time `json:"endTime" valid:"required,gtfield=StartTime"`
+	EntryTime    *time.Time `json:"entryTime"`
+	ExitTime     *time.Time `json:"exitTime"`
+	Total        *float64   `json:"total" valid:"omitempty,gte=0"`
+	IsLongTerm   bool       `json:"isLongTerm"`
+	Type         string     `json:"type"`
+}
+
+// ReassignTicketReq moves a future ticket, with its extensions, to ParkingSlotId or to the slot chosen by
+// the assignment strategy of the parking lot. TicketId may be an extension, its origin ticket is moved.
+// UserId, set from the caller on the user API, must own the ticket. CompanyId, required on the merchant
+// API, must own its parking lot.
+type ReassignTicketReq struct {
+	TicketId      *uuid.UUID `json:"ticketId" valid:"required"`
+	ParkingSlotId *uuid.UUID `json:"parkingSlotId"`
+	CompanyId     *uuid.UUID `json:"companyId"`
+	UserId        *uuid.UUID `json:"-"`
 }
+
 type ExtendTicketReq struct {
-	TicketOriginId *uuid.UUID `json:"ticketOriginId" valid:"Required"`
-	TimeFrameId    *uuid.UUID `json:"timeFrameId" valid:"Required"`
-	StartTime      *time.Time `json:"startTime" valid:"Required"`
-	EndTime        *time.Time `json:"endTime" valid:"Required"`
-	Total          *float64   `json:"total"`
+	TicketOriginId *uuid.UUID `json:"ticketOriginId" valid:"required"`
+	TimeFrameId    *uuid.UUID `json:"timeFrameId" valid:"required"`
+	StartTime      *time.Time `json:"startTime" valid:"required"`
+	EndTime        *time.Time `json:"endTime" valid:"required,gtfield=StartTime"`
+	Total          *float64   `json:"total" valid:"omitempty,gte=0"`
 }
 type TicketResponse struct {
 	Ticket
@@ -64,12 +87,19 @@ type TicketResponse struct {
 }
 
 type ProcedureReq struct {
-	Type     string `json:"type"`
-	TicketId string `json:"ticketId"`
+	Type     string `json:"type" valid:"required,oneof=check_in check_out"`
+	TicketId string `json:"ticketId" valid:"required,uuid"`
 }
 type GetListTicketReq struct {
 	ParkingLotID *string `json:"parking_lot_id" form:"parking_lot_id"`
 	State        *string `json:"state" form:"state"`
+	Cursor       string  `json:"cursor" form:"cursor"`
+	PageSize     int     `json:"page_size" form:"page_size"`
+}
+
+type ListTicketCompanyRes struct {
+	Data []GetListTicketRes `json:"data"`
+	Meta ginext.BodyMeta    `json:"meta" swaggertype:"object"`
 }
 
 type GetListTicketRes struct {
@@ -88,4 +118,5 @@ type GetListTicketRes struct {
 	Total         float64      `json:"total"`
 	State         string       `json:"state"`
 	IsExtend      bool         `json:"isExtend"`
+	CreatedAt     time.Time    `json:"createdAt"`
 }
@@ -16,22 +16,22 @@ func (timeFrame *TimeFrame) TableName() string {
 }
 
 type TimeFrameReq struct {
-	Duration     int       `json:"duration" valid:"Required"`
-	Cost         float64   `json:"cost" valid:"Required"`
-	ParkingLotId uuid.UUID `json:"parkingLotId" valid:"Required"`
+	Duration     int       `json:"duration" valid:"required,gt=0"`
+	Cost         float64   `json:"cost" valid:"required,gt=0"`
+	ParkingLotId uuid.UUID `json:"parkingLotId" valid:"required"`
 }
 type ListTimeFrameReq struct {
-	Data []TimeFrameReq `json:"data"`
+	Data []TimeFrameReq `json:"data" valid:"dive"`
 }
 type GetListTimeFrameParam struct {
-	ParkingLotId *string `json:"parkingLotId" form:"parkingLotId" valid:"Required"`
+	ParkingLotId *string `json:"parkingLotId" form:"parkingLotId" valid:"required"`
 }
 type ListTimeFrame struct {
 	Data []TimeFrame `json:"data"`
 }
 
 type TimeFrameRequest struct {
-	Duration     *int       `json:"duration" valid:"Required"`
-	Cost         *float64   `json:"cost" valid:"Required"`
-	ParkingLotId *uuid.UUID `json:"parkingLotId" valid:"Required"`
+	Duration     *int       `json:"duration" valid:"required,gt=0"`
+	Cost         *float64   `json:"cost" valid:"required,gt=0"`
+	ParkingLotId *uuid.UUID `json:"parkingLotId" valid:"required"`
 }
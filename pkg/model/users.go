@@ -12,15 +12,22 @@ type User struct {
 	ImageUrl    string `json:"imageUrl"`
 	Password    string `json:"password" gorm:"not null"`
 	PhoneNumber string `json:"phoneNumber" gorm:"not null"`
+	// Role is a set of the utils.*_ROLE flags.
+	Role int `json:"role" gorm:"not null;default:1"`
 }
 
 func (user *User) TableName() string {
 	return "users"
 }
 
+// HasRole reports whether the user has every flag of role.
+func (user *User) HasRole(role int) bool {
+	return user.Role&role == role
+}
+
 type Credential struct {
-	UserName *string `json:"user_name" valid:"Required"`
-	Password *string `json:"password" valid:"Required"`
+	UserName *string `json:"user_name" valid:"required"`
+	Password *string `json:"password" valid:"required"`
 }
 type LoginResponse struct {
 	AccessToken  string    `json:"accessToken"`
@@ -32,19 +39,19 @@ type LoginResponse struct {
 	ImageUrl     string    `json:"imageUrl"`
 }
 type CheckPhoneReq struct {
-	PhoneNumber string `json:"phone_number" valid:"Required"`
+	PhoneNumber string `json:"phone_number" valid:"required,phone"`
 }
 type UserReq struct {
-	ID          *uuid.UUID `json:"id" valid:"Required"`
+	ID          *uuid.UUID `json:"id" valid:"required"`
 	DisplayName *string    `json:"displayName"`
 	ImageUrl    *string    `json:"imageUrl"`
 	Password    *string    `json:"password"`
-	PhoneNumber *string    `json:"phoneNumber"`
-	Email       *string    `json:"email"`
+	PhoneNumber *string    `json:"phoneNumber" valid:"omitempty,phone"`
+	Email       *string    `json:"email" valid:"omitempty,email"`
 }
 type CreateUserReq struct {
 	DisplayName *string `json:"display_name"`
-	Password    *string `json:"password" valid:"Required"`
-	PhoneNumber *string `json:"phone_number" valid:"Required"`
-	Email       *string `json:"email"`
+	Password    *string `json:"password" valid:"required"`
+	PhoneNumber *string `json:"phone_number" valid:"required,phone"`
+	Email       *string `json:"email" valid:"omitempty,email"`
 }
@@ -26,11 +26,13 @@ type VehicleReq struct {
 }
 
 type ListVehicleReq struct {
-	UserID   *string `json:"user_id" form:"user_id"`
-	Type     *string `json:"type" form:"type"`
-	Sort     string  `json:"sort" form:"sort"`
-	Page     int     `json:"page" form:"page"`
-	PageSize int     `json:"page_size" form:"page_size"`
+	UserID   *string  `json:"user_id" form:"user_id"`
+	Type     *string  `json:"type" form:"type"`
+	Sort     string   `json:"sort" form:"sort"`
+	Filter   []string `json:"filter" form:"filter"`
+	Cursor   *string  `json:"cursor" form:"cursor"`
+	Page     int      `json:"page" form:"page"`
+	PageSize int      `json:"page_size" form:"page_size"`
 }
 
 type ListVehicleRes struct {
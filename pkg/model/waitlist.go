@@ -0,0 +1,62 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+const (
+	WaitlistStateWaiting   = "waiting"
+	WaitlistStateOffered   = "offered"
+	WaitlistStateBooked    = "booked"
+	WaitlistStateExpired   = "expired"
+	WaitlistStateCancelled = "cancelled"
+)
+
+// Waitlist is the interest of a user for a full parking lot from StartTime to EndTime. When a slot frees
+// up the oldest waiting entry it fits is offered ParkingSlotID until OfferExpiresAt.
+type Waitlist struct {
+	BaseModel
+	UserId         *uuid.UUID `json:"userId" gorm:"type:uuid"`
+	VehicleId      *uuid.UUID `json:"vehicleId" gorm:"type:uuid"`
+	ParkingLotId   *uuid.UUID `json:"parkingLotId" gorm:"type:uuid"`
+	TimeFrameId    *uuid.UUID `json:"timeFrameId" gorm:"type:uuid"`
+	StartTime      time.Time  `json:"startTime"`
+	EndTime        time.Time  `json:"endTime"`
+	State          string     `json:"state"`
+	ParkingSlotId  *uuid.UUID `json:"parkingSlotId,omitempty" gorm:"type:uuid"`
+	OfferExpiresAt *time.Time `json:"offerExpiresAt,omitempty"`
+	TicketId       *uuid.UUID `json:"ticketId,omitempty" gorm:"type:uuid"`
+}
+
+func (Waitlist) TableName() string {
+	return "waitlist"
+}
+
+type WaitlistReq struct {
+	UserId       *uuid.UUID `json:"-"`
+	VehicleId    *uuid.UUID `json:"vehicleId" valid:"required"`
+	ParkingLotId *uuid.UUID `json:"parkingLotId" valid:"required"`
+	TimeFrameId  *uuid.UUID `json:"timeFrameId" valid:"required"`
+	StartTime    *time.Time `json:"startTime" valid:"required"`
+	EndTime      *time.Time `json:"endTime" valid:"required,gtfield=StartTime"`
+}
+
+type AcceptWaitlistReq struct {
+	ID     *uuid.UUID `json:"-"`
+	UserId *uuid.UUID `json:"-"`
+	Total  *float64   `json:"total" valid:"omitempty,gte=0"`
+}
+
+type ListWaitlistReq struct {
+	UserId   *uuid.UUID `json:"-" form:"-"`
+	State    *string    `json:"state" form:"state" valid:"omitempty,oneof=waiting offered booked expired cancelled"`
+	Page     int        `json:"page" form:"page"`
+	PageSize int        `json:"pageSize" form:"pageSize"`
+}
+
+type ListWaitlistRes struct {
+	Data []Waitlist      `json:"data"`
+	Meta ginext.BodyMeta `json:"meta" swaggertype:"object"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/lib/pq"
+	"gitlab.com/goxp/cloud0/ginext"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends the events of EventTypes of a company to Url, signed with Secret.
+type WebhookSubscription struct {
+	BaseModel
+	CompanyId  uuid.UUID      `json:"companyId" gorm:"type:uuid;index"`
+	Url        string         `json:"url"`
+	Secret     string         `json:"secret,omitempty"`
+	EventTypes pq.StringArray `json:"eventTypes" gorm:"type:text[]" swaggertype:"array,string"`
+	Active     bool           `json:"active"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscription"
+}
+
+type WebhookSubscriptionReq struct {
+	ID        *uuid.UUID `json:"-"`
+	CompanyId *uuid.UUID `json:"companyId" valid:"required"`
+	Url       *string    `json:"url" valid:"required"`
+	// Secret is generated when empty, it is only returned by create.
+	Secret     *string  `json:"secret"`
+	EventTypes []string `json:"eventTypes"`
+	Active     *bool    `json:"active"`
+}
+
+type ListWebhookSubscriptionReq struct {
+	CompanyId *string `json:"companyId" form:"companyId" valid:"required"`
+}
+
+// WebhookCompanyReq is the query of the webhook endpoints working on one subscription or delivery of the company.
+type WebhookCompanyReq struct {
+	CompanyId *string `json:"companyId" form:"companyId" valid:"required,uuid"`
+}
+
+// WebhookDelivery is one event to send to one subscription, with the outcome of the last attempt.
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionId uuid.UUID    `json:"subscriptionId" gorm:"type:uuid;uniqueIndex:idx_webhook_delivery_event"`
+	EventId        uuid.UUID    `json:"eventId" gorm:"type:uuid;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string       `json:"eventType"`
+	Payload        pgtype.JSONB `json:"payload" gorm:"type:jsonb" swaggertype:"object"`
+	Status         string       `json:"status" gorm:"index"`
+	Attempts       int          `json:"attempts"`
+	NextAttemptAt  time.Time    `json:"nextAttemptAt" gorm:"index"`
+	LastStatusCode int          `json:"lastStatusCode"`
+	LastError      string       `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time   `json:"deliveredAt,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+type ListWebhookDeliveryReq struct {
+	SubscriptionId *string `json:"subscriptionId" form:"subscriptionId" valid:"required"`
+	Status         *string `json:"status" form:"status"`
+	Page           int     `json:"page" form:"page"`
+	PageSize       int     `json:"pageSize" form:"pageSize"`
+}
+
+type ListWebhookDeliveryRes struct {
+	Data []WebhookDelivery `json:"data"`
+	Meta ginext.BodyMeta   `json:"meta" swaggertype:"object"`
+}
@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gitlab.com/goxp/cloud0/logger"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	ChannelPush  = "push"
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+
+	ProviderLog  = "log"
+	ProviderFile = "file"
+)
+
+// Message is what a provider delivers to one recipient: a device token for push, a phone number for sms
+// and an address for email.
+type Message struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+}
+
+// Provider delivers the messages of one channel. Real gateways (FCM, an SMS operator, SMTP) implement it
+// next to the fakes below.
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogProvider only writes the messages to the application log.
+type LogProvider struct{}
+
+func (LogProvider) Send(ctx context.Context, msg Message) error {
+	logger.WithCtx(ctx, "notification.LogProvider").WithField("channel", msg.Channel).WithField("to", msg.To).
+		Infof("%s: %s", msg.Title, msg.Body)
+	return nil
+}
+
+// FileProvider appends the messages as JSON lines to a file, handy to check them in local runs.
+type FileProvider struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (p *FileProvider) Send(_ context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := os.OpenFile(p.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// NewProviders returns the same kind of fake provider for every channel, kind is "log" or "file".
+func NewProviders(kind, path string) (map[string]Provider, error) {
+	var provider Provider
+	switch kind {
+	case ProviderLog, "":
+		provider = LogProvider{}
+	case ProviderFile:
+		provider = &FileProvider{Path: path}
+	default:
+		return nil, fmt.Errorf("unknown notification provider %s", kind)
+	}
+	return map[string]Provider{
+		ChannelPush:  provider,
+		ChannelSMS:   provider,
+		ChannelEmail: provider,
+	}, nil
+}
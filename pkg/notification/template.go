@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"parkar-server/pkg/utils"
+	"text/template"
+	"time"
+)
+
+const (
+	EventBookingConfirmed = "booking_confirmed"
+	EventBookingEnding    = "booking_ending"
+	EventOverstay         = "overstay"
+	EventBookingCancelled = "booking_cancelled"
+	EventBookingRelocated = "booking_relocated"
+	EventWaitlistOffered  = "waitlist_offered"
+	EventWaitlistExpired  = "waitlist_expired"
+	EventCompanyVerify    = "company_verify_email"
+	EventCompanyApproved  = "company_approved"
+	EventCompanySuspended = "company_suspended"
+
+	LangVI = "vi"
+	LangEN = "en"
+)
+
+// Data is what the templates can show about a booking or a company.
+type Data struct {
+	ParkingLot string
+	Slot       string
+	Start      time.Time
+	End        time.Time
+	// Deadline is when a waitlist offer expires.
+	Deadline time.Time
+	// Location is the zone of the parking lot the times are shown in, the default zone when nil.
+	Location *time.Location
+	Company  string
+	// Link is the email verification link of a company, Reason why it was suspended.
+	Link   string
+	Reason string
+}
+
+type messageTemplate struct {
+	title string
+	body  string
+}
+
+var templates = map[string]map[string]messageTemplate{
+	EventBookingConfirmed: {
+		LangVI: {"Đặt chỗ thành công", "Vị trí {{.Slot}} tại {{.ParkingLot}} đã được giữ cho bạn từ {{time .Start}} đến {{time .End}}."},
+		LangEN: {"Booking confirmed", "Slot {{.Slot}} at {{.ParkingLot}} is yours from {{time .Start}} to {{time .End}}."},
+	},
+	EventBookingEnding: {
+		LangVI: {"Sắp hết giờ đỗ xe", "Vé tại {{.ParkingLot}} sẽ hết hạn lúc {{time .End}}, vui lòng gia hạn hoặc lấy xe."},
+		LangEN: {"Your parking ends soon", "Your ticket at {{.ParkingLot}} ends at {{time .End}}, please extend it or pick up your vehicle."},
+	},
+	EventOverstay: {
+		LangVI: {"Quá giờ đỗ xe", "Vé tại {{.ParkingLot}} đã hết hạn lúc {{time .End}}, phí quá giờ có thể được áp dụng."},
+		LangEN: {"Parking time exceeded", "Your ticket at {{.ParkingLot}} ended at {{time .End}}, overstay fees may apply."},
+	},
+	EventBookingCancelled: {
+		LangVI: {"Vé đã bị hủy", "Vé tại {{.ParkingLot}} từ {{time .Start}} đến {{time .End}} đã bị hủy."},
+		LangEN: {"Booking cancelled", "Your booking at {{.ParkingLot}} from {{time .Start}} to {{time .End}} was cancelled."},
+	},
+	EventBookingRelocated: {
+		LangVI: {"Vé đã được đổi vị trí", "Vé của bạn tại {{.ParkingLot}} đã được chuyển sang vị trí {{.Slot}}."},
+		LangEN: {"Booking moved", "Your booking at {{.ParkingLot}} was moved to slot {{.Slot}}."},
+	},
+	EventWaitlistOffered: {
+		LangVI: {"Đã có chỗ trống", "Vị trí {{.Slot}} tại {{.ParkingLot}} đang được giữ cho bạn, vui lòng xác nhận trước {{time .Deadline}}."},
+		LangEN: {"A slot is free", "Slot {{.Slot}} at {{.ParkingLot}} is held for you, please accept it before {{time .Deadline}}."},
+	},
+	EventWaitlistExpired: {
+		LangVI: {"Đề nghị giữ chỗ đã hết hạn", "Đề nghị giữ chỗ tại {{.ParkingLot}} đã hết hạn."},
+		LangEN: {"Offer expired", "The slot offered at {{.ParkingLot}} is no longer held for you."},
+	},
+	EventCompanyVerify: {
+		LangVI: {"Xác nhận email", "Mở liên kết sau để xác nhận email của {{.Company}} trước {{time .Deadline}}: {{.Link}}"},
+		LangEN: {"Verify your email", "Open this link to verify the email of {{.Company}} before {{time .Deadline}}: {{.Link}}"},
+	},
+	EventCompanyApproved: {
+		LangVI: {"Công ty đã được phê duyệt", "{{.Company}} đã được phê duyệt, bạn có thể tạo bãi đỗ xe."},
+		LangEN: {"Company approved", "{{.Company}} is approved, you can now create parking lots."},
+	},
+	EventCompanySuspended: {
+		LangVI: {"Công ty đã bị tạm ngưng", "{{.Company}} đã bị tạm ngưng, các bãi đỗ xe không còn được hiển thị.{{if .Reason}} Lý do: {{.Reason}}{{end}}"},
+		LangEN: {"Company suspended", "{{.Company}} is suspended, its parking lots are no longer listed.{{if .Reason}} Reason: {{.Reason}}{{end}}"},
+	},
+}
+
+// funcs are the template functions, times are shown in the zone of data.
+func funcs(data Data) template.FuncMap {
+	loc := data.Location
+	if loc == nil {
+		loc = utils.Location(utils.TIMEZONE_VN)
+	}
+	return template.FuncMap{
+		"time": func(t time.Time) string { return t.In(loc).Format("15:04 02/01/2006") },
+	}
+}
+
+// Render fills the title and body of event in lang, Vietnamese when lang has no translation.
+func Render(event, lang string, data Data) (title, body string, err error) {
+	byLang, ok := templates[event]
+	if !ok {
+		return "", "", fmt.Errorf("no template for event %s", event)
+	}
+	tmpl, ok := byLang[lang]
+	if !ok {
+		tmpl = byLang[LangVI]
+	}
+	if title, err = execute(tmpl.title, data); err != nil {
+		return "", "", err
+	}
+	body, err = execute(tmpl.body, data)
+	return title, body, err
+}
+
+func execute(text string, data Data) (string, error) {
+	t, err := template.New("").Funcs(funcs(data)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
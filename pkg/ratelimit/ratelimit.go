@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket holding Burst tokens, refilled at Burst tokens per Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Lockout locks a key once it failed Threshold times in a row, for Base then twice as long on every further
+// failure up to Max. A success resets the count.
+type Lockout struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// Store keeps the buckets and the failure counts. MemoryStore keeps them in the process, a store shared by
+// every instance, such as one backed by Redis, is needed when the server runs more than once.
+type Store interface {
+	// Allow takes a token from the bucket of key, when it is empty it returns false and when to retry.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+	// Locked returns how long key is still locked, 0 when it is not.
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// Fail counts a failure of key and returns how long it is locked for, 0 when it is not.
+	Fail(ctx context.Context, key string, lockout Lockout) (time.Duration, error)
+	// Reset forgets the failures of key.
+	Reset(ctx context.Context, key string) error
+}
+
+const (
+	// memorySweepInterval is how often MemoryStore drops the entries that went idle.
+	memorySweepInterval = 10 * time.Minute
+	// memoryIdleTTL is how long an untouched entry is kept, longer than any limit period or lockout.
+	memoryIdleTTL = 2 * time.Hour
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type failure struct {
+	count       int
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// MemoryStore is a Store for a single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	failures  map[string]*failure
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   map[string]*bucket{},
+		failures:  map[string]*failure{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Burst <= 0 || limit.Period <= 0 {
+		return true, 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	rate := float64(limit.Burst) / limit.Period.Seconds()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+}
+
+func (s *MemoryStore) Locked(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.failures[key]
+	if !ok {
+		return 0, nil
+	}
+	if left := f.lockedUntil.Sub(time.Now()); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Fail(_ context.Context, key string, lockout Lockout) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	f, ok := s.failures[key]
+	if !ok {
+		f = &failure{}
+		s.failures[key] = f
+	}
+	f.count++
+	f.last = now
+	if lockout.Threshold <= 0 || f.count < lockout.Threshold {
+		return 0, nil
+	}
+	lock := LockDuration(lockout, f.count)
+	f.lockedUntil = now.Add(lock)
+	return lock, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	return nil
+}
+
+// sweep drops the entries idle for memoryIdleTTL, a full bucket or an old failure changes nothing.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > memoryIdleTTL {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if now.Sub(f.last) > memoryIdleTTL && now.After(f.lockedUntil) {
+			delete(s.failures, key)
+		}
+	}
+}
+
+// LockDuration is how long a key is locked after failures failures in a row.
+func LockDuration(lockout Lockout, failures int) time.Duration {
+	lock := lockout.Base
+	for i := lockout.Threshold; i < failures && lock < lockout.Max; i++ {
+		lock *= 2
+	}
+	if lockout.Max > 0 && lock > lockout.Max {
+		lock = lockout.Max
+	}
+	return lock
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// elapse moves the entries of key back in time as if d had passed.
+func (s *MemoryStore) elapse(key string, d time.Duration) {
+	if b, ok := s.buckets[key]; ok {
+		b.last = b.last.Add(-d)
+	}
+	if f, ok := s.failures[key]; ok {
+		f.last = f.last.Add(-d)
+		f.lockedUntil = f.lockedUntil.Add(-d)
+	}
+}
+
+func TestMemoryStoreAllow(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := Limit{Burst: 3, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := s.Allow(ctx, "a", limit); !ok {
+			t.Fatalf("request %d refused within the burst", i+1)
+		}
+	}
+	ok, retry, err := s.Allow(ctx, "a", limit)
+	if err != nil || ok {
+		t.Fatalf("request over the burst = %v, %v", ok, err)
+	}
+	// a token comes back every 20s
+	if retry <= 19*time.Second || retry > 20*time.Second {
+		t.Errorf("retry after %v, want about 20s", retry)
+	}
+	if ok, _, _ := s.Allow(ctx, "b", limit); !ok {
+		t.Error("another key shares the bucket")
+	}
+
+	s.elapse("a", 20*time.Second)
+	if ok, _, _ := s.Allow(ctx, "a", limit); !ok {
+		t.Error("no token refilled after 20s")
+	}
+	if ok, _, _ := s.Allow(ctx, "a", limit); ok {
+		t.Error("more than one token refilled after 20s")
+	}
+
+	// the bucket never holds more than the burst
+	s.elapse("a", time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := s.Allow(ctx, "a", limit); !ok {
+			t.Fatalf("request %d refused after a full refill", i+1)
+		}
+	}
+	if ok, _, _ := s.Allow(ctx, "a", limit); ok {
+		t.Error("the bucket refilled over the burst")
+	}
+}
+
+func TestMemoryStoreAllowWithoutLimit(t *testing.T) {
+	s := NewMemoryStore()
+	for _, limit := range []Limit{{}, {Burst: 5}, {Period: time.Minute}} {
+		for i := 0; i < 10; i++ {
+			if ok, _, _ := s.Allow(context.Background(), "a", limit); !ok {
+				t.Fatalf("Allow(%+v) refused a request", limit)
+			}
+		}
+	}
+}
+
+func TestMemoryStoreLockout(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	lockout := Lockout{Threshold: 3, Base: time.Minute, Max: 5 * time.Minute}
+
+	for i := 1; i < 3; i++ {
+		if lock, _ := s.Fail(ctx, "a", lockout); lock != 0 {
+			t.Fatalf("failure %d locked for %v before the threshold", i, lock)
+		}
+	}
+	if left, _ := s.Locked(ctx, "a"); left != 0 {
+		t.Fatalf("locked for %v before the threshold", left)
+	}
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		lock, err := s.Fail(ctx, "a", lockout)
+		if err != nil || lock != want {
+			t.Fatalf("Fail() = %v, %v, want %v", lock, err, want)
+		}
+		if left, _ := s.Locked(ctx, "a"); left <= 0 || left > want {
+			t.Fatalf("Locked() = %v after a lock of %v", left, want)
+		}
+	}
+	if left, _ := s.Locked(ctx, "b"); left != 0 {
+		t.Errorf("another key is locked for %v", left)
+	}
+
+	s.elapse("a", 5*time.Minute)
+	if left, _ := s.Locked(ctx, "a"); left != 0 {
+		t.Errorf("still locked for %v once the lock ended", left)
+	}
+
+	// a success starts the count again
+	if err := s.Reset(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if lock, _ := s.Fail(ctx, "a", lockout); lock != 0 {
+		t.Errorf("locked for %v on the first failure after a reset", lock)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	lockout := Lockout{Threshold: 1, Base: 3 * time.Hour}
+	s.Allow(ctx, "idle", Limit{Burst: 1, Period: time.Minute})
+	s.Fail(ctx, "idle", Lockout{Threshold: 5})
+	s.Fail(ctx, "locked", lockout)
+	s.elapse("idle", memoryIdleTTL+time.Minute)
+	s.failures["locked"].last = s.failures["locked"].last.Add(-memoryIdleTTL - time.Minute)
+	s.lastSweep = s.lastSweep.Add(-memorySweepInterval)
+
+	s.Allow(ctx, "other", Limit{Burst: 1, Period: time.Minute})
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket kept")
+	}
+	if _, ok := s.failures["idle"]; ok {
+		t.Error("idle failures kept")
+	}
+	if _, ok := s.failures["locked"]; !ok {
+		t.Error("failures of a locked key dropped")
+	}
+}
+
+func TestLockDuration(t *testing.T) {
+	lockout := Lockout{Threshold: 5, Base: time.Minute, Max: time.Hour}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{10, 32 * time.Minute},
+		{11, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := LockDuration(lockout, tt.failures); got != tt.want {
+			t.Errorf("LockDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
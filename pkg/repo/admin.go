@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+// GetListCompanyAdmin lists the companies of the platform, the latest first, without their password.
+func (r *RepoPG) GetListCompanyAdmin(ctx context.Context, req model.AdminListCompanyReq) (res model.AdminListCompanyRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.Company{}).Omit("password")
+	if req.Status != nil {
+		tx = tx.Where("status = ?", req.Status)
+	}
+	if req.Search != nil {
+		search := "%" + *req.Search + "%"
+		tx = tx.Where("(name ilike ? or email ilike ? or phone_number ilike ?)", search, search, search)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListCompanyAdmin")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetListUserAdmin lists the users of the platform, the latest first, without their password.
+func (r *RepoPG) GetListUserAdmin(ctx context.Context, req model.AdminListUserReq) (res model.AdminListUserRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.User{}).Omit("password")
+	if req.Search != nil {
+		search := "%" + *req.Search + "%"
+		tx = tx.Where("(display_name ilike ? or email ilike ? or phone_number ilike ?)", search, search, search)
+	}
+	if req.Role != nil {
+		tx = tx.Where("role & ? = ?", req.Role, req.Role)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListUserAdmin")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// UpdateUserRole sets the role flags of the user.
+func (r *RepoPG) UpdateUserRole(ctx context.Context, id uuid.UUID, role int) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	err := tx.Model(&model.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()}).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to UpdateUserRole")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// GetPlatformStats counts the entities of every company, the tickets and their revenue are those starting
+// between from and to. Cancelled tickets are not counted, as in the revenue report.
+func (r *RepoPG) GetPlatformStats(ctx context.Context, from, to time.Time) (res model.PlatformStats, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	query := `select
+					(select count(*) from users where deleted_at is null) as users,
+					(select count(*) from parking_lot where deleted_at is null) as parking_lots,
+					(select count(*) from parking_slot where deleted_at is null) as parking_slots,
+					count(t.id) as tickets,
+					coalesce(sum(t.total), 0) as revenue
+				from ticket t
+				where t.deleted_at is null
+					and t.state <> 'cancel'
+					and t.start_time >= @from
+					and t.start_time < @to`
+	args := map[string]interface{}{"from": from, "to": to}
+	if err := tx.Raw(utils.RemoveSpace(query), args).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetPlatformStats")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	var companies []struct {
+		Status string
+		Total  int64
+	}
+	err = tx.Model(&model.Company{}).Select("status, count(*) as total").Group("status").Scan(&companies).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to GetPlatformStats")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	res.Companies = map[string]int64{}
+	for _, c := range companies {
+		res.Companies[c.Status] = c.Total
+	}
+	res.From, res.To = from, to
+	return res, nil
+}
+
+// GetListGlobalSetting returns the settings stored for every company, by key.
+func (r *RepoPG) GetListGlobalSetting(ctx context.Context) (res []model.Setting, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	err = tx.Model(&model.Setting{}).Where("company_id is null and parking_lot_id is null").Order("key").Find(&res).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to GetListGlobalSetting")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// DeleteGlobalSetting deletes the setting of key stored for every company, the companies fall back to the
+// default value unless they set their own.
+func (r *RepoPG) DeleteGlobalSetting(ctx context.Context, key string) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Where("key = ? and company_id is null and parking_lot_id is null", key).Delete(&model.Setting{})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to DeleteGlobalSetting")
+		return apperror.Wrap(apperror.Internal, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperror.New(apperror.NotFound)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+)
+
+func (r *RepoPG) GetListAuditLog(ctx context.Context, req model.ListAuditLogReq) (res model.ListAuditLogRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.AuditLog{}).Where("company_id = ?", req.CompanyId)
+	if req.EntityType != nil {
+		tx = tx.Where("entity_type = ?", req.EntityType)
+	}
+	if req.EntityId != nil {
+		tx = tx.Where("entity_id = ?", req.EntityId)
+	}
+	if req.ActorId != nil {
+		tx = tx.Where("actor_id = ?", req.ActorId)
+	}
+	if req.Action != nil {
+		tx = tx.Where("action = ?", req.Action)
+	}
+	if req.From != nil {
+		tx = tx.Where("created_at >= ?", req.From)
+	}
+	if req.To != nil {
+		tx = tx.Where("created_at < ?", req.To)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListAuditLog")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// CreateAuditLog records an action that is not a change of rows, such as an impersonation.
+func (r *RepoPG) CreateAuditLog(ctx context.Context, req *model.AuditLog) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to CreateAuditLog")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
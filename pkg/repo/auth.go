@@ -2,9 +2,8 @@ package repo
 
 import (
 	"context"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 )
 
@@ -15,7 +14,7 @@ func (r *RepoPG) CreateRefreshToken(ctx context.Context, refreshToken *model.Ref
 		defer cancel()
 	}
 	if err := tx.Create(&refreshToken).Error; err != nil {
-		return ginext.NewError(http.StatusInternalServerError, "Error when create refresh token:"+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
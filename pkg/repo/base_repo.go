@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"math"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 	"runtime/debug"
@@ -64,12 +64,15 @@ type PGInterface interface {
 
 	//ticket
 	CreateTicket(ctx context.Context, req *model.Ticket, tx *gorm.DB) error
-	GetAllTicket(ctx context.Context, req model.GetListTicketParam, tx *gorm.DB) ([]model.Ticket, error)
+	GetAllTicket(ctx context.Context, req model.GetListTicketParam, tx *gorm.DB) (model.ListTicketRes, error)
 	GetOneTicket(ctx context.Context, id string, tx *gorm.DB) (model.Ticket, error)
 	GetOneTicketWithExtend(ctx context.Context, id string, tx *gorm.DB) (model.Ticket, error)
 	GetListExtendTicketByOrigin(ctx context.Context, idParent string, tx *gorm.DB) ([]model.Ticket, error)
+	GetOriginTicketByExtend(ctx context.Context, idExtend string, tx *gorm.DB) (model.Ticket, error)
 	UpdateTicket(ctx context.Context, ticket *model.Ticket, tx *gorm.DB) error
-	GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (res []model.GetListTicketRes, err error)
+	GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (model.ListTicketCompanyRes, error)
+	GetUnfinishedTicket(ctx context.Context, scope model.TicketScope) ([]model.Ticket, error)
+	MarkNoShowTicket(ctx context.Context, startedBefore time.Time) ([]model.Ticket, error)
 
 	//ticket extend
 	CreateTicketExtend(ctx context.Context, req *model.TicketExtend, tx *gorm.DB) error
@@ -86,6 +89,10 @@ type PGInterface interface {
 	GetListParkingLotCompany(ctx context.Context, req model.GetListParkingLotReq) (model.ListParkingLotRes, error)
 	UpdateParkingLot(ctx context.Context, req *model.ParkingLot) error
 	DeleteParkingLot(ctx context.Context, id uuid.UUID) error
+	GetOneParkingLotSearchItem(ctx context.Context, id uuid.UUID) (model.ParkingLotSearchItem, error)
+	GetListParkingLotSearchItem(ctx context.Context, page int, pageSize int) ([]model.ParkingLotSearchItem, error)
+	GetParkingLotIDsByCompany(ctx context.Context, companyID uuid.UUID) ([]uuid.UUID, error)
+	SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (model.SearchParkingLotRes, error)
 
 	// Block
 	CreateBlock(ctx context.Context, req *model.Block) error
@@ -93,6 +100,9 @@ type PGInterface interface {
 	GetListBlock(ctx context.Context, req model.ListBlockReq) (model.ListBlockRes, error)
 	UpdateBlock(ctx context.Context, req *model.Block) error
 	DeleteBlock(ctx context.Context, id uuid.UUID) error
+	RefreshBlockSlotCount(ctx context.Context, id uuid.UUID) error
+	GetBlockSlotDrift(ctx context.Context, req model.BlockSlotDriftReq) ([]model.BlockSlotDrift, error)
+	DeleteBlockByParkingLot(ctx context.Context, parkingLotID uuid.UUID) error
 
 	// ParkingSlot
 	CreateParkingSlot(ctx context.Context, req *model.ParkingSlot) error
@@ -101,6 +111,82 @@ type PGInterface interface {
 	GetAvailableParkingSlot(ctx context.Context, req model.AvailableParkingSlotReq) (model.ListParkingSlotRes, error)
 	UpdateParkingSlot(ctx context.Context, req *model.ParkingSlot) error
 	DeleteParkingSlot(ctx context.Context, id uuid.UUID) error
+	CreateParkingSlots(ctx context.Context, slots []model.ParkingSlot) error
+	GetSlotIDsWithUpcomingTicket(ctx context.Context, slotIDs []uuid.UUID) ([]uuid.UUID, error)
+	GetFreeParkingSlot(ctx context.Context, req model.FreeSlotReq) (*model.ParkingSlot, error)
+	GetSlotCandidates(ctx context.Context, req model.FreeSlotReq) ([]model.SlotCandidate, error)
+	LockParkingLot(ctx context.Context, id uuid.UUID) error
+	DeleteParkingSlotByBlock(ctx context.Context, blockID uuid.UUID) error
+	DeleteParkingSlotByParkingLot(ctx context.Context, parkingLotID uuid.UUID) error
+
+	// setting
+	GetSetting(ctx context.Context, key string, companyID, parkingLotID *uuid.UUID) (*model.Setting, error)
+	UpsertSetting(ctx context.Context, req *model.Setting) error
+
+	// notification
+	GetNotificationPreference(ctx context.Context, userID uuid.UUID) (*model.NotificationPreference, error)
+	SaveNotificationPreference(ctx context.Context, req *model.NotificationPreference) error
+	SaveDeviceToken(ctx context.Context, req *model.DeviceToken) error
+	DeleteDeviceToken(ctx context.Context, userID uuid.UUID, token string) error
+	GetDeviceTokens(ctx context.Context, userID uuid.UUID) ([]model.DeviceToken, error)
+	CreateNotification(ctx context.Context, req *model.Notification) error
+	GetListNotification(ctx context.Context, req model.ListNotificationReq) (model.ListNotificationRes, error)
+	GetTicketToNotify(ctx context.Context, event string, from, to time.Time) ([]model.TicketToNotify, error)
+
+	// slot hold
+	CreateSlotHold(ctx context.Context, req *model.SlotHold) error
+	GetOneSlotHold(ctx context.Context, id uuid.UUID) (model.SlotHold, error)
+	BookSlotHold(ctx context.Context, id, ticketID uuid.UUID) error
+	DeleteSlotHold(ctx context.Context, id uuid.UUID) error
+	ReleaseUserSlotHold(ctx context.Context, userID, parkingLotID uuid.UUID) error
+	GetSlotHoldConflict(ctx context.Context, slotID uuid.UUID, start, end time.Time, holderID *uuid.UUID) (*model.SlotHold, error)
+	DeleteExpiredSlotHold(ctx context.Context) (int64, error)
+
+	// waitlist
+	CreateWaitlist(ctx context.Context, req *model.Waitlist) error
+	GetOneWaitlist(ctx context.Context, id uuid.UUID) (model.Waitlist, error)
+	GetListWaitlist(ctx context.Context, req model.ListWaitlistReq) (model.ListWaitlistRes, error)
+	UpdateWaitlist(ctx context.Context, req *model.Waitlist) error
+	BookWaitlistOffer(ctx context.Context, id, ticketID uuid.UUID) error
+	GetWaitingWaitlist(ctx context.Context, parkingLotID uuid.UUID) ([]model.Waitlist, error)
+	GetWaitlistParkingLotIDs(ctx context.Context) ([]uuid.UUID, error)
+	ExpireWaitlist(ctx context.Context) ([]model.Waitlist, error)
+
+	// outbox
+	CreateOutboxEvent(ctx context.Context, req *model.OutboxEvent) error
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id uuid.UUID) error
+	MarkOutboxEventFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error
+	MarkEventProcessed(ctx context.Context, consumer string, eventID uuid.UUID) (bool, error)
+
+	// idempotency
+	ClaimIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) (*model.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+	DeleteExpiredIdempotencyKey(ctx context.Context) (int64, error)
+
+	// webhook
+	CreateWebhookSubscription(ctx context.Context, req *model.WebhookSubscription) error
+	GetOneWebhookSubscription(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error)
+	GetListWebhookSubscription(ctx context.Context, companyID uuid.UUID) ([]model.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, req *model.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error
+	GetWebhookSubscriptionsForEvent(ctx context.Context, companyID uuid.UUID, eventType string) ([]model.WebhookSubscription, error)
+	CreateWebhookDeliveries(ctx context.Context, req []model.WebhookDelivery) error
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	GetOneWebhookDelivery(ctx context.Context, id uuid.UUID) (model.WebhookDelivery, error)
+	GetListWebhookDelivery(ctx context.Context, req model.ListWebhookDeliveryReq) (model.ListWebhookDeliveryRes, error)
+	UpdateWebhookDelivery(ctx context.Context, req *model.WebhookDelivery) error
+
+	// slot downtime
+	CreateSlotDowntime(ctx context.Context, req *model.SlotDowntime) error
+	GetOneSlotDowntime(ctx context.Context, id uuid.UUID) (model.SlotDowntime, error)
+	GetListSlotDowntime(ctx context.Context, req model.ListSlotDowntimeReq) (model.ListSlotDowntimeRes, error)
+	UpdateSlotDowntime(ctx context.Context, req *model.SlotDowntime) error
+	DeleteSlotDowntime(ctx context.Context, id uuid.UUID) error
+
+	// layout
+	GetParkingLotLayout(ctx context.Context, parkingLotID uuid.UUID) ([]model.Block, error)
 
 	// Vehicle
 	CreateVehicle(ctx context.Context, req *model.Vehicle) error
@@ -114,6 +200,35 @@ type PGInterface interface {
 	GetCompanyByEmail(ctx context.Context, email string) (model.Company, error)
 	GetOneCompany(ctx context.Context, id uuid.UUID) (model.Company, error)
 	UpdateCompany(ctx context.Context, req *model.Company) error
+	UpdateCompanyStatus(ctx context.Context, id uuid.UUID, status, reason string) error
+	VerifyCompanyEmail(ctx context.Context, id uuid.UUID, at time.Time) error
+	CreateCompanyVerificationToken(ctx context.Context, req *model.CompanyVerificationToken) error
+	UseCompanyVerificationToken(ctx context.Context, hash string, at time.Time) (*model.CompanyVerificationToken, error)
+	CreateCompanyDocument(ctx context.Context, req *model.CompanyDocument) error
+	GetListCompanyDocument(ctx context.Context, companyID uuid.UUID) ([]model.CompanyDocument, error)
+	DeleteCompanyDocument(ctx context.Context, id uuid.UUID) error
+	CountCompanyDocument(ctx context.Context, companyID uuid.UUID, docType string) (int64, error)
+
+	// admin
+	GetListCompanyAdmin(ctx context.Context, req model.AdminListCompanyReq) (model.AdminListCompanyRes, error)
+	GetListUserAdmin(ctx context.Context, req model.AdminListUserReq) (model.AdminListUserRes, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role int) error
+	GetPlatformStats(ctx context.Context, from, to time.Time) (model.PlatformStats, error)
+	GetListGlobalSetting(ctx context.Context) ([]model.Setting, error)
+	DeleteGlobalSetting(ctx context.Context, key string) error
+
+	// report
+	GetRevenueReport(ctx context.Context, req model.ReportReq) ([]model.RevenueReportItem, error)
+	GetOccupancyReport(ctx context.Context, req model.ReportReq) ([]model.OccupancyReportItem, error)
+	GetTicketStatsReport(ctx context.Context, req model.ReportReq) ([]model.TicketStatsReportItem, error)
+	GetTopTimeFrameReport(ctx context.Context, req model.ReportReq) ([]model.TimeFrameReportItem, error)
+
+	// audit
+	GetListAuditLog(ctx context.Context, req model.ListAuditLogReq) (model.ListAuditLogRes, error)
+	CreateAuditLog(ctx context.Context, req *model.AuditLog) error
+
+	// export
+	ExportTicketCompany(ctx context.Context, req model.ExportTicketReq, fn func(row model.ExportTicketRow) error) error
 }
 
 type RepoPG struct {
@@ -228,8 +343,8 @@ func (r *RepoPG) ReturnErrorInGetFuncV2(ctx context.Context, logStr string, err
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		log.WithError(err).Error(fmt.Sprintf("error_404: %s - RepoPG", logStr))
-		return ginext.NewError(http.StatusNotFound, utils.MessageError()[http.StatusNotFound])
+		return apperror.Wrap(apperror.NotFound, err)
 	}
 	log.WithError(err).Error(fmt.Sprintf("error_500: %s - RepoPG", logStr))
-	return ginext.NewError(http.StatusInternalServerError, err.Error())
+	return apperror.Wrap(apperror.Internal, err)
 }
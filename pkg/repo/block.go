@@ -4,10 +4,9 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
@@ -21,7 +20,7 @@ func (r *RepoPG) CreateBlock(ctx context.Context, req *model.Block) error {
 
 	if err := tx.Model(&model.Block{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateBlock")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -35,10 +34,10 @@ func (r *RepoPG) GetOneBlock(ctx context.Context, id uuid.UUID) (res model.Block
 	if err = tx.Model(&model.Block{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneBlock")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -52,28 +51,46 @@ func (r *RepoPG) GetListBlock(ctx context.Context, req model.ListBlockReq) (res
 	tx = tx.Model(&model.Block{}).Where("parking_lot_id = ?", valid.String(req.ParkingLotID))
 
 	if req.Code != nil {
-		name := utils.TransformString(valid.String(req.Code), false)
-		tx = tx.Where("unaccent(name) ilike ?", name+"%")
+		code := utils.TransformString(valid.String(req.Code), false)
+		tx = tx.Where("unaccent(code) ilike ?", code+"%")
 	}
 
-	if req.Sort != "" {
-		tx = tx.Order(req.Sort)
-	} else {
-		tx = tx.Order("created_at desc")
+	if tx, err = blockQuerySpec.ApplyFilter(tx, req.Filter); err != nil {
+		log.WithError(err).Error("error_400: invalid filter")
+		return res, err
 	}
 
 	var total int64 = 0
 	page := r.GetPage(req.Page)
 	pageSize := r.GetPageSize(req.PageSize)
 
+	// keyset pagination skips the COUNT but only supports the default order
+	if req.Cursor != nil {
+		if req.Sort != "" {
+			return res, apperror.New(apperror.CursorSortUnsupported)
+		}
+		if res.Data, res.Meta, err = FindCursorPage(r, tx, "", valid.String(req.Cursor), pageSize, func(b model.Block) model.BaseModel {
+			return b.BaseModel
+		}); err != nil {
+			log.WithError(err).Error("error_500: failed to get cursor page")
+			return res, err
+		}
+		return res, nil
+	}
+
+	if tx, err = blockQuerySpec.ApplySort(tx, req.Sort); err != nil {
+		log.WithError(err).Error("error_400: invalid sort")
+		return res, err
+	}
+
 	if err := tx.Count(&total).Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetListBlock")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
 		log.WithError(err).Error("error_500: failed to get pagination")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	return res, nil
@@ -87,7 +104,7 @@ func (r *RepoPG) UpdateBlock(ctx context.Context, req *model.Block) error {
 
 	if err := tx.Model(&model.Block{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when UpdateBlock")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -100,7 +117,59 @@ func (r *RepoPG) DeleteBlock(ctx context.Context, id uuid.UUID) error {
 
 	if err := tx.Where("id = ?", id).Delete(&model.Block{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when DeleteBlock")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// RefreshBlockSlotCount sets Block.Slot to the number of slots of the block.
+func (r *RepoPG) RefreshBlockSlotCount(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.Block{}).Where("id = ?", id).
+		Update("slot", tx.Model(&model.ParkingSlot{}).Select("count(*)").Where("block_id = ?", id)).Error; err != nil {
+		log.WithError(err).Error("error_500: error when RefreshBlockSlotCount")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// GetBlockSlotDrift returns the blocks of a parking lot whose Slot is not the number of their slots.
+func (r *RepoPG) GetBlockSlotDrift(ctx context.Context, req model.BlockSlotDriftReq) (res []model.BlockSlotDrift, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := `select
+					b.id as block_id, b.code, b.parking_lot_id, b.slot, count(sl.id) as actual_slot
+				from block b
+				left join parking_slot sl on sl.block_id = b.id and sl.deleted_at is null
+				where b.deleted_at is null
+					and b.parking_lot_id = ?
+				group by b.id, b.code, b.parking_lot_id, b.slot
+				having b.slot <> count(sl.id)
+				order by b.code`
+
+	if err := tx.Raw(utils.RemoveSpace(query), valid.String(req.ParkingLotID)).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetBlockSlotDrift")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) DeleteBlockByParkingLot(ctx context.Context, parkingLotID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("parking_lot_id = ?", parkingLotID).Delete(&model.Block{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteBlockByParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
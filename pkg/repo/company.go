@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
+	"time"
 )
 
 func (r *RepoPG) CreateCompany(ctx context.Context, req *model.Company) error {
@@ -20,7 +20,7 @@ func (r *RepoPG) CreateCompany(ctx context.Context, req *model.Company) error {
 
 	if err := tx.Model(&model.Company{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateCompany")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -33,7 +33,7 @@ func (r *RepoPG) GetCompanyByEmail(ctx context.Context, email string) (res model
 
 	if err := tx.Model(&model.Company{}).Where("email = ?", email).Take(&res).Error; err != nil {
 		log.WithError(err).Error("error_500: error when GetCompanyByEmail")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -47,10 +47,10 @@ func (r *RepoPG) GetOneCompany(ctx context.Context, id uuid.UUID) (res model.Com
 	if err = tx.Model(&model.Company{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneCompany")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -60,7 +60,144 @@ func (r *RepoPG) UpdateCompany(ctx context.Context, req *model.Company) error {
 	defer cancel()
 
 	if err := tx.Model(&model.Company{}).Where("id = ?", req.ID).Updates(&req).Error; err != nil {
-		return ginext.NewError(http.StatusInternalServerError, "Error when UpdateCompany: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
+
+// UpdateCompanyStatus sets the status of the company and the reason of it, cleared when reason is empty.
+func (r *RepoPG) UpdateCompanyStatus(ctx context.Context, id uuid.UUID, status, reason string) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	err := tx.Model(&model.Company{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "status_reason": reason, "updated_at": time.Now()}).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to UpdateCompanyStatus")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// VerifyCompanyEmail marks the email of the company verified at, a company pending its email verification
+// is then pending the review of an admin.
+func (r *RepoPG) VerifyCompanyEmail(ctx context.Context, id uuid.UUID, at time.Time) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	err := tx.Model(&model.Company{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email_verified_at": at,
+		"status":            gorm.Expr("case when status = ? then ? else status end", model.CompanyStatusPendingEmail, model.CompanyStatusPendingReview),
+		"updated_at":        time.Now(),
+	}).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to VerifyCompanyEmail")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) CreateCompanyVerificationToken(ctx context.Context, req *model.CompanyVerificationToken) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to CreateCompanyVerificationToken")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// UseCompanyVerificationToken marks the token of hash used and returns it, nil when it does not exist, is
+// expired or was already used.
+func (r *RepoPG) UseCompanyVerificationToken(ctx context.Context, hash string, at time.Time) (*model.CompanyVerificationToken, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	var res []model.CompanyVerificationToken
+	err := tx.Where("token_hash = ? and used_at is null and expires_at > ?", hash, at).Limit(1).Find(&res).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to UseCompanyVerificationToken")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	// the used_at condition keeps a token racing with itself from being used twice
+	update := tx.Model(&model.CompanyVerificationToken{}).Where("id = ? and used_at is null", res[0].ID).Update("used_at", at)
+	if update.Error != nil {
+		log.WithError(update.Error).Error("error_500: failed to UseCompanyVerificationToken")
+		return nil, apperror.Wrap(apperror.Internal, update.Error)
+	}
+	if update.RowsAffected == 0 {
+		return nil, nil
+	}
+	res[0].UsedAt = &at
+	return &res[0], nil
+}
+
+func (r *RepoPG) CreateCompanyDocument(ctx context.Context, req *model.CompanyDocument) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to CreateCompanyDocument")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetListCompanyDocument(ctx context.Context, companyID uuid.UUID) (res []model.CompanyDocument, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("company_id = ?", companyID).Order("created_at desc").Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListCompanyDocument")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) DeleteCompanyDocument(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Where("id = ?", id).Delete(&model.CompanyDocument{})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to DeleteCompanyDocument")
+		return apperror.Wrap(apperror.Internal, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperror.New(apperror.NotFound)
+	}
+	return nil
+}
+
+// CountCompanyDocument counts the documents of the company of type docType.
+func (r *RepoPG) CountCompanyDocument(ctx context.Context, companyID uuid.UUID, docType string) (total int64, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.CompanyDocument{}).Where("company_id = ? and type = ?", companyID, docType).Count(&total).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to CountCompanyDocument")
+		return 0, apperror.Wrap(apperror.Internal, err)
+	}
+	return total, nil
+}
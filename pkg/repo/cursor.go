@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"time"
+)
+
+// Cursor is the position of a row in created_at desc, id desc order. It is handed to clients
+// as an opaque base64 string, Prev marks a cursor that pages backwards.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+	Prev      bool      `json:"p,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (c Cursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, apperror.New(apperror.InvalidCursor)
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil {
+		return c, apperror.New(apperror.InvalidCursor)
+	}
+	return c, nil
+}
+
+// ApplyCursor scopes tx to the rows after cursor (or before it for a Prev cursor), an empty cursor
+// is the first page. It fetches one extra row so GetCursorInfo can tell whether there is another page.
+// column prefixes created_at and id when the query joins other tables.
+func (r *RepoPG) ApplyCursor(tx *gorm.DB, column string, cursor string, pageSize int) (*gorm.DB, Cursor, error) {
+	createdAt, id := column+"created_at", column+"id"
+	c := Cursor{}
+	if cursor != "" {
+		var err error
+		if c, err = DecodeCursor(cursor); err != nil {
+			return tx, c, err
+		}
+		if c.Prev {
+			tx = tx.Where("("+createdAt+", "+id+") > (?, ?)", c.CreatedAt, c.ID)
+		} else {
+			tx = tx.Where("("+createdAt+", "+id+") < (?, ?)", c.CreatedAt, c.ID)
+		}
+	}
+	if c.Prev {
+		tx = tx.Order(createdAt + " asc").Order(id + " asc")
+	} else {
+		tx = tx.Order(createdAt + " desc").Order(id + " desc")
+	}
+	return tx.Limit(pageSize + 1), c, nil
+}
+
+// CursorPage trims the extra row fetched by ApplyCursor, restores the created_at desc order of
+// a backwards page and builds the meta with the next and prev cursors.
+func CursorPage[T any](rows []T, cursor Cursor, pageSize int, key func(T) model.BaseModel) ([]T, ginext.BodyMeta) {
+	hasMore := len(rows) > pageSize
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+	if cursor.Prev {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	meta := ginext.BodyMeta{
+		"page_size":   pageSize,
+		"next_cursor": nil,
+		"prev_cursor": nil,
+	}
+	if len(rows) == 0 {
+		return rows, meta
+	}
+	first, last := key(rows[0]), key(rows[len(rows)-1])
+	isFirstPage := cursor.ID == uuid.Nil
+	if (!cursor.Prev && hasMore) || cursor.Prev {
+		meta["next_cursor"] = Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if (!cursor.Prev && !isFirstPage) || (cursor.Prev && hasMore) {
+		meta["prev_cursor"] = Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Prev: true}.Encode()
+	}
+	return rows, meta
+}
+
+// FindCursorPage runs tx as a keyset-paginated query, see ApplyCursor and CursorPage.
+func FindCursorPage[T any](r *RepoPG, tx *gorm.DB, column string, cursor string, pageSize int, key func(T) model.BaseModel) ([]T, ginext.BodyMeta, error) {
+	tx, c, err := r.ApplyCursor(tx, column, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows []T
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, nil, apperror.Wrap(apperror.Internal, err)
+	}
+	rows, meta := CursorPage(rows, c, pageSize, key)
+	return rows, meta, nil
+}
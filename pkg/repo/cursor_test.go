@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	for _, c := range []Cursor{
+		{CreatedAt: time.Date(2023, 5, 1, 8, 30, 0, 123456000, time.UTC), ID: uuid.New()},
+		{CreatedAt: time.Date(2023, 5, 1, 8, 30, 0, 0, time.UTC), ID: uuid.New(), Prev: true},
+	} {
+		got, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor(%+v) error = %v", c, err)
+		}
+		if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID || got.Prev != c.Prev {
+			t.Errorf("DecodeCursor(Encode(%+v)) = %+v", c, got)
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	for _, s := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2023-05-01T00:00:00Z"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2023-05-01T00:00:00Z","i":"x"}`)),
+	} {
+		if _, err := DecodeCursor(s); apperror.From(err).ErrorCode() != apperror.InvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want %s", s, err, apperror.InvalidCursor)
+		}
+	}
+}
+
+func TestApplyCursor(t *testing.T) {
+	r := &RepoPG{}
+	at := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	tests := []struct {
+		cursor string
+		sql    string
+		vars   []interface{}
+	}{
+		{"", `SELECT * FROM "ticket" WHERE "ticket"."deleted_at" IS NULL ORDER BY t.created_at desc,t.id desc LIMIT 11`, nil},
+		{Cursor{CreatedAt: at, ID: id}.Encode(),
+			`SELECT * FROM "ticket" WHERE (t.created_at, t.id) < ($1, $2) AND "ticket"."deleted_at" IS NULL ORDER BY t.created_at desc,t.id desc LIMIT 11`,
+			[]interface{}{at, id}},
+		{Cursor{CreatedAt: at, ID: id, Prev: true}.Encode(),
+			`SELECT * FROM "ticket" WHERE (t.created_at, t.id) > ($1, $2) AND "ticket"."deleted_at" IS NULL ORDER BY t.created_at asc,t.id asc LIMIT 11`,
+			[]interface{}{at, id}},
+	}
+	for _, tt := range tests {
+		tx, _, err := r.ApplyCursor(dryRunDB(t).Model(&model.Ticket{}), "t.", tt.cursor, 10)
+		if err != nil {
+			t.Fatalf("ApplyCursor(%q) error = %v", tt.cursor, err)
+		}
+		stmt := tx.Find(&[]model.Ticket{}).Statement
+		if got := stmt.SQL.String(); got != tt.sql {
+			t.Errorf("ApplyCursor(%q) sql = %s, want %s", tt.cursor, got, tt.sql)
+		}
+		if len(stmt.Vars) != len(tt.vars) || (len(tt.vars) > 0 && !reflect.DeepEqual(stmt.Vars, tt.vars)) {
+			t.Errorf("ApplyCursor(%q) vars = %v, want %v", tt.cursor, stmt.Vars, tt.vars)
+		}
+	}
+
+	if _, _, err := r.ApplyCursor(dryRunDB(t), "", "garbage", 10); apperror.From(err).ErrorCode() != apperror.InvalidCursor {
+		t.Errorf("ApplyCursor(garbage) error = %v, want %s", err, apperror.InvalidCursor)
+	}
+}
+
+// fetchPage runs the query ApplyCursor builds over rows, then CursorPage, like FindCursorPage on a database.
+func fetchPage(t *testing.T, rows []model.BaseModel, cursor string, pageSize int) ([]model.BaseModel, ginext.BodyMeta) {
+	t.Helper()
+	c := Cursor{}
+	if cursor != "" {
+		var err error
+		if c, err = DecodeCursor(cursor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// (created_at, id) compared as postgres does, uuids byte by byte
+	less := func(a, b model.BaseModel) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) < 0
+	}
+	at := model.BaseModel{ID: c.ID, CreatedAt: c.CreatedAt}
+	var res []model.BaseModel
+	for _, row := range rows {
+		if cursor == "" || (c.Prev && less(at, row)) || (!c.Prev && less(row, at)) {
+			res = append(res, row)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if c.Prev {
+			return less(res[i], res[j])
+		}
+		return less(res[j], res[i])
+	})
+	if len(res) > pageSize+1 {
+		res = res[:pageSize+1]
+	}
+	return CursorPage(res, c, pageSize, func(row model.BaseModel) model.BaseModel { return row })
+}
+
+func TestCursorPaging(t *testing.T) {
+	// 7 rows, some created at the same time so the id breaks the ties, newest first
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	var rows []model.BaseModel
+	for i := 0; i < 7; i++ {
+		rows = append(rows, model.BaseModel{ID: uuid.New(), CreatedAt: start.Add(time.Duration(i/2) * time.Hour)})
+	}
+	want := append([]model.BaseModel(nil), rows...)
+	sort.Slice(want, func(i, j int) bool {
+		if !want[i].CreatedAt.Equal(want[j].CreatedAt) {
+			return want[i].CreatedAt.After(want[j].CreatedAt)
+		}
+		return bytes.Compare(want[i].ID[:], want[j].ID[:]) > 0
+	})
+	wantPages := [][]model.BaseModel{want[0:3], want[3:6], want[6:7]}
+
+	// forwards with next_cursor
+	var cursors []string
+	cursor := ""
+	for i, wantPage := range wantPages {
+		page, meta := fetchPage(t, rows, cursor, 3)
+		if !reflect.DeepEqual(page, wantPage) {
+			t.Fatalf("page %d = %v, want %v", i, page, wantPage)
+		}
+		if (meta["prev_cursor"] == nil) != (i == 0) {
+			t.Errorf("page %d prev_cursor = %v", i, meta["prev_cursor"])
+		}
+		if (meta["next_cursor"] == nil) != (i == len(wantPages)-1) {
+			t.Errorf("page %d next_cursor = %v", i, meta["next_cursor"])
+		}
+		cursors = append(cursors, cursor)
+		if next, ok := meta["next_cursor"].(string); ok {
+			cursor = next
+		}
+	}
+
+	// backwards with prev_cursor from the last page
+	_, meta := fetchPage(t, rows, cursors[len(cursors)-1], 3)
+	for i := len(wantPages) - 2; i >= 0; i-- {
+		prev, ok := meta["prev_cursor"].(string)
+		if !ok {
+			t.Fatalf("no prev_cursor before page %d", i)
+		}
+		var page []model.BaseModel
+		page, meta = fetchPage(t, rows, prev, 3)
+		if !reflect.DeepEqual(page, wantPages[i]) {
+			t.Fatalf("prev page %d = %v, want %v", i, page, wantPages[i])
+		}
+		if meta["next_cursor"] == nil {
+			t.Errorf("prev page %d has no next_cursor", i)
+		}
+	}
+	if meta["prev_cursor"] != nil {
+		t.Errorf("first page reached backwards has prev_cursor %v", meta["prev_cursor"])
+	}
+}
+
+func TestCursorPageEmpty(t *testing.T) {
+	rows, meta := CursorPage([]model.BaseModel{}, Cursor{}, 10, func(row model.BaseModel) model.BaseModel { return row })
+	if len(rows) != 0 || meta["next_cursor"] != nil || meta["prev_cursor"] != nil || meta["page_size"] != 10 {
+		t.Errorf("CursorPage(empty) = %v, %v", rows, meta)
+	}
+}
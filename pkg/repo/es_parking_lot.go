@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
+	"math"
+	"net/http"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+func (r *RepoES) IndexParkingLot(ctx context.Context, item model.ParkingLotSearchItem) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	body, err := json.Marshal(newParkingLotDocument(item))
+	if err != nil {
+		return err
+	}
+	if err := r.do(ctx, esapi.IndexRequest{
+		Index:      r.index,
+		DocumentID: item.ID.String(),
+		Body:       bytes.NewReader(body),
+	}, nil); err != nil {
+		log.WithError(err).Error("error_500: error when IndexParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoES) BulkIndexParkingLot(ctx context.Context, items []model.ParkingLotSearchItem) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	if len(items) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		meta := map[string]interface{}{"index": map[string]string{"_index": r.index, "_id": item.ID.String()}}
+		if err := enc.Encode(meta); err != nil {
+			return err
+		}
+		if err := enc.Encode(newParkingLotDocument(item)); err != nil {
+			return err
+		}
+	}
+
+	res := struct {
+		Errors bool `json:"errors"`
+	}{}
+	if err := r.do(ctx, esapi.BulkRequest{Body: &buf}, &res); err != nil {
+		log.WithError(err).Error("error_500: error when BulkIndexParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	if res.Errors {
+		log.Error("error_500: some documents failed in BulkIndexParkingLot")
+		return apperror.Wrap(apperror.Internal, errors.New("some parking lots could not be indexed"))
+	}
+	return nil
+}
+
+func (r *RepoES) DeleteParkingLot(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	res, err := esapi.DeleteRequest{Index: r.index, DocumentID: id.String()}.Do(ctx, r.client)
+	if err != nil {
+		log.WithError(err).Error("error_500: error when DeleteParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		log.Error("error_500: error when DeleteParkingLot: " + res.Status())
+		return apperror.Wrap(apperror.Internal, errors.New(res.Status()))
+	}
+	return nil
+}
+
+func (r *RepoES) SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (res model.SearchParkingLotRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	hasLocation := req.Lat != nil && req.Long != nil
+	location := map[string]float64{"lat": valid.Float64(req.Lat), "lon": valid.Float64(req.Long)}
+
+	must := []interface{}{map[string]interface{}{"match_all": map[string]interface{}{}}}
+	if q := valid.String(req.Query); q != "" {
+		must = []interface{}{map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":     q,
+				"fields":    []string{"name^3", "address^2", "companyName^2", "description", "amenities"},
+				"fuzziness": "AUTO",
+				"operator":  "and",
+			},
+		}}
+	}
+	filter := []interface{}{}
+	if req.CompanyID != nil {
+		filter = append(filter, map[string]interface{}{"term": map[string]string{"companyID": valid.String(req.CompanyID)}})
+	}
+	if hasLocation && req.Distance != nil {
+		filter = append(filter, map[string]interface{}{
+			"geo_distance": map[string]interface{}{
+				"distance": fmt.Sprintf("%fkm", valid.Float64(req.Distance)),
+				"location": location,
+			},
+		})
+	}
+	sort := []interface{}{"_score", map[string]string{"created_at": "desc"}}
+	if hasLocation {
+		sort = []interface{}{map[string]interface{}{
+			"_geo_distance": map[string]interface{}{"location": location, "order": "asc", "unit": "km"},
+		}, "_score"}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"from":             (page - 1) * pageSize,
+		"size":             pageSize,
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"bool": map[string]interface{}{"must": must, "filter": filter}},
+		"sort":             sort,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	out := struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source parkingLotDocument `json:"_source"`
+				Sort   []interface{}      `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}{}
+	if err := r.do(ctx, esapi.SearchRequest{Index: []string{r.index}, Body: bytes.NewReader(body)}, &out); err != nil {
+		log.WithError(err).Error("error_500: failed to SearchParkingLot")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	res.Data = make([]model.ParkingLotSearchItem, 0, len(out.Hits.Hits))
+	for _, hit := range out.Hits.Hits {
+		item := hit.Source.ParkingLotSearchItem
+		if hasLocation && len(hit.Sort) > 0 {
+			if distance, ok := hit.Sort[0].(float64); ok {
+				item.Distance = &distance
+			}
+		}
+		res.Data = append(res.Data, item)
+	}
+	res.Meta = ginext.BodyMeta{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(out.Hits.Total.Value) / float64(pageSize))),
+		"total_rows":  out.Hits.Total.Value,
+	}
+	return res, nil
+}
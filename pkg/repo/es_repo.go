@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/google/uuid"
+	"io"
+	"parkar-server/pkg/model"
+)
+
+func NewESRepo(client *elasticsearch.Client, index string) ESInterface {
+	return &RepoES{client: client, index: index}
+}
+
+type ESInterface interface {
+	// Index
+	EnsureParkingLotIndex(ctx context.Context) error
+	RecreateParkingLotIndex(ctx context.Context) error
+
+	// Parking lot
+	IndexParkingLot(ctx context.Context, item model.ParkingLotSearchItem) error
+	BulkIndexParkingLot(ctx context.Context, items []model.ParkingLotSearchItem) error
+	DeleteParkingLot(ctx context.Context, id uuid.UUID) error
+	SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (model.SearchParkingLotRes, error)
+}
+
+type RepoES struct {
+	client *elasticsearch.Client
+	index  string
+}
+
+// parkingLotIndexBody folds Vietnamese diacritics (including đ) at index and query time,
+// so "Bai xe Quan 1" matches "Bãi xe Quận 1".
+const parkingLotIndexBody = `{
+	"settings": {
+		"analysis": {
+			"analyzer": {
+				"vi_folding": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "asciifolding"]
+				}
+			}
+		}
+	},
+	"mappings": {
+		"properties": {
+			"id":          {"type": "keyword"},
+			"name":        {"type": "text", "analyzer": "vi_folding", "fields": {"raw": {"type": "keyword"}}},
+			"address":     {"type": "text", "analyzer": "vi_folding"},
+			"description": {"type": "text", "analyzer": "vi_folding"},
+			"companyID":   {"type": "keyword"},
+			"companyName": {"type": "text", "analyzer": "vi_folding"},
+			"amenities":   {"type": "text", "analyzer": "vi_folding", "fields": {"raw": {"type": "keyword"}}},
+			"location":    {"type": "geo_point"},
+			"created_at":  {"type": "date"}
+		}
+	}
+}`
+
+type esGeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type parkingLotDocument struct {
+	model.ParkingLotSearchItem
+	Location esGeoPoint `json:"location"`
+}
+
+func newParkingLotDocument(item model.ParkingLotSearchItem) parkingLotDocument {
+	item.Distance = nil
+	return parkingLotDocument{
+		ParkingLotSearchItem: item,
+		Location:             esGeoPoint{Lat: item.Lat, Lon: item.Long},
+	}
+}
+
+func (r *RepoES) do(ctx context.Context, req esapi.Request, out interface{}) error {
+	res, err := req.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("elasticsearch: %s: %s", res.Status(), string(body))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (r *RepoES) EnsureParkingLotIndex(ctx context.Context) error {
+	res, err := esapi.IndicesExistsRequest{Index: []string{r.index}}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+	return r.do(ctx, esapi.IndicesCreateRequest{
+		Index: r.index,
+		Body:  bytes.NewBufferString(parkingLotIndexBody),
+	}, nil)
+}
+
+func (r *RepoES) RecreateParkingLotIndex(ctx context.Context) error {
+	res, err := esapi.IndicesDeleteRequest{Index: []string{r.index}}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return r.EnsureParkingLotIndex(ctx)
+}
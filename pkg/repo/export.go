@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+// ExportTicketCompany streams the tickets of a parking lot to fn one row at a time, newest first.
+// Extension tickets are folded into their original ticket unless they are asked for by state.
+func (r *RepoPG) ExportTicketCompany(ctx context.Context, req model.ExportTicketReq, fn func(row model.ExportTicketRow) error) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	query := `select
+					t.id, t.created_at, t.state, t.start_time, t.end_time, t.entry_time, t.exit_time, t.total,
+					coalesce(v.name, '') as vehicle_name,
+					coalesce(v.number, '') as vehicle_number,
+					coalesce(v.type, '') as vehicle_type,
+					coalesce(b.code, '') as block_code,
+					coalesce(sl.name, '') as slot_name,
+					ext.extend_count, ext.extend_total, ext.extended_until
+				from ticket t
+				left join vehicle v on v.id = t.vehicle_id
+				left join parking_slot sl on sl.id = t.parking_slot_id
+				left join block b on b.id = sl.block_id
+				left join lateral (
+					select count(e.id) as extend_count, coalesce(sum(e.total), 0) as extend_total, max(e.end_time) as extended_until
+					from ticket_extend te
+					join ticket e on e.id = te.ticket_extend_id and e.deleted_at is null
+					where te.ticket_id = t.id and te.deleted_at is null
+				) ext on true
+				where t.deleted_at is null
+					and t.parking_lot_id = @parking_lot_id
+					and ((@state = '' and t.state <> 'extend') or t.state = @state)
+				order by t.created_at desc, t.id desc`
+
+	rows, err := tx.Raw(utils.RemoveSpace(query), map[string]interface{}{
+		"parking_lot_id": valid.String(req.ParkingLotID),
+		"state":          valid.String(req.State),
+	}).Rows()
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to ExportTicketCompany")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := model.ExportTicketRow{}
+		if err := tx.ScanRows(rows, &row); err != nil {
+			log.WithError(err).Error("error_500: failed to scan ExportTicketCompany")
+			return apperror.Wrap(apperror.Internal, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.WithError(err).Error("error_500: failed to read ExportTicketCompany")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
@@ -3,10 +3,9 @@ package repo
 import (
 	"context"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 )
@@ -20,7 +19,7 @@ func (r *RepoPG) CreateFavorite(ctx context.Context, favorite *model.Favorite, t
 	}
 	if err := tx.Model(&model.Favorite{}).Create(&favorite).Error; err != nil {
 		log.WithError(err).Error("Error when create favorite parking - Create - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when create favorite parking: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -34,7 +33,7 @@ func (r *RepoPG) GetAllFavoriteParkingByUser(ctx context.Context, userId string,
 	}
 	if err = tx.Model(&model.Favorite{}).Where("user_id = ?", userId).Preload("ParkingLot").Find(&res).Error; err != nil {
 		log.WithError(err).Error("Error when get all favorite parking by user id - GetAllFavoriteParkingByUser - RepoPG")
-		return nil, ginext.NewError(http.StatusInternalServerError, "Error when get all favorite parking by user id: "+err.Error())
+		return nil, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -47,7 +46,7 @@ func (r *RepoPG) DeleteOneFavorite(ctx context.Context, id uuid.UUID, tx *gorm.D
 	}
 	if err := tx.Where("id = ?", id).Delete(&model.Favorite{}).Error; err != nil {
 		log.WithError(err).Error("Error when delete favorite parking - DeleteOneFavorite - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when delete favorite parking: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -61,7 +60,7 @@ func (r *RepoPG) GetOne(ctx context.Context, req model.FavoriteRequestV2, tx *go
 	var res model.Favorite
 	if err := tx.Where(" user_id= ? and parking_lot_id = ?", req.UserId, req.ParkingLotId).Take(&res).Error; err != nil {
 		log.WithError(err).Error("Error when delete favorite parking - DeleteOneFavorite - RepoPG")
-		return res, ginext.NewError(http.StatusInternalServerError, "Error when delete favorite parking: "+err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+// ClaimIdempotencyKey stores req unless the user already sent a request with the key that has not expired,
+// in which case the stored request is returned. An expired key is claimed again.
+func (r *RepoPG) ClaimIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"method":       req.Method,
+			"path":         req.Path,
+			"request_hash": req.RequestHash,
+			"status_code":  0,
+			"content_type": "",
+			"response":     nil,
+			"created_at":   gorm.Expr("now()"),
+			"completed_at": nil,
+			"expires_at":   req.ExpiresAt,
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "idempotency_key.expires_at <= now()"}}},
+	}).Create(req)
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to ClaimIdempotencyKey")
+		return nil, apperror.Wrap(apperror.Internal, res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existing model.IdempotencyKey
+	if err := tx.Where("user_id = ? and key = ?", req.UserId, req.Key).Take(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// the first request failed and released the key in the meantime
+			return nil, apperror.New(apperror.IdempotencyKeyInProgress)
+		}
+		log.WithError(err).Error("error_500: failed to ClaimIdempotencyKey")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return &existing, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed the key.
+func (r *RepoPG) CompleteIdempotencyKey(ctx context.Context, req *model.IdempotencyKey) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	req.CompletedAt = &now
+	if err := tx.Model(&model.IdempotencyKey{}).Where("user_id = ? and key = ?", req.UserId, req.Key).Updates(map[string]interface{}{
+		"status_code":  req.StatusCode,
+		"content_type": req.ContentType,
+		"response":     req.Response,
+		"completed_at": req.CompletedAt,
+	}).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to CompleteIdempotencyKey")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey releases a key whose request failed so a retry runs it again.
+func (r *RepoPG) DeleteIdempotencyKey(ctx context.Context, userID, key string) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("user_id = ? and key = ?", userID, key).Delete(&model.IdempotencyKey{}).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to DeleteIdempotencyKey")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteExpiredIdempotencyKey(ctx context.Context) (int64, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Where("expires_at <= now()").Delete(&model.IdempotencyKey{})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to DeleteExpiredIdempotencyKey")
+		return 0, apperror.Wrap(apperror.Internal, res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+)
+
+// GetParkingLotLayout returns the blocks of a parking lot with their slots, ordered by code and name.
+func (r *RepoPG) GetParkingLotLayout(ctx context.Context, parkingLotID uuid.UUID) (res []model.Block, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.Block{}).Where("parking_lot_id = ?", parkingLotID).
+		Preload("ParkingSLots", func(db *gorm.DB) *gorm.DB {
+			return db.Order("name")
+		}).Order("code").Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetParkingLotLayout")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) CreateParkingSlots(ctx context.Context, slots []model.ParkingSlot) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if len(slots) == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.ParkingSlot{}).CreateInBatches(&slots, 500).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateParkingSlots")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// GetSlotIDsWithUpcomingTicket returns which of slotIDs still have a ticket that is not over yet.
+func (r *RepoPG) GetSlotIDsWithUpcomingTicket(ctx context.Context, slotIDs []uuid.UUID) (res []uuid.UUID, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if len(slotIDs) == 0 {
+		return nil, nil
+	}
+	if err := tx.Model(&model.Ticket{}).Distinct("parking_slot_id").
+		Where("parking_slot_id in ?", slotIDs).
+		Where("state in ?", []string{"new", "extend", "ongoing"}).
+		Where("end_time > now()").
+		Pluck("parking_slot_id", &res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetSlotIDsWithUpcomingTicket")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
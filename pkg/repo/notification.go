@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+// GetNotificationPreference returns the preference of a user, nil when they never set one.
+func (r *RepoPG) GetNotificationPreference(ctx context.Context, userID uuid.UUID) (*model.NotificationPreference, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	var res []model.NotificationPreference
+	if err := tx.Model(&model.NotificationPreference{}).Where("user_id = ?", userID).Limit(1).Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetNotificationPreference")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return &res[0], nil
+}
+
+func (r *RepoPG) SaveNotificationPreference(ctx context.Context, req *model.NotificationPreference) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Save(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when SaveNotificationPreference")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// SaveDeviceToken registers a push token for a user, a token moves to the last user who registered it.
+func (r *RepoPG) SaveDeviceToken(ctx context.Context, req *model.DeviceToken) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"user_id": req.UserId, "platform": req.Platform, "updated_at": time.Now(), "deleted_at": nil}),
+	}).Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when SaveDeviceToken")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteDeviceToken(ctx context.Context, userID uuid.UUID, token string) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("user_id = ? and token = ?", userID, token).Delete(&model.DeviceToken{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteDeviceToken")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetDeviceTokens(ctx context.Context, userID uuid.UUID) (res []model.DeviceToken, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.DeviceToken{}).Where("user_id = ?", userID).Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetDeviceTokens")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) CreateNotification(ctx context.Context, req *model.Notification) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateNotification")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetListNotification(ctx context.Context, req model.ListNotificationReq) (res model.ListNotificationRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.Notification{}).Where("user_id = ? and status <> ?", req.UserId, model.NotificationStatusSkipped)
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListNotification")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetTicketToNotify returns the ongoing tickets due, at the end of their last extension, from from to to
+// that were not notified about event yet.
+func (r *RepoPG) GetTicketToNotify(ctx context.Context, event string, from, to time.Time) (res []model.TicketToNotify, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := utils.RemoveSpace(`select t.*, greatest(t.end_time, coalesce(ext.end_time, t.end_time)) as due_time
+								from ticket t
+								left join lateral (select max(x.end_time) as end_time
+													from ticket_extend te
+													join ticket x on x.id = te.ticket_extend_id
+													where te.ticket_id = t.id
+														and te.deleted_at is null
+														and x.deleted_at is null
+														and x.state <> 'cancel') ext on true
+								where t.deleted_at is null
+									and t.state = 'ongoing'
+									and greatest(t.end_time, coalesce(ext.end_time, t.end_time)) >= @from
+									and greatest(t.end_time, coalesce(ext.end_time, t.end_time)) < @to
+									and not exists (select 1 from notification n
+													where n.ticket_id = t.id
+														and n.event = @event)`)
+	if err := tx.Raw(query, map[string]interface{}{"event": event, "from": from, "to": to}).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetTicketToNotify")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
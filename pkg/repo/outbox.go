@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"sort"
+	"time"
+)
+
+func (r *RepoPG) CreateOutboxEvent(ctx context.Context, req *model.OutboxEvent) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateOutboxEvent")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// ClaimOutboxEvents returns the oldest events not published yet that are due and postpones them by lease,
+// so another dispatcher does not hand them out again while they are being processed.
+func (r *RepoPG) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) (res []model.OutboxEvent, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := utils.RemoveSpace(`update outbox set next_attempt_at = @lease_until
+								where id in (select id from outbox
+												where published_at is null
+													and next_attempt_at <= now()
+												order by created_at
+												limit @limit
+												for update skip locked)
+								returning *`)
+	args := map[string]interface{}{
+		"lease_until": time.Now().Add(lease),
+		"limit":       limit,
+	}
+	if err := tx.Raw(query, args).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to ClaimOutboxEvents")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+	return res, nil
+}
+
+func (r *RepoPG) MarkOutboxEventPublished(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"published_at": time.Now(), "last_error": ""}).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to MarkOutboxEventPublished")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// MarkOutboxEventFailed records why an event could not be processed and when to try it again.
+func (r *RepoPG) MarkOutboxEventFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      reason,
+		"next_attempt_at": retryAt,
+	}).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to MarkOutboxEventFailed")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// MarkEventProcessed records that consumer handled the event, it returns false when it already had.
+func (r *RepoPG) MarkEventProcessed(ctx context.Context, consumer string, eventID uuid.UUID) (bool, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ProcessedEvent{Consumer: consumer, EventId: eventID})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to MarkEventProcessed")
+		return false, apperror.Wrap(apperror.Internal, res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
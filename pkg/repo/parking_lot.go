@@ -4,10 +4,9 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
@@ -21,7 +20,7 @@ func (r *RepoPG) CreateParkingLot(ctx context.Context, req *model.ParkingLot) er
 
 	if err := tx.Model(&model.ParkingLot{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateParkingLot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -35,21 +34,23 @@ func (r *RepoPG) GetOneParkingLot(ctx context.Context, id uuid.UUID) (res model.
 	if err = tx.Model(&model.ParkingLot{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneParkingLot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
 
+// GetListParkingLot lists the parking lots shown to the drivers, those of the active companies.
 func (r *RepoPG) GetListParkingLot(ctx context.Context, req model.ListParkingLotReq) (res model.ListParkingLotRes, err error) {
 	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
 
 	tx, cancel := r.DBWithTimeout(ctx)
 	defer cancel()
 
-	tx = tx.Model(&model.ParkingLot{})
+	tx = tx.Model(&model.ParkingLot{}).
+		Where("company_id in (select id from company where status = ?)", model.CompanyStatusActive)
 
 	if req.Name != nil {
 		name := utils.TransformString(valid.String(req.Name), false)
@@ -60,24 +61,42 @@ func (r *RepoPG) GetListParkingLot(ctx context.Context, req model.ListParkingLot
 		tx = tx.Where("round(cast(ST_DistanceSphere(ST_MakePoint(long , lat),ST_MakePoint( ?,?)) As numeric)/1000.0,1) < ?", req.Long, req.Lat, req.Distance)
 	}
 
-	if req.Sort != "" {
-		tx = tx.Order(req.Sort)
-	} else {
-		tx = tx.Order("created_at desc")
+	if tx, err = parkingLotQuerySpec.ApplyFilter(tx, req.Filter); err != nil {
+		log.WithError(err).Error("error_400: invalid filter")
+		return res, err
 	}
 
 	var total int64 = 0
 	page := r.GetPage(req.Page)
 	pageSize := r.GetPageSize(req.PageSize)
 
+	// keyset pagination skips the COUNT but only supports the default order
+	if req.Cursor != nil {
+		if req.Sort != "" {
+			return res, apperror.New(apperror.CursorSortUnsupported)
+		}
+		if res.Data, res.Meta, err = FindCursorPage(r, tx, "", valid.String(req.Cursor), pageSize, func(p model.ParkingLot) model.BaseModel {
+			return p.BaseModel
+		}); err != nil {
+			log.WithError(err).Error("error_500: failed to get cursor page")
+			return res, err
+		}
+		return res, nil
+	}
+
+	if tx, err = parkingLotQuerySpec.ApplySort(tx, req.Sort); err != nil {
+		log.WithError(err).Error("error_400: invalid sort")
+		return res, err
+	}
+
 	if err := tx.Count(&total).Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetListParkingLot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
 		log.WithError(err).Error("error_500: failed to get pagination")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	return res, nil
@@ -91,7 +110,7 @@ func (r *RepoPG) UpdateParkingLot(ctx context.Context, req *model.ParkingLot) er
 
 	if err := tx.Model(&model.ParkingLot{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when UpdateParkingLot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -104,7 +123,7 @@ func (r *RepoPG) DeleteParkingLot(ctx context.Context, id uuid.UUID) error {
 
 	if err := tx.Where("id = ?", id).Delete(&model.ParkingLot{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when DeleteParkingLot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -126,25 +145,146 @@ func (r *RepoPG) GetListParkingLotCompany(ctx context.Context, req model.GetList
 		tx = tx.Where("unaccent(name) ilike ?", name+"%")
 	}
 
-	if req.Sort != "" {
-		tx = tx.Order(req.Sort)
-	} else {
-		tx = tx.Order("created_at desc")
+	if tx, err = parkingLotQuerySpec.ApplyFilter(tx, req.Filter); err != nil {
+		log.WithError(err).Error("error_400: invalid filter")
+		return res, err
 	}
 
 	var total int64 = 0
 	page := r.GetPage(req.Page)
 	pageSize := r.GetPageSize(req.PageSize)
 
+	// keyset pagination skips the COUNT but only supports the default order
+	if req.Cursor != nil {
+		if req.Sort != "" {
+			return res, apperror.New(apperror.CursorSortUnsupported)
+		}
+		if res.Data, res.Meta, err = FindCursorPage(r, tx, "", valid.String(req.Cursor), pageSize, func(p model.ParkingLot) model.BaseModel {
+			return p.BaseModel
+		}); err != nil {
+			log.WithError(err).Error("error_500: failed to get cursor page")
+			return res, err
+		}
+		return res, nil
+	}
+
+	if tx, err = parkingLotQuerySpec.ApplySort(tx, req.Sort); err != nil {
+		log.WithError(err).Error("error_400: invalid sort")
+		return res, err
+	}
+
 	if err := tx.Count(&total).Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetListParkingLot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
 		log.WithError(err).Error("error_500: failed to get pagination")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	return res, nil
+}
+
+func (r *RepoPG) GetOneParkingLotSearchItem(ctx context.Context, id uuid.UUID) (res model.ParkingLotSearchItem, err error) {
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Table("parking_lot pl").
+		Select("pl.*, c.name as company_name, c.status as company_status").
+		Joins("left join company c on c.id = pl.company_id").
+		Where("pl.id = ? and pl.deleted_at is null", id).
+		Take(&res).Error; err != nil {
+		return res, r.ReturnErrorInGetFuncV2(ctx, "failed to GetOneParkingLotSearchItem", err, "id", id)
+	}
+	return res, nil
+}
+
+// GetListParkingLotSearchItem lists the parking lots to index for search, those of the active companies.
+func (r *RepoPG) GetListParkingLotSearchItem(ctx context.Context, page int, pageSize int) (res []model.ParkingLotSearchItem, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Table("parking_lot pl").
+		Select("pl.*, c.name as company_name, c.status as company_status").
+		Joins("left join company c on c.id = pl.company_id").
+		Where("pl.deleted_at is null and c.status = ?", model.CompanyStatusActive).
+		Order("pl.created_at, pl.id").
+		Limit(pageSize).Offset(r.GetOffset(page, pageSize)).
+		Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListParkingLotSearchItem")
+		return nil, apperror.Wrap(apperror.Internal, err)
 	}
+	return res, nil
+}
+
+// SearchParkingLot is the Postgres fallback of the Elasticsearch search: accent-insensitive
+// substring matching on name, address, description and company name, sorted by distance when a location is given.
+// Only the parking lots of the active companies are found.
+func (r *RepoPG) SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (res model.SearchParkingLotRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
 
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Table("parking_lot pl").
+		Joins("left join company c on c.id = pl.company_id").
+		Where("pl.deleted_at is null and c.status = ?", model.CompanyStatusActive)
+
+	if q := utils.TransformString(valid.String(req.Query), false); q != "" {
+		q = "%" + escapeLike(q) + "%"
+		tx = tx.Where("(unaccent(pl.name) ilike ? or unaccent(pl.address) ilike ? or unaccent(pl.description) ilike ? or unaccent(c.name) ilike ?)", q, q, q, q)
+	}
+
+	if req.CompanyID != nil {
+		tx = tx.Where("pl.company_id = ?", valid.String(req.CompanyID))
+	}
+
+	hasLocation := req.Lat != nil && req.Long != nil
+	if hasLocation && req.Distance != nil {
+		tx = tx.Where("ST_DistanceSphere(ST_MakePoint(pl.long, pl.lat), ST_MakePoint(?, ?))/1000.0 < ?", req.Long, req.Lat, req.Distance)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to count SearchParkingLot")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if hasLocation {
+		tx = tx.Select("pl.*, c.name as company_name, ST_DistanceSphere(ST_MakePoint(pl.long, pl.lat), ST_MakePoint(?, ?))/1000.0 as distance", req.Long, req.Lat).
+			Order("distance")
+	} else {
+		tx = tx.Select("pl.*, c.name as company_name").Order("pl.created_at desc")
+	}
+
+	if err := tx.Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Scan(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to SearchParkingLot")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	return res, nil
+}
+
+func (r *RepoPG) GetParkingLotIDsByCompany(ctx context.Context, companyID uuid.UUID) (res []uuid.UUID, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.ParkingLot{}).Where("company_id = ?", companyID).Pluck("id", &res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetParkingLotIDsByCompany")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
 	return res, nil
 }
@@ -3,15 +3,15 @@ package repo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
-	"time"
 )
 
 func (r *RepoPG) CreateParkingSlot(ctx context.Context, req *model.ParkingSlot) error {
@@ -22,7 +22,7 @@ func (r *RepoPG) CreateParkingSlot(ctx context.Context, req *model.ParkingSlot)
 
 	if err := tx.Model(&model.ParkingSlot{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateParkingSlot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -36,10 +36,10 @@ func (r *RepoPG) GetOneParkingSlot(ctx context.Context, id uuid.UUID) (res model
 	if err = tx.Model(&model.ParkingSlot{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneParkingSlot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -56,28 +56,84 @@ func (r *RepoPG) GetListParkingSlot(ctx context.Context, req model.ListParkingSl
 		tx = tx.Where("block_id = ?", valid.String(req.BlockID))
 	}
 
-	if req.Sort != "" {
-		tx = tx.Order(req.Sort)
-	} else {
-		tx = tx.Order("created_at desc")
+	if tx, err = parkingSlotQuerySpec.ApplyFilter(tx, req.Filter); err != nil {
+		log.WithError(err).Error("error_400: invalid filter")
+		return res, err
 	}
 
 	var total int64 = 0
 	page := r.GetPage(req.Page)
 	pageSize := r.GetPageSize(req.PageSize)
 
+	// keyset pagination skips the COUNT but only supports the default order
+	if req.Cursor != nil {
+		if req.Sort != "" {
+			return res, apperror.New(apperror.CursorSortUnsupported)
+		}
+		if res.Data, res.Meta, err = FindCursorPage(r, tx, "", valid.String(req.Cursor), pageSize, func(p model.ParkingSlot) model.BaseModel {
+			return p.BaseModel
+		}); err != nil {
+			log.WithError(err).Error("error_500: failed to get cursor page")
+			return res, err
+		}
+		return res, nil
+	}
+
+	if tx, err = parkingSlotQuerySpec.ApplySort(tx, req.Sort); err != nil {
+		log.WithError(err).Error("error_400: invalid sort")
+		return res, err
+	}
+
 	if err := tx.Count(&total).Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetListParkingSlot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
 		log.WithError(err).Error("error_500: failed to get pagination")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	return res, nil
 }
+
+// availableParkingSlotQuery lists the slots of a parking lot free over a time range, with their block. Its
+// arguments are those of availableParkingSlotArgs.
+var availableParkingSlotQuery = utils.RemoveSpace(`select
+									sl.*,
+									b.id as "Block__id",
+									b.creator_id as "Block__creator_id",
+									b.updater_id as "Block__updater_id",
+									b.created_at as "Block__created_at",
+									b.updated_at as "Block__updated_at",
+									b.deleted_at as "Block__deleted_at",
+									b.code as "Block__code",
+									b.description as "Block__description",
+									b.slot as "Block__slot",
+									b.parking_lot_id as "Block__parking_lot_id"
+								from
+									parking_slot sl
+								join block b on sl.block_id = b.id
+								where not exists (select 1 from ticket t
+													where t.parking_slot_id = sl.id
+														and t.deleted_at is null
+														and t.state in ('new', 'extend', 'ongoing')
+														and t.start_time < ?
+														and t.end_time > ?)
+								  and ` + fmt.Sprintf(slotDowntimeFilter, "sl") + `
+								  and ` + fmt.Sprintf(waitlistOfferFilter, "sl") + `
+								  and ` + fmt.Sprintf(slotHoldFilter, "sl") + `
+								  and sl.deleted_at is null
+								  and b.deleted_at is null
+								  and b.parking_lot_id = ?
+								order by
+									b.code,
+									sl.created_at`)
+
+func availableParkingSlotArgs(req model.AvailableParkingSlotReq) []interface{} {
+	return []interface{}{req.End, req.Start, req.End, req.Start, req.End, req.Start, req.End, req.Start, req.UserId, req.ParkingLotId}
+}
+
 func (r *RepoPG) GetAvailableParkingSlot(ctx context.Context, req model.AvailableParkingSlotReq) (res model.ListParkingSlotRes, err error) {
 	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
 
@@ -86,36 +142,9 @@ func (r *RepoPG) GetAvailableParkingSlot(ctx context.Context, req model.Availabl
 
 	tx = tx.Model(&model.ParkingSlot{})
 
-	query := utils.RemoveSpace(`select
-										sl.*,
-										b.id as "Block__id",
-										b.creator_id as "Block__creator_id",
-										b.updater_id as "Block__updater_id",
-										b.created_at as "Block__created_at",
-										b.updated_at as "Block__updated_at",
-										b.deleted_at as "Block__deleted_at",
-										b.code as "Block__code",
-										b.description as "Block__description",
-										b.slot as "Block__slot",
-										b.parking_lot_id as "Block__parking_lot_id"
-									from
-										parking_slot sl
-									join block b on sl.block_id = b.id
-									where sl.id  not in ( select t.parking_slot_id as id 
-									                      from ticket t
-															where t.state in ('new', 'extend', 'ongoing')
-															  and t.parking_lot_id = ?
-															  and t.start_time < ?
-															  and t.end_time > ?) 
-									  and b.parking_lot_id = ?
-									order by
-										b.code,
-										sl.created_at`)
-	req.Start = valid.DayTimePointer(valid.DayTime(req.Start).Add(1 * time.Second))
-	req.End = valid.DayTimePointer(valid.DayTime(req.End).Add(-1 * time.Second))
-	if err := tx.Raw(query, req.ParkingLotId, req.End, req.Start, req.ParkingLotId).Scan(&res.Data).Error; err != nil {
+	if err := tx.Raw(availableParkingSlotQuery, availableParkingSlotArgs(req)...).Scan(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetAvailableParkingSlot")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -127,7 +156,7 @@ func (r *RepoPG) UpdateParkingSlot(ctx context.Context, req *model.ParkingSlot)
 
 	if err := tx.Model(&model.ParkingSlot{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when UpdateParkingSlot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -140,7 +169,127 @@ func (r *RepoPG) DeleteParkingSlot(ctx context.Context, id uuid.UUID) error {
 
 	if err := tx.Where("id = ?", id).Delete(&model.ParkingSlot{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when DeleteParkingSlot")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// freeParkingSlotQuery selects the slots of req.ParkingLotID without overlapping tickets or downtime.
+func freeParkingSlotQuery(tx *gorm.DB, req model.FreeSlotReq) *gorm.DB {
+	tx = tx.Model(&model.ParkingSlot{}).
+		Joins("join block b on b.id = parking_slot.block_id and b.deleted_at is null").
+		Where("b.parking_lot_id = ?", req.ParkingLotID).
+		Where(`not exists (select 1 from ticket t
+							where t.parking_slot_id = parking_slot.id
+								and t.deleted_at is null
+								and t.state in ('new', 'extend', 'ongoing')
+								and t.start_time < ?
+								and t.end_time > ?)`, req.End, req.Start).
+		Where(fmt.Sprintf(slotDowntimeFilter, "parking_slot"), req.End, req.Start).
+		Where(fmt.Sprintf(waitlistOfferFilter, "parking_slot"), req.End, req.Start).
+		Where(fmt.Sprintf(slotHoldFilter, "parking_slot"), req.End, req.Start, req.HolderID)
+	if req.ExcludeBlockID != nil {
+		tx = tx.Where("parking_slot.block_id <> ?", req.ExcludeBlockID)
+	}
+	if len(req.ExcludeSlotIDs) > 0 {
+		tx = tx.Where("parking_slot.id not in ?", req.ExcludeSlotIDs)
+	}
+	if req.SlotID != nil {
+		tx = tx.Where("parking_slot.id = ?", req.SlotID)
+	}
+	if req.StrictVehicleType && req.VehicleType != "" {
+		tx = tx.Where("parking_slot.vehicle_type in ('', ?)", req.VehicleType)
+	}
+	return tx
+}
+
+// GetFreeParkingSlot returns a slot without overlapping tickets for req, nil when the parking lot is full.
+func (r *RepoPG) GetFreeParkingSlot(ctx context.Context, req model.FreeSlotReq) (*model.ParkingSlot, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	// one ORDER BY expression as Order ignores a clause.OrderBy and the expressions of merged clauses are lost
+	order, vars := "parking_slot.vehicle_type = ? desc, b.code, parking_slot.name", []interface{}{req.VehicleType}
+	if req.PreferSlotID != nil {
+		order, vars = "parking_slot.id = ? desc, "+order, append([]interface{}{req.PreferSlotID}, vars...)
+	}
+	tx = freeParkingSlotQuery(tx, req).Select("parking_slot.*").
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: order, Vars: vars, WithoutParentheses: true}})
+
+	var res []model.ParkingSlot
+	if err := tx.Limit(1).Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetFreeParkingSlot")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return &res[0], nil
+}
+
+// GetSlotCandidates returns every slot free for req with what the assignment strategies need to rank them.
+func (r *RepoPG) GetSlotCandidates(ctx context.Context, req model.FreeSlotReq) ([]model.SlotCandidate, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	var res []model.SlotCandidate
+	err := freeParkingSlotQuery(tx, req).
+		Select(`parking_slot.*, b.code as block_code,
+				(select count(*) from ticket u
+					where u.parking_slot_id = parking_slot.id
+						and u.deleted_at is null
+						and u.state <> 'cancel'
+						and u.start_time > now() - interval '30 days') as usage_count`).
+		Order("b.code").Order("parking_slot.name").
+		Find(&res).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to GetSlotCandidates")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// LockParkingLot serializes slot assignment in a parking lot until the end of the current transaction.
+func (r *RepoPG) LockParkingLot(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Exec("select pg_advisory_xact_lock(hashtext(?))", id.String()).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to LockParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteParkingSlotByBlock(ctx context.Context, blockID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("block_id = ?", blockID).Delete(&model.ParkingSlot{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteParkingSlotByBlock")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteParkingSlotByParkingLot(ctx context.Context, parkingLotID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("block_id in (?)", r.db.Model(&model.Block{}).Select("id").Where("parking_lot_id = ?", parkingLotID)).
+		Delete(&model.ParkingSlot{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteParkingSlotByParkingLot")
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"parkar-server/pkg/model"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// BenchmarkGetAvailableParkingSlot times the availability query for one lot and one hour on the data seeded
+// by scripts/bench_availability.sql, and logs its plan. It only runs against BENCH_DATABASE_DSN:
+//
+//	BENCH_DATABASE_DSN="host=... dbname=..." go test ./pkg/repo -run '^$' -bench GetAvailableParkingSlot -v
+func BenchmarkGetAvailableParkingSlot(b *testing.B) {
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var lotID string
+	if err := db.Raw("select id from parking_lot where name = 'bench lot 1'").Scan(&lotID).Error; err != nil {
+		b.Fatal(err)
+	}
+	if lotID == "" {
+		b.Fatal("no bench lot, run scripts/bench_availability.sql first")
+	}
+	start := time.Now().UTC().Truncate(24 * time.Hour).Add(30*24*time.Hour + 9*time.Hour)
+	end := start.Add(time.Hour)
+	req := model.AvailableParkingSlotReq{ParkingLotId: &lotID, Start: &start, End: &end}
+
+	rows, err := db.Raw("explain (analyze, buffers) "+availableParkingSlotQuery, availableParkingSlotArgs(req)...).Rows()
+	if err != nil {
+		b.Fatal(err)
+	}
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			b.Fatal(err)
+		}
+		plan = append(plan, line)
+	}
+	rows.Close()
+	b.Log("\n" + strings.Join(plan, "\n"))
+
+	r := &RepoPG{db: db}
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetAvailableParkingSlot(ctx, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
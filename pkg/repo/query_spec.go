@@ -0,0 +1,254 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FilterEq       = "eq"
+	FilterIn       = "in"
+	FilterRange    = "range"
+	FilterContains = "contains"
+)
+
+// FieldType is the type the filter values of a field are parsed to before they reach the SQL.
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	// FieldTime takes a date (2023-01-01) or an RFC 3339 time.
+	FieldTime
+	FieldUUID
+)
+
+// QueryField maps a public field name of a list endpoint to its SQL column.
+// Every field can be filtered on, only Text fields accept the contains operator.
+type QueryField struct {
+	Column   string
+	Type     FieldType
+	Sortable bool
+	Text     bool
+}
+
+// parse converts a filter value to the type of the field.
+func (field QueryField) parse(value string) (interface{}, error) {
+	switch field.Type {
+	case FieldInt:
+		return strconv.Atoi(value)
+	case FieldTime:
+		if t, err := time.Parse("2006-01-02", value); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339, value)
+	case FieldUUID:
+		return uuid.Parse(value)
+	default:
+		return value, nil
+	}
+}
+
+// parseAll parses every value, the error is the InvalidFilter one of filter.
+func (field QueryField) parseAll(filter string, values ...string) ([]interface{}, error) {
+	res := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v, err := field.parse(value)
+		if err != nil {
+			return nil, apperror.Wrap(apperror.InvalidFilter, err, filter)
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// QuerySpec whitelists the fields a list endpoint can be sorted and filtered by,
+// user input never reaches the SQL as anything but a bound value.
+//
+// Sort is a comma separated list of fields, each optionally prefixed by "-" or
+// followed by "asc"/"desc": "code,-created_at" or "code asc, created_at desc".
+//
+// Filters are "field:op:value" with op one of eq, in, range, contains:
+//
+//	state:eq:new
+//	state:in:new,extend
+//	created_at:range:2023-01-01,2023-02-01 (either bound may be empty)
+//	name:contains:quan
+//
+// The values of eq, in and range are parsed to the Type of the field first.
+type QuerySpec struct {
+	Fields      map[string]QueryField
+	DefaultSort []clause.OrderByColumn
+}
+
+// Apply adds the sort and filters to tx, returning a 400 error on unknown fields or operators.
+func (spec QuerySpec) Apply(tx *gorm.DB, sort string, filters []string) (*gorm.DB, error) {
+	tx, err := spec.ApplyFilter(tx, filters)
+	if err != nil {
+		return tx, err
+	}
+	return spec.ApplySort(tx, sort)
+}
+
+func (spec QuerySpec) ApplySort(tx *gorm.DB, sort string) (*gorm.DB, error) {
+	columns, err := spec.ParseSort(sort)
+	if err != nil {
+		return tx, err
+	}
+	// Order ignores a clause.OrderBy, it has to be added as a clause
+	return tx.Clauses(clause.OrderBy{Columns: columns}), nil
+}
+
+func (spec QuerySpec) ParseSort(sort string) ([]clause.OrderByColumn, error) {
+	if strings.TrimSpace(sort) == "" {
+		return spec.DefaultSort, nil
+	}
+
+	var columns []clause.OrderByColumn
+	for _, item := range strings.Split(sort, ",") {
+		parts := strings.Fields(item)
+		if len(parts) == 0 {
+			continue
+		}
+		name, desc := parts[0], false
+		if strings.HasPrefix(name, "-") {
+			name, desc = name[1:], true
+		}
+		if len(parts) > 2 {
+			return nil, apperror.New(apperror.InvalidSort, item)
+		}
+		if len(parts) == 2 {
+			switch strings.ToLower(parts[1]) {
+			case "asc":
+			case "desc":
+				desc = !desc
+			default:
+				return nil, apperror.New(apperror.InvalidSort, item)
+			}
+		}
+		field, ok := spec.Fields[name]
+		if !ok || !field.Sortable {
+			return nil, apperror.New(apperror.InvalidSort, item)
+		}
+		columns = append(columns, clause.OrderByColumn{Column: clause.Column{Name: field.Column, Raw: true}, Desc: desc})
+	}
+	if len(columns) == 0 {
+		return spec.DefaultSort, nil
+	}
+	return columns, nil
+}
+
+func (spec QuerySpec) ApplyFilter(tx *gorm.DB, filters []string) (*gorm.DB, error) {
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, ":", 3)
+		if len(parts) != 3 {
+			return tx, apperror.New(apperror.InvalidFilter, filter)
+		}
+		name, op, value := parts[0], parts[1], parts[2]
+		field, ok := spec.Fields[name]
+		if !ok {
+			return tx, apperror.New(apperror.InvalidFilter, filter)
+		}
+
+		switch op {
+		case FilterEq:
+			values, err := field.parseAll(filter, value)
+			if err != nil {
+				return tx, err
+			}
+			tx = tx.Where(field.Column+" = ?", values[0])
+		case FilterIn:
+			values, err := field.parseAll(filter, strings.Split(value, ",")...)
+			if err != nil {
+				return tx, err
+			}
+			tx = tx.Where(field.Column+" in ?", values)
+		case FilterRange:
+			bounds := strings.Split(value, ",")
+			if len(bounds) != 2 || (bounds[0] == "" && bounds[1] == "") {
+				return tx, apperror.New(apperror.InvalidFilter, filter)
+			}
+			if bounds[0] != "" {
+				from, err := field.parseAll(filter, bounds[0])
+				if err != nil {
+					return tx, err
+				}
+				tx = tx.Where(field.Column+" >= ?", from[0])
+			}
+			if bounds[1] != "" {
+				to, err := field.parseAll(filter, bounds[1])
+				if err != nil {
+					return tx, err
+				}
+				tx = tx.Where(field.Column+" <= ?", to[0])
+			}
+		case FilterContains:
+			if !field.Text {
+				return tx, apperror.New(apperror.InvalidFilter, filter)
+			}
+			tx = tx.Where("unaccent("+field.Column+") ilike unaccent(?)", "%"+escapeLike(value)+"%")
+		default:
+			return tx, apperror.New(apperror.InvalidFilter, filter)
+		}
+	}
+	return tx, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+var defaultSort = []clause.OrderByColumn{{Column: clause.Column{Name: "created_at", Raw: true}, Desc: true}}
+
+var parkingLotQuerySpec = QuerySpec{
+	Fields: map[string]QueryField{
+		"name":        {Column: "name", Sortable: true, Text: true},
+		"address":     {Column: "address", Sortable: true, Text: true},
+		"description": {Column: "description", Text: true},
+		"company_id":  {Column: "company_id", Type: FieldUUID},
+		"start_time":  {Column: "start_time", Type: FieldTime, Sortable: true},
+		"end_time":    {Column: "end_time", Type: FieldTime, Sortable: true},
+		"created_at":  {Column: "created_at", Type: FieldTime, Sortable: true},
+		"updated_at":  {Column: "updated_at", Type: FieldTime, Sortable: true},
+	},
+	DefaultSort: defaultSort,
+}
+
+var blockQuerySpec = QuerySpec{
+	Fields: map[string]QueryField{
+		"code":        {Column: "code", Sortable: true, Text: true},
+		"description": {Column: "description", Text: true},
+		"slot":        {Column: "slot", Type: FieldInt, Sortable: true},
+		"created_at":  {Column: "created_at", Type: FieldTime, Sortable: true},
+		"updated_at":  {Column: "updated_at", Type: FieldTime, Sortable: true},
+	},
+	DefaultSort: defaultSort,
+}
+
+var parkingSlotQuerySpec = QuerySpec{
+	Fields: map[string]QueryField{
+		"name":         {Column: "name", Sortable: true, Text: true},
+		"description":  {Column: "description", Text: true},
+		"vehicle_type": {Column: "vehicle_type"},
+		"block_id":     {Column: "block_id", Type: FieldUUID},
+		"created_at":   {Column: "created_at", Type: FieldTime, Sortable: true},
+		"updated_at":   {Column: "updated_at", Type: FieldTime, Sortable: true},
+	},
+	DefaultSort: defaultSort,
+}
+
+var vehicleQuerySpec = QuerySpec{
+	Fields: map[string]QueryField{
+		"name":       {Column: "name", Sortable: true, Text: true},
+		"number":     {Column: "number", Sortable: true, Text: true},
+		"type":       {Column: "type", Sortable: true},
+		"created_at": {Column: "created_at", Type: FieldTime, Sortable: true},
+		"updated_at": {Column: "updated_at", Type: FieldTime, Sortable: true},
+	},
+	DefaultSort: defaultSort,
+}
@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dryRunDB builds the SQL of the queries without a database.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// blockSQL returns the statement and the bound values of a block list scoped by scope.
+func blockSQL(t *testing.T, scope func(tx *gorm.DB) (*gorm.DB, error)) (string, []interface{}, error) {
+	t.Helper()
+	tx, err := scope(dryRunDB(t).Model(&model.Block{}))
+	if err != nil {
+		return "", nil, err
+	}
+	stmt := tx.Find(&[]model.Block{}).Statement
+	return stmt.SQL.String(), stmt.Vars, nil
+}
+
+func TestQuerySpecParseSort(t *testing.T) {
+	tests := []struct {
+		sort string
+		want []clause.OrderByColumn
+	}{
+		{"", defaultSort},
+		{" , ", defaultSort},
+		{"code", []clause.OrderByColumn{{Column: clause.Column{Name: "code", Raw: true}}}},
+		{"code,-created_at", []clause.OrderByColumn{
+			{Column: clause.Column{Name: "code", Raw: true}},
+			{Column: clause.Column{Name: "created_at", Raw: true}, Desc: true},
+		}},
+		{"code asc, created_at DESC", []clause.OrderByColumn{
+			{Column: clause.Column{Name: "code", Raw: true}},
+			{Column: clause.Column{Name: "created_at", Raw: true}, Desc: true},
+		}},
+		{"-slot desc", []clause.OrderByColumn{{Column: clause.Column{Name: "slot", Raw: true}}}},
+	}
+	for _, tt := range tests {
+		got, err := blockQuerySpec.ParseSort(tt.sort)
+		if err != nil {
+			t.Errorf("ParseSort(%q) error = %v", tt.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSort(%q) = %+v, want %+v", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestQuerySpecParseSortRejects(t *testing.T) {
+	for _, sort := range []string{
+		"name",                   // not a field of blocks
+		"description",            // not sortable
+		"code sideways",          // unknown direction
+		"code asc nulls",         // too many words
+		"code; drop table block", // not a field
+		"created_at,password",
+	} {
+		_, err := blockQuerySpec.ParseSort(sort)
+		if apperror.From(err).ErrorCode() != apperror.InvalidSort {
+			t.Errorf("ParseSort(%q) error = %v, want %s", sort, err, apperror.InvalidSort)
+		}
+	}
+}
+
+func TestQuerySpecApplyFilter(t *testing.T) {
+	jan, feb := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		filter string
+		where  string
+		vars   []interface{}
+	}{
+		{"code:eq:A", `WHERE code = $1`, []interface{}{"A"}},
+		{"code:in:A,B", `WHERE code in ($1,$2)`, []interface{}{"A", "B"}},
+		{"slot:range:10,20", `WHERE slot >= $1 AND slot <= $2`, []interface{}{10, 20}},
+		{"slot:in:1,2", `WHERE slot in ($1,$2)`, []interface{}{1, 2}},
+		{"created_at:range:2023-01-01,", `WHERE created_at >= $1`, []interface{}{jan}},
+		{"created_at:range:,2023-02-01T08:30:00Z", `WHERE created_at <= $1`, []interface{}{feb}},
+		{"description:contains:50%_off", `WHERE unaccent(description) ilike unaccent($1)`, []interface{}{`%50\%\_off%`}},
+		{"code:eq:a:b", `WHERE code = $1`, []interface{}{"a:b"}},
+	}
+	for _, tt := range tests {
+		sql, vars, err := blockSQL(t, func(tx *gorm.DB) (*gorm.DB, error) {
+			return blockQuerySpec.ApplyFilter(tx, []string{tt.filter})
+		})
+		if err != nil {
+			t.Errorf("ApplyFilter(%q) error = %v", tt.filter, err)
+			continue
+		}
+		want := `SELECT * FROM "block" ` + tt.where + ` AND "block"."deleted_at" IS NULL`
+		if sql != want {
+			t.Errorf("ApplyFilter(%q) sql = %s, want %s", tt.filter, sql, want)
+		}
+		if !reflect.DeepEqual(vars, tt.vars) {
+			t.Errorf("ApplyFilter(%q) vars = %v, want %v", tt.filter, vars, tt.vars)
+		}
+	}
+}
+
+func TestQuerySpecApplyFilterRejects(t *testing.T) {
+	for _, filter := range []string{
+		"code",                  // no operator
+		"code:eq",               // no value
+		"password:eq:x",         // not a field
+		"code:like:A%",          // unknown operator
+		"slot:contains:1",       // contains on a field that is not text
+		"slot:range:1",          // one bound
+		"slot:range:,",          // no bound
+		"1=1 or code:eq:A",      // not a field
+		"slot:eq:abc",           // not an integer
+		"slot:in:1,x",           // one value is not an integer
+		"created_at:range:foo,", // not a time
+		"created_at:eq:2023-13-01",
+	} {
+		_, err := blockQuerySpec.ApplyFilter(dryRunDB(t), []string{filter})
+		if apperror.From(err).ErrorCode() != apperror.InvalidFilter {
+			t.Errorf("ApplyFilter(%q) error = %v, want %s", filter, err, apperror.InvalidFilter)
+		}
+	}
+}
+
+func TestQueryFieldParse(t *testing.T) {
+	id := uuid.New()
+	blockID := parkingSlotQuerySpec.Fields["block_id"]
+	if v, err := blockID.parse(id.String()); err != nil || v != id {
+		t.Errorf("parse(%s) = %v, %v, want the uuid", id, v, err)
+	}
+	if _, err := blockID.parse("1 or 1=1"); err == nil {
+		t.Error("parse(1 or 1=1) of a uuid field has no error")
+	}
+}
+
+func TestQuerySpecApply(t *testing.T) {
+	sql, vars, err := blockSQL(t, func(tx *gorm.DB) (*gorm.DB, error) {
+		return blockQuerySpec.Apply(tx, "-code", []string{"slot:eq:5"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT * FROM "block" WHERE slot = $1 AND "block"."deleted_at" IS NULL ORDER BY code DESC`
+	if sql != want || !reflect.DeepEqual(vars, []interface{}{5}) {
+		t.Errorf("Apply() = %s %v, want %s [5]", sql, vars, want)
+	}
+
+	sql, _, err = blockSQL(t, func(tx *gorm.DB) (*gorm.DB, error) {
+		return blockQuerySpec.Apply(tx, "", nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM "block" WHERE "block"."deleted_at" IS NULL ORDER BY created_at DESC`; sql != want {
+		t.Errorf("Apply() without sort = %s, want %s", sql, want)
+	}
+}
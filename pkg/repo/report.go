@@ -0,0 +1,180 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+// reportLotScope restricts column, a parking lot id, to the parking lot or the company of the report.
+func reportLotScope(column string, req model.ReportReq) string {
+	if req.ParkingLotID != nil {
+		return column + " = @parking_lot_id"
+	}
+	return column + " in (select id from parking_lot where company_id = @company_id)"
+}
+
+func reportArgs(req model.ReportReq) map[string]interface{} {
+	return map[string]interface{}{
+		"parking_lot_id": valid.String(req.ParkingLotID),
+		"company_id":     valid.String(req.CompanyID),
+		"from":           req.From,
+		"to":             req.To,
+		"granularity":    req.Granularity,
+		"tz":             utils.Location(req.Timezone).String(),
+		"limit":          req.Limit,
+	}
+}
+
+// GetRevenueReport sums ticket totals per local day/week/month, optionally per parking lot or block.
+// Extension tickets carry their own total so they are counted, cancelled tickets are not.
+func (r *RepoPG) GetRevenueReport(ctx context.Context, req model.ReportReq) (res []model.RevenueReportItem, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	groupSelect, groupBy := "", ""
+	switch req.GroupBy {
+	case model.ReportGroupByParkingLot:
+		groupSelect = "pl.id as parking_lot_id, pl.name as parking_lot_name,"
+		groupBy = ", pl.id, pl.name"
+	case model.ReportGroupByBlock:
+		groupSelect = "pl.id as parking_lot_id, pl.name as parking_lot_name, b.id as block_id, b.code as block_code,"
+		groupBy = ", pl.id, pl.name, b.id, b.code"
+	}
+
+	query := fmt.Sprintf(`select
+								date_trunc(@granularity, t.start_time at time zone @tz) at time zone @tz as bucket,
+								%s
+								coalesce(sum(t.total), 0) as revenue,
+								count(*) as ticket_count
+							from ticket t
+							join parking_lot pl on pl.id = t.parking_lot_id
+							left join parking_slot sl on sl.id = t.parking_slot_id
+							left join block b on b.id = sl.block_id
+							where t.deleted_at is null
+								and t.state <> 'cancel'
+								and t.start_time >= @from
+								and t.start_time < @to
+								and %s
+							group by 1 %s
+							order by 1 %s`, groupSelect, reportLotScope("t.parking_lot_id", req), groupBy, groupBy)
+
+	if err := tx.Raw(utils.RemoveSpace(query), reportArgs(req)).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetRevenueReport")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetOccupancyReport compares, per local day/week/month, the booked slot time with the slot time
+// available in the current layout.
+func (r *RepoPG) GetOccupancyReport(ctx context.Context, req model.ReportReq) (res []model.OccupancyReportItem, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`with buckets as (
+								select b as bucket_start, b + ('1 ' || @granularity)::interval as bucket_end
+								from generate_series(
+									date_trunc(@granularity, @from::timestamptz at time zone @tz),
+									@to::timestamptz at time zone @tz - interval '1 microsecond',
+									('1 ' || @granularity)::interval) b
+							), capacity as (
+								select count(*) as slots
+								from parking_slot sl
+								join block bl on bl.id = sl.block_id
+								where sl.deleted_at is null
+									and bl.deleted_at is null
+									and %s
+							)
+							select
+								bk.bucket_start at time zone @tz as bucket,
+								coalesce(sum(extract(epoch from
+									least(t.end_time, bk.bucket_end at time zone @tz) - greatest(t.start_time, bk.bucket_start at time zone @tz))), 0) as booked_seconds,
+								(select slots from capacity) * extract(epoch from
+									(bk.bucket_end at time zone @tz) - (bk.bucket_start at time zone @tz)) as capacity_seconds
+							from buckets bk
+							left join ticket t on t.deleted_at is null
+								and t.state <> 'cancel'
+								and %s
+								and t.start_time < bk.bucket_end at time zone @tz
+								and t.end_time > bk.bucket_start at time zone @tz
+							group by bk.bucket_start, bk.bucket_end
+							order by bk.bucket_start`, reportLotScope("bl.parking_lot_id", req), reportLotScope("t.parking_lot_id", req))
+
+	if err := tx.Raw(utils.RemoveSpace(query), reportArgs(req)).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetOccupancyReport")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetTicketStatsReport counts booked, cancelled, no-show and extended tickets per local day/week/month,
+// extension tickets themselves are left out so the rates are per original booking.
+func (r *RepoPG) GetTicketStatsReport(ctx context.Context, req model.ReportReq) (res []model.TicketStatsReportItem, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`select
+								date_trunc(@granularity, t.start_time at time zone @tz) at time zone @tz as bucket,
+								count(*) as total_tickets,
+								count(*) filter (where t.state = 'cancel') as cancelled_tickets,
+								count(*) filter (where t.state = 'no_show' or (t.state = 'new' and t.entry_time is null and t.end_time < now())) as no_show_tickets,
+								count(*) filter (where t.is_extend) as extended_tickets,
+								coalesce(avg(extract(epoch from t.exit_time - t.entry_time))
+									filter (where t.entry_time is not null and t.exit_time is not null), 0) as avg_stay_seconds
+							from ticket t
+							where t.deleted_at is null
+								and t.state <> 'extend'
+								and t.start_time >= @from
+								and t.start_time < @to
+								and %s
+							group by 1
+							order by 1`, reportLotScope("t.parking_lot_id", req))
+
+	if err := tx.Raw(utils.RemoveSpace(query), reportArgs(req)).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetTicketStatsReport")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetTopTimeFrameReport(ctx context.Context, req model.ReportReq) (res []model.TimeFrameReportItem, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout2Minutes(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`select
+								tf.id as time_frame_id,
+								tf.parking_lot_id,
+								tf.duration,
+								tf.cost,
+								count(t.id) as ticket_count,
+								coalesce(sum(t.total), 0) as revenue
+							from ticket t
+							join time_frame tf on tf.id = t.time_frame_id
+							where t.deleted_at is null
+								and t.state <> 'cancel'
+								and t.start_time >= @from
+								and t.start_time < @to
+								and %s
+							group by tf.id, tf.parking_lot_id, tf.duration, tf.cost
+							order by ticket_count desc, revenue desc
+							limit @limit`, reportLotScope("t.parking_lot_id", req))
+
+	if err := tx.Raw(utils.RemoveSpace(query), reportArgs(req)).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetTopTimeFrameReport")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
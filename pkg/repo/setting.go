@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+)
+
+// GetSetting returns the most specific setting of key for the parking lot, then the company, then the
+// global one, nil when none is stored.
+func (r *RepoPG) GetSetting(ctx context.Context, key string, companyID, parkingLotID *uuid.UUID) (*model.Setting, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	var res []model.Setting
+	err := tx.Model(&model.Setting{}).
+		Where("key = ?", key).
+		Where(`(parking_lot_id = ?
+				or (parking_lot_id is null and company_id = ?)
+				or (parking_lot_id is null and company_id is null))`, parkingLotID, companyID).
+		Order("parking_lot_id nulls last").Order("company_id nulls last").
+		Limit(1).Find(&res).Error
+	if err != nil {
+		log.WithError(err).Error("error_500: failed to GetSetting")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return &res[0], nil
+}
+
+// UpsertSetting stores req in place of the setting with the same key, company and parking lot.
+func (r *RepoPG) UpsertSetting(ctx context.Context, req *model.Setting) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := tx.Model(&model.Setting{}).Where("key = ?", req.Key)
+	if req.CompanyId != nil {
+		query = query.Where("company_id = ?", req.CompanyId)
+	} else {
+		query = query.Where("company_id is null")
+	}
+	if req.ParkingLotId != nil {
+		query = query.Where("parking_lot_id = ?", req.ParkingLotId)
+	} else {
+		query = query.Where("parking_lot_id is null")
+	}
+
+	var old []model.Setting
+	if err := query.Limit(1).Find(&old).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to UpsertSetting")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	if len(old) > 0 {
+		req.ID, req.CreatorID, req.CreatedAt = old[0].ID, old[0].CreatorID, old[0].CreatedAt
+	}
+	if err := tx.Save(req).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to UpsertSetting")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
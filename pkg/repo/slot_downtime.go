@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+// slotDowntimeFilter is the condition, on a parking_slot aliased as column, that the slot is not closed
+// by a downtime overlapping the two bound times (end, start).
+const slotDowntimeFilter = `not exists (select 1 from slot_downtime d
+								where d.deleted_at is null
+									and (d.parking_slot_id = %[1]s.id or d.block_id = %[1]s.block_id)
+									and d.start_time < ?
+									and d.end_time > ?)`
+
+func (r *RepoPG) CreateSlotDowntime(ctx context.Context, req *model.SlotDowntime) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.SlotDowntime{}).Create(&req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateSlotDowntime")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetOneSlotDowntime(ctx context.Context, id uuid.UUID) (res model.SlotDowntime, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Model(&model.SlotDowntime{}).Where("id = ?", id).Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOneSlotDowntime")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetListSlotDowntime(ctx context.Context, req model.ListSlotDowntimeReq) (res model.ListSlotDowntimeRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.SlotDowntime{}).Where("parking_lot_id = ?", valid.String(req.ParkingLotID))
+	if req.BlockID != nil {
+		tx = tx.Where("block_id = ?", valid.String(req.BlockID))
+	}
+	if req.ParkingSlotID != nil {
+		tx = tx.Where("parking_slot_id = ?", valid.String(req.ParkingSlotID))
+	}
+	if req.From != nil {
+		tx = tx.Where("end_time > ?", req.From)
+	}
+	if req.To != nil {
+		tx = tx.Where("start_time < ?", req.To)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("start_time").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListSlotDowntime")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) UpdateSlotDowntime(ctx context.Context, req *model.SlotDowntime) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.SlotDowntime{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when UpdateSlotDowntime")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteSlotDowntime(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("id = ?", id).Delete(&model.SlotDowntime{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteSlotDowntime")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+// slotHoldFilter is the condition, on a parking_slot aliased as column, that the slot is not held by
+// another user than the third bound value over the two bound times (end, start).
+const slotHoldFilter = `not exists (select 1 from slot_hold h
+								where h.deleted_at is null
+									and h.ticket_id is null
+									and h.parking_slot_id = %[1]s.id
+									and h.expires_at > now()
+									and h.start_time < ?
+									and h.end_time > ?
+									and h.user_id is distinct from ?)`
+
+func (r *RepoPG) CreateSlotHold(ctx context.Context, req *model.SlotHold) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateSlotHold")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetOneSlotHold(ctx context.Context, id uuid.UUID) (res model.SlotHold, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Model(&model.SlotHold{}).Where("id = ?", id).Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOneSlotHold")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// BookSlotHold turns the hold into ticketID if it is still open, SLOT_HOLD_EXPIRED when it expired or was
+// already booked.
+func (r *RepoPG) BookSlotHold(ctx context.Context, id, ticketID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Model(&model.SlotHold{}).
+		Where("id = ? and ticket_id is null and expires_at > now()", id).
+		Update("ticket_id", ticketID)
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to BookSlotHold")
+		return apperror.Wrap(apperror.Internal, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperror.New(apperror.SlotHoldExpired)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteSlotHold(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("id = ?", id).Delete(&model.SlotHold{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteSlotHold")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// ReleaseUserSlotHold drops the holds of a user in a parking lot that did not become a ticket.
+func (r *RepoPG) ReleaseUserSlotHold(ctx context.Context, userID, parkingLotID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("user_id = ? and parking_lot_id = ? and ticket_id is null", userID, parkingLotID).
+		Delete(&model.SlotHold{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when ReleaseUserSlotHold")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// GetSlotHoldConflict returns an active hold of another user than holderID on the slot overlapping start
+// to end, nil when there is none.
+func (r *RepoPG) GetSlotHoldConflict(ctx context.Context, slotID uuid.UUID, start, end time.Time, holderID *uuid.UUID) (*model.SlotHold, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	var res []model.SlotHold
+	if err := tx.Model(&model.SlotHold{}).
+		Where("parking_slot_id = ? and ticket_id is null and expires_at > now()", slotID).
+		Where("start_time < ? and end_time > ?", end, start).
+		Where("user_id is distinct from ?", holderID).
+		Limit(1).Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetSlotHoldConflict")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return &res[0], nil
+}
+
+// DeleteExpiredSlotHold drops the holds that expired without becoming a ticket.
+func (r *RepoPG) DeleteExpiredSlotHold(ctx context.Context) (int64, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Where("ticket_id is null and expires_at <= now()").Delete(&model.SlotHold{})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: error when DeleteExpiredSlotHold")
+		return 0, apperror.Wrap(apperror.Internal, res.Error)
+	}
+	return res.RowsAffected, nil
+}
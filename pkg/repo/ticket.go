@@ -3,12 +3,12 @@ package repo
 import (
 	"context"
 	"errors"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
+	"time"
 )
 
 func (r *RepoPG) CreateTicket(ctx context.Context, ticket *model.Ticket, tx *gorm.DB) error {
@@ -20,7 +20,7 @@ func (r *RepoPG) CreateTicket(ctx context.Context, ticket *model.Ticket, tx *gor
 	}
 	if err := tx.Model(&model.Ticket{}).Create(&ticket).Error; err != nil {
 		log.WithError(err).Error("Error when create ticket - CreateTicket - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when create ticket: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -31,11 +31,11 @@ func (r *RepoPG) UpdateTicket(ctx context.Context, ticket *model.Ticket, tx *gor
 		defer cancel()
 	}
 	if err := tx.Model(&model.Ticket{}).Where("id = ?", ticket.ID).Updates(&ticket).Error; err != nil {
-		return ginext.NewError(http.StatusInternalServerError, "Error when update ticket: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
-func (r *RepoPG) GetAllTicket(ctx context.Context, req model.GetListTicketParam, tx *gorm.DB) (res []model.Ticket, err error) {
+func (r *RepoPG) GetAllTicket(ctx context.Context, req model.GetListTicketParam, tx *gorm.DB) (res model.ListTicketRes, err error) {
 	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
 	var cancel context.CancelFunc
 	if tx == nil {
@@ -46,15 +46,18 @@ func (r *RepoPG) GetAllTicket(ctx context.Context, req model.GetListTicketParam,
 	if req.State != nil {
 		tx = tx.Where("state = ?", req.State)
 	}
-	if err := tx.Where("user_id = ?", req.UserId).Preload("Vehicle").Preload("ParkingLot").
+	tx = tx.Where("user_id = ?", req.UserId).Preload("Vehicle").Preload("ParkingLot").
 		Preload("ParkingSlot", func(db *gorm.DB) *gorm.DB {
 			return db.Unscoped()
 		}).Preload("ParkingSlot.Block", func(db *gorm.DB) *gorm.DB {
 		return db.Unscoped()
-	}).Preload("TimeFrame").
-		Find(&res).Error; err != nil {
+	}).Preload("TimeFrame")
+
+	if res.Data, res.Meta, err = FindCursorPage(r, tx, "", req.Cursor, r.GetPageSize(req.PageSize), func(t model.Ticket) model.BaseModel {
+		return t.BaseModel
+	}); err != nil {
 		log.WithError(err).Error("Error when get all ticket - GetAllTicket - RepoPG")
-		return nil, ginext.NewError(http.StatusInternalServerError, "Error when get all ticket: "+err.Error())
+		return res, err
 	}
 	return res, nil
 }
@@ -69,10 +72,10 @@ func (r *RepoPG) GetOneTicket(ctx context.Context, id string, tx *gorm.DB) (mode
 	if err := tx.Model(&model.Ticket{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneTicket")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -93,10 +96,32 @@ func (r *RepoPG) GetOneTicketWithExtend(ctx context.Context, id string, tx *gorm
 		Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneTicket")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetOriginTicketByExtend returns the ticket that the extension ticket idExtend extends.
+func (r *RepoPG) GetOriginTicketByExtend(ctx context.Context, idExtend string, tx *gorm.DB) (model.Ticket, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+	var cancel context.CancelFunc
+	if tx == nil {
+		tx, cancel = r.DBWithTimeout(ctx)
+		defer cancel()
+	}
+	var res model.Ticket
+	if err := tx.Model(&model.Ticket{}).
+		Where("id = (select te.ticket_id from ticket_extend te where te.ticket_extend_id = ? and te.deleted_at is null)", idExtend).
+		Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOriginTicketByExtend")
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -116,7 +141,7 @@ func (r *RepoPG) GetListExtendTicketByOrigin(ctx context.Context, idParent strin
               order by t.start_time asc`
 	if err := tx.Raw(query, idParent).Scan(&res).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetOneTicket")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -130,12 +155,12 @@ func (r *RepoPG) CreateLongTermTicket(ctx context.Context, ltTicket *model.LongT
 	}
 	if err := tx.Model(&model.LongTermTicket{}).Create(&ltTicket).Error; err != nil {
 		log.WithError(err).Error("Error when create long term ticket - CreateLongTermTicket - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when create long term ticket: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
 
-func (r *RepoPG) GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (res []model.GetListTicketRes, err error) {
+func (r *RepoPG) GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (res model.ListTicketCompanyRes, err error) {
 	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
 
 	tx, cancel := r.DBWithTimeout(ctx)
@@ -146,14 +171,74 @@ func (r *RepoPG) GetAllTicketCompany(ctx context.Context, req model.GetListTicke
 		tx = tx.Where("state = ?", req.State)
 	}
 
-	if err := tx.Where("parking_lot_id = ?", req.ParkingLotID).Preload("Vehicle").Preload("ParkingLot").
+	tx = tx.Where("parking_lot_id = ?", req.ParkingLotID).Preload("Vehicle").Preload("ParkingLot").
 		Preload("ParkingSlot", func(db *gorm.DB) *gorm.DB {
 			return db.Unscoped()
 		}).Preload("ParkingSlot.Block", func(db *gorm.DB) *gorm.DB {
 		return db.Unscoped()
-	}).Find(&res).Error; err != nil {
-		log.WithError(err).Error("Error when get all ticket - GetAllTicket - RepoPG")
-		return nil, ginext.NewError(http.StatusInternalServerError, "Error when get all ticket: "+err.Error())
+	})
+
+	if res.Data, res.Meta, err = FindCursorPage(r, tx, "", req.Cursor, r.GetPageSize(req.PageSize), func(t model.GetListTicketRes) model.BaseModel {
+		return model.BaseModel{ID: t.ID, CreatedAt: t.CreatedAt}
+	}); err != nil {
+		log.WithError(err).Error("Error when get all ticket - GetAllTicketCompany - RepoPG")
+		return res, err
+	}
+	return res, nil
+}
+
+// GetUnfinishedTicket returns the tickets in scope that are not over yet, ordered by start time.
+func (r *RepoPG) GetUnfinishedTicket(ctx context.Context, scope model.TicketScope) (res []model.Ticket, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.Ticket{}).
+		Where("state in ?", []string{"new", "extend", "ongoing"}).
+		Where("end_time > now()")
+	if scope.ParkingLotID != nil {
+		tx = tx.Where("parking_lot_id = ?", scope.ParkingLotID)
+	}
+	if scope.BlockID != nil {
+		tx = tx.Where("parking_slot_id in (?)", r.db.Model(&model.ParkingSlot{}).Select("id").Where("block_id = ?", scope.BlockID))
+	}
+	if len(scope.ParkingSlotIDs) > 0 {
+		tx = tx.Where("parking_slot_id in ?", scope.ParkingSlotIDs)
+	}
+	if scope.Start != nil && scope.End != nil {
+		tx = tx.Where("start_time < ? and end_time > ?", scope.End, scope.Start)
+	}
+
+	if err := tx.Order("start_time").Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetUnfinishedTicket")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// MarkNoShowTicket moves the tickets that started before startedBefore without a check in, and their
+// extensions, to the no_show state so their slots can be booked again. It returns the updated tickets.
+func (r *RepoPG) MarkNoShowTicket(ctx context.Context, startedBefore time.Time) (res []model.Ticket, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := utils.RemoveSpace(`update ticket set state = 'no_show', updated_at = now()
+								where deleted_at is null
+									and entry_time is null
+									and ((state = 'new' and start_time <= @before)
+										or (state = 'extend' and id in (select te.ticket_extend_id from ticket_extend te
+																		join ticket o on o.id = te.ticket_id
+																		where te.deleted_at is null
+																			and o.state = 'new'
+																			and o.entry_time is null
+																			and o.start_time <= @before)))
+								returning *`)
+	if err := tx.Raw(query, map[string]interface{}{"before": startedBefore}).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to MarkNoShowTicket")
+		return nil, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
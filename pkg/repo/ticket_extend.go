@@ -2,10 +2,9 @@ package repo
 
 import (
 	"context"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 )
@@ -19,7 +18,7 @@ func (r *RepoPG) CreateTicketExtend(ctx context.Context, req *model.TicketExtend
 	}
 	if err := tx.Model(&model.TicketExtend{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("Error when create extend ticket: " + err.Error())
-		return ginext.NewError(http.StatusInternalServerError, "Error when create extend ticket: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -4,10 +4,9 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 )
@@ -22,7 +21,7 @@ func (r *RepoPG) GetAllTimeFrame(ctx context.Context, req model.GetListTimeFrame
 	res := &model.ListTimeFrame{}
 	if err := tx.Model(&model.TimeFrame{}).Where("parking_lot_id = ? ", req.ParkingLotId).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("Error when get all time frame - GetAllTimeFrame - RepoPG")
-		return nil, ginext.NewError(http.StatusInternalServerError, "Error when get all time frame: "+err.Error())
+		return nil, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -35,7 +34,7 @@ func (r *RepoPG) CreateMultiTimeFrame(ctx context.Context, timeFrame []model.Tim
 	}
 	if err := tx.Model(&model.TimeFrame{}).Create(&timeFrame).Error; err != nil {
 		log.WithError(err).Error("Error when create time frame - CreateMultiTimeFrame - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when create time frame: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -49,7 +48,7 @@ func (r *RepoPG) DeleteTimeFrameByParkingLotID(ctx context.Context, parkingLotID
 
 	if err := tx.Where("parking_lot_id = ?", parkingLotID).Delete(&model.TimeFrame{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when delete time frame - DeleteTimeFrameByParkingLotID -RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -62,7 +61,7 @@ func (r *RepoPG) CreateTimeframe(ctx context.Context, req *model.TimeFrame) erro
 
 	if err := tx.Model(&model.TimeFrame{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateTimeFrame")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -76,10 +75,10 @@ func (r *RepoPG) GetOneTimeframe(ctx context.Context, id uuid.UUID) (res model.T
 	if err = tx.Model(&model.TimeFrame{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneTimeFrame")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -92,7 +91,7 @@ func (r *RepoPG) UpdateTimeframe(ctx context.Context, req *model.TimeFrame) erro
 
 	if err := tx.Model(&model.TimeFrame{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when UpdateTimeFrame")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -105,7 +104,7 @@ func (r *RepoPG) DeleteTimeframe(ctx context.Context, id uuid.UUID) error {
 
 	if err := tx.Where("id = ?", id).Delete(&model.TimeFrame{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when DeleteTimeFrame")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -3,10 +3,9 @@ package repo
 import (
 	"context"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 )
@@ -23,10 +22,10 @@ func (r *RepoPG) GetOneUserById(ctx context.Context, id uuid.UUID, tx *gorm.DB)
 	if err := tx.Model(&model.User{}).Where("id = ?", id.String()).Take(&rs).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.WithError(err).Error("Not found user by id: " + id.String())
-			return nil, ginext.NewError(http.StatusInternalServerError, "Not found user by id: "+id.String())
+			return nil, apperror.Wrap(apperror.NotFound, err)
 		} else {
 			log.WithError(err).Error("Error when get one user by id")
-			return nil, ginext.NewError(http.StatusInternalServerError, "Error when get one user by id")
+			return nil, apperror.Wrap(apperror.Internal, err)
 		}
 	}
 	return rs, nil
@@ -46,7 +45,7 @@ func (r *RepoPG) GetOneUserByPhone(ctx context.Context, phoneNumber string, tx *
 			return nil, err
 		} else {
 			log.WithError(err).Error("Error when get one user by phone number")
-			return nil, ginext.NewError(http.StatusInternalServerError, "Error when get one user by phone number")
+			return nil, apperror.Wrap(apperror.Internal, err)
 		}
 	}
 	return rs, nil
@@ -61,7 +60,7 @@ func (r *RepoPG) CreateUser(ctx context.Context, user *model.User, tx *gorm.DB)
 
 	if err := tx.Model(&model.User{}).Create(&user).Error; err != nil {
 		log.WithError(err).Error("Error when create user")
-		return ginext.NewError(http.StatusInternalServerError, "Error when create user: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -73,7 +72,7 @@ func (r *RepoPG) UpdateUser(ctx context.Context, req *model.User, tx *gorm.DB) e
 	}
 
 	if err := tx.Model(&model.User{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -87,7 +86,7 @@ func (r *RepoPG) DeleteUser(ctx context.Context, id string, tx *gorm.DB) error {
 	}
 	if err := tx.Where("id = ? ", id).Delete(&model.User{}).Error; err != nil {
 		log.WithError(err).Error("Error when delete user - DeleteUser - RepoPG")
-		return ginext.NewError(http.StatusInternalServerError, "Error when delete user: "+err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -4,10 +4,9 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
@@ -21,7 +20,7 @@ func (r *RepoPG) CreateVehicle(ctx context.Context, req *model.Vehicle) error {
 
 	if err := tx.Model(&model.Vehicle{}).Create(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when CreateVehicle")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -35,10 +34,10 @@ func (r *RepoPG) GetOneVehicle(ctx context.Context, id uuid.UUID) (res model.Veh
 	if err = tx.Model(&model.Vehicle{}).Where("id = ?", id).Take(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.WithError(err).Error("error_404: not found")
-			return res, ginext.NewError(http.StatusNotFound, err.Error())
+			return res, apperror.Wrap(apperror.NotFound, err)
 		}
 		log.WithError(err).Error("error_500: failed to GetOneVehicle")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 	return res, nil
 }
@@ -59,24 +58,42 @@ func (r *RepoPG) GetListVehicle(ctx context.Context, req model.ListVehicleReq) (
 		tx = tx.Where("user_id = ?", valid.String(req.UserID))
 	}
 
-	if req.Sort != "" {
-		tx = tx.Order(req.Sort)
-	} else {
-		tx = tx.Order("created_at desc")
+	if tx, err = vehicleQuerySpec.ApplyFilter(tx, req.Filter); err != nil {
+		log.WithError(err).Error("error_400: invalid filter")
+		return res, err
 	}
 
 	var total int64 = 0
 	page := r.GetPage(req.Page)
 	pageSize := r.GetPageSize(req.PageSize)
 
+	// keyset pagination skips the COUNT but only supports the default order
+	if req.Cursor != nil {
+		if req.Sort != "" {
+			return res, apperror.New(apperror.CursorSortUnsupported)
+		}
+		if res.Data, res.Meta, err = FindCursorPage(r, tx, "", valid.String(req.Cursor), pageSize, func(v model.Vehicle) model.BaseModel {
+			return v.BaseModel
+		}); err != nil {
+			log.WithError(err).Error("error_500: failed to get cursor page")
+			return res, err
+		}
+		return res, nil
+	}
+
+	if tx, err = vehicleQuerySpec.ApplySort(tx, req.Sort); err != nil {
+		log.WithError(err).Error("error_400: invalid sort")
+		return res, err
+	}
+
 	if err := tx.Count(&total).Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
 		log.WithError(err).Error("error_500: failed to GetListVehicle")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
 		log.WithError(err).Error("error_500: failed to get pagination")
-		return res, ginext.NewError(http.StatusInternalServerError, err.Error())
+		return res, apperror.Wrap(apperror.Internal, err)
 	}
 
 	return res, nil
@@ -90,7 +107,7 @@ func (r *RepoPG) UpdateVehicle(ctx context.Context, req *model.Vehicle) error {
 
 	if err := tx.Model(&model.Vehicle{}).Where("id = ?", req.ID).Save(&req).Error; err != nil {
 		log.WithError(err).Error("error_500: error when UpdateVehicle")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
@@ -103,7 +120,7 @@ func (r *RepoPG) DeleteVehicle(ctx context.Context, id uuid.UUID) error {
 
 	if err := tx.Where("id = ?", id).Delete(&model.Vehicle{}).Error; err != nil {
 		log.WithError(err).Error("error_500: error when DeleteVehicle")
-		return ginext.NewError(http.StatusInternalServerError, err.Error())
+		return apperror.Wrap(apperror.Internal, err)
 	}
 	return nil
 }
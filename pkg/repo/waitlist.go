@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+)
+
+// waitlistOfferFilter is the condition, on a parking_slot aliased as column, that the slot is not offered
+// to a waiting user over the two bound times (end, start).
+const waitlistOfferFilter = `not exists (select 1 from waitlist w
+									where w.deleted_at is null
+										and w.state = 'offered'
+										and w.parking_slot_id = %[1]s.id
+										and w.offer_expires_at > now()
+										and w.start_time < ?
+										and w.end_time > ?)`
+
+func (r *RepoPG) CreateWaitlist(ctx context.Context, req *model.Waitlist) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateWaitlist")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetOneWaitlist(ctx context.Context, id uuid.UUID) (res model.Waitlist, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Model(&model.Waitlist{}).Where("id = ?", id).Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOneWaitlist")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetListWaitlist(ctx context.Context, req model.ListWaitlistReq) (res model.ListWaitlistRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.Waitlist{}).Where("user_id = ?", req.UserId)
+	if req.State != nil {
+		tx = tx.Where("state = ?", req.State)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListWaitlist")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) UpdateWaitlist(ctx context.Context, req *model.Waitlist) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Save(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when UpdateWaitlist")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// BookWaitlistOffer marks the entry booked with ticketID if its offer is still open, so an offer expired or
+// cancelled meanwhile is never booked.
+func (r *RepoPG) BookWaitlistOffer(ctx context.Context, id, ticketID uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	res := tx.Model(&model.Waitlist{}).
+		Where("id = ? and state = ? and offer_expires_at > now()", id, model.WaitlistStateOffered).
+		Updates(map[string]interface{}{"state": model.WaitlistStateBooked, "ticket_id": ticketID})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("error_500: failed to BookWaitlistOffer")
+		return apperror.Wrap(apperror.Internal, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return apperror.New(apperror.WaitlistOfferGone)
+	}
+	return nil
+}
+
+// GetWaitingWaitlist returns the waiting entries of a parking lot, oldest first.
+func (r *RepoPG) GetWaitingWaitlist(ctx context.Context, parkingLotID uuid.UUID) (res []model.Waitlist, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.Waitlist{}).
+		Where("parking_lot_id = ? and state = ? and start_time > now()", parkingLotID, model.WaitlistStateWaiting).
+		Order("created_at").Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetWaitingWaitlist")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// GetWaitlistParkingLotIDs returns the parking lots that have waiting entries.
+func (r *RepoPG) GetWaitlistParkingLotIDs(ctx context.Context) (res []uuid.UUID, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Model(&model.Waitlist{}).Distinct("parking_lot_id").
+		Where("state = ? and start_time > now()", model.WaitlistStateWaiting).
+		Pluck("parking_lot_id", &res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetWaitlistParkingLotIDs")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// ExpireWaitlist closes the offers that were not taken in time and the entries whose interval has started,
+// it returns the closed entries.
+func (r *RepoPG) ExpireWaitlist(ctx context.Context) (res []model.Waitlist, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := utils.RemoveSpace(`update waitlist set state = ?, updated_at = now()
+								where deleted_at is null
+									and ((state = ? and offer_expires_at <= now())
+										or (state in (?, ?) and start_time <= now()))
+								returning *`)
+	if err := tx.Raw(query, model.WaitlistStateExpired, model.WaitlistStateOffered,
+		model.WaitlistStateWaiting, model.WaitlistStateOffered).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to ExpireWaitlist")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
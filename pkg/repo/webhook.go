@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+func (r *RepoPG) CreateWebhookSubscription(ctx context.Context, req *model.WebhookSubscription) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Create(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateWebhookSubscription")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) GetOneWebhookSubscription(ctx context.Context, id uuid.UUID) (res model.WebhookSubscription, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Model(&model.WebhookSubscription{}).Where("id = ?", id).Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOneWebhookSubscription")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetListWebhookSubscription(ctx context.Context, companyID uuid.UUID) (res []model.WebhookSubscription, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("company_id = ?", companyID).Order("created_at").Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListWebhookSubscription")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) UpdateWebhookSubscription(ctx context.Context, req *model.WebhookSubscription) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Save(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when UpdateWebhookSubscription")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+func (r *RepoPG) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("id = ?", id).Delete(&model.WebhookSubscription{}).Error; err != nil {
+		log.WithError(err).Error("error_500: error when DeleteWebhookSubscription")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// GetWebhookSubscriptionsForEvent returns the active subscriptions of the company to eventType, a
+// subscription without event types receives every event.
+func (r *RepoPG) GetWebhookSubscriptionsForEvent(ctx context.Context, companyID uuid.UUID, eventType string) (res []model.WebhookSubscription, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Where("company_id = ? and active and (cardinality(event_types) = 0 or ? = any(event_types))", companyID, eventType).
+		Find(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetWebhookSubscriptionsForEvent")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+// CreateWebhookDeliveries queues the deliveries, the ones already queued for the same event and
+// subscription are left as they are.
+func (r *RepoPG) CreateWebhookDeliveries(ctx context.Context, req []model.WebhookDelivery) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if len(req) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when CreateWebhookDeliveries")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
+
+// ClaimDueWebhookDeliveries returns the pending deliveries due and postpones them by lease, so another
+// worker does not send them again while they are in flight.
+func (r *RepoPG) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (res []model.WebhookDelivery, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	query := utils.RemoveSpace(`update webhook_delivery set next_attempt_at = @lease_until
+								where id in (select id from webhook_delivery
+												where deleted_at is null
+													and status = @status
+													and next_attempt_at <= now()
+												order by next_attempt_at
+												limit @limit
+												for update skip locked)
+								returning *`)
+	args := map[string]interface{}{
+		"lease_until": time.Now().Add(lease),
+		"status":      model.WebhookDeliveryPending,
+		"limit":       limit,
+	}
+	if err := tx.Raw(query, args).Scan(&res).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to ClaimDueWebhookDeliveries")
+		return nil, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetOneWebhookDelivery(ctx context.Context, id uuid.UUID) (res model.WebhookDelivery, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err = tx.Model(&model.WebhookDelivery{}).Where("id = ?", id).Take(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithError(err).Error("error_404: not found")
+			return res, apperror.Wrap(apperror.NotFound, err)
+		}
+		log.WithError(err).Error("error_500: failed to GetOneWebhookDelivery")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) GetListWebhookDelivery(ctx context.Context, req model.ListWebhookDeliveryReq) (res model.ListWebhookDeliveryRes, err error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	tx = tx.Model(&model.WebhookDelivery{}).Where("subscription_id = ?", req.SubscriptionId)
+	if req.Status != nil {
+		tx = tx.Where("status = ?", req.Status)
+	}
+
+	var total int64 = 0
+	page := r.GetPage(req.Page)
+	pageSize := r.GetPageSize(req.PageSize)
+
+	if err := tx.Count(&total).Order("created_at desc").Limit(pageSize).Offset(r.GetOffset(page, pageSize)).Find(&res.Data).Error; err != nil {
+		log.WithError(err).Error("error_500: failed to GetListWebhookDelivery")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	if res.Meta, err = r.GetPaginationInfo("", nil, int(total), page, pageSize); err != nil {
+		log.WithError(err).Error("error_500: failed to get pagination")
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+	return res, nil
+}
+
+func (r *RepoPG) UpdateWebhookDelivery(ctx context.Context, req *model.WebhookDelivery) error {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(r, 0))
+
+	tx, cancel := r.DBWithTimeout(ctx)
+	defer cancel()
+
+	if err := tx.Save(req).Error; err != nil {
+		log.WithError(err).Error("error_500: error when UpdateWebhookDelivery")
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"context"
 	"fmt"
 	"github.com/caarlos0/env/v6"
 	"github.com/gin-contrib/cors"
@@ -8,11 +9,25 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	swagger "github.com/swaggo/gin-swagger"
 	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
 	"gitlab.com/goxp/cloud0/service"
+	"gorm.io/gorm"
 	"parkar-server/conf"
+	"parkar-server/migrations"
+	"parkar-server/pkg/audit"
+	"parkar-server/pkg/broker"
 	"parkar-server/pkg/handlers"
+	"parkar-server/pkg/midleware"
+	"parkar-server/pkg/migration"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/ratelimit"
 	"parkar-server/pkg/repo"
 	service2 "parkar-server/pkg/service"
+	"parkar-server/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type extraSetting struct {
@@ -21,18 +36,26 @@ type extraSetting struct {
 
 type Service struct {
 	*service.BaseApp
-	setting *extraSetting
+	setting       *extraSetting
+	searchService service2.SearchServiceInterface
+	waitlist      service2.WaitlistInterface
+	notification  service2.NotificationInterface
+	webhook       service2.WebhookInterface
+	events        *service2.EventBus
+	idempotency   *midleware.Idempotency
+	admin         service2.AdminInterface
+	db            *gorm.DB
 }
 
 func NewService() *Service {
 	s := &Service{
-		service.NewApp("Parkar", "v1.0"),
-		&extraSetting{},
+		BaseApp: service.NewApp("Parkar", "v1.0"),
+		setting: &extraSetting{},
 	}
 	// repo
 	_ = env.Parse(s.setting)
 	s.Config.DB.DSN = fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s connect_timeout=5",
+		"host=%s port=%s user=%s dbname=%s password=%s connect_timeout=5 TimeZone=UTC",
 		conf.GetConfig().DBHost,
 		conf.GetConfig().DBPort,
 		conf.GetConfig().DBUser,
@@ -43,24 +66,75 @@ func NewService() *Service {
 	if s.setting.DbDebugEnable {
 		db = db.Debug()
 	}
+	s.db = db
+	if err := audit.Register(db); err != nil {
+		logger.Tag("NewService").WithError(err).Error("Failed to register audit log callbacks")
+	}
 	repoPG := repo.NewPGRepo(db)
+	var repoES repo.ESInterface
+	if conf.GetConfig().EnableES == "true" {
+		esClient, err := utils.CreateESClient(conf.GetConfig().ESAddress)
+		if err != nil {
+			logger.Tag("NewService").WithError(err).Error("Failed to create Elasticsearch client, search falls back to Postgres")
+		} else {
+			repoES = repo.NewESRepo(esClient, conf.GetConfig().ESIndex)
+			if err := repoES.EnsureParkingLotIndex(context.Background()); err != nil {
+				logger.Tag("NewService").WithError(err).Error("Failed to create parking lot index")
+			}
+		}
+	}
+
+	providers, err := notification.NewProviders(conf.GetConfig().NotificationProvider, conf.GetConfig().NotificationFile)
+	if err != nil {
+		logger.Tag("NewService").WithError(err).Error("Failed to create notification providers, notifications are only logged")
+		providers, _ = notification.NewProviders(notification.ProviderLog, "")
+	}
 
 	//service
+	notificationService := service2.NewNotificationService(repoPG, providers)
+	s.notification = notificationService
 	authService := service2.NewAuthService(repoPG)
 	favoriteService := service2.NewFavoriteService(repoPG)
-	lotService := service2.NewParkingLotService(repoPG)
-	blockService := service2.NewBlockService(repoPG)
-	slotService := service2.NewParkingSlotService(repoPG)
+	searchService := service2.NewSearchService(repoPG, repoES)
+	s.searchService = searchService
+	lotService := service2.NewParkingLotService(repoPG, searchService, notificationService)
+	blockService := service2.NewBlockService(repoPG, notificationService)
+	slotService := service2.NewParkingSlotService(repoPG, notificationService)
 	vehicleService := service2.NewVehicleService(repoPG)
 	userService := service2.NewUserService(repoPG)
 	timeFrameService := service2.NewTimeFrameService(repoPG)
-	ticketService := service2.NewTicketService(repoPG)
-	companyService := service2.NewCompanyService(repoPG)
+	ticketService := service2.NewTicketService(repoPG, notificationService)
+	companyService := service2.NewCompanyService(repoPG, notificationService, conf.GetConfig().CompanyVerifyURL)
+	reportService := service2.NewReportService(repoPG)
+	exportService := service2.NewExportService(repoPG, reportService)
+	layoutService := service2.NewLayoutService(repoPG)
+	downtimeService := service2.NewSlotDowntimeService(repoPG, notificationService)
+	settingService := service2.NewSettingService(repoPG)
+	waitlistService := service2.NewWaitlistService(repoPG, notificationService)
+	holdService := service2.NewSlotHoldService(repoPG)
+	auditService := service2.NewAuditService(repoPG)
+	adminService := service2.NewAdminService(repoPG, searchService, notificationService)
+	s.admin = adminService
+	s.waitlist = waitlistService
+	webhookService := service2.NewWebhookService(repoPG)
+	s.webhook = webhookService
+	s.idempotency = midleware.NewIdempotency(repoPG)
+
+	// event bus
+	s.events = service2.NewEventBus(repoPG)
+	s.events.Subscribe("webhook", webhookService, model.TicketEventTypes...)
+	eventBroker, err := broker.NewBroker(conf.GetConfig().EventBroker, conf.GetConfig().EventBrokerURL)
+	if err != nil {
+		logger.Tag("NewService").WithError(err).Error("Failed to create event broker, events are not published outside")
+	} else if eventBroker != nil {
+		s.events.Subscribe("broker", service2.NewBrokerPublisher(eventBroker))
+	}
 
 	//handler
 	authHandler := handlers.NewAuthHandler(authService)
 	favoriteHandler := handlers.NewFavoriteHandler(favoriteService)
 	lotHandler := handlers.NewParkingLotHandler(lotService)
+	searchHandler := handlers.NewSearchHandler(searchService)
 	blockHandler := handlers.NewBlockHandler(blockService)
 	slotHandler := handlers.NewParkingSlotHandler(slotService)
 	vehicleHandler := handlers.NewVehicleHandler(vehicleService)
@@ -68,13 +142,24 @@ func NewService() *Service {
 	timeFrameHandler := handlers.NewTimeFrameHandler(timeFrameService)
 	ticketHandler := handlers.NewTicketHandler(ticketService)
 	companyHanler := handlers.NewCompanyHandler(companyService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	layoutHandler := handlers.NewLayoutHandler(layoutService)
+	downtimeHandler := handlers.NewSlotDowntimeHandler(downtimeService)
+	settingHandler := handlers.NewSettingHandler(settingService)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService)
+	holdHandler := handlers.NewSlotHoldHandler(holdService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(adminService)
 
 	route := s.Router
 	route.Use(func() gin.HandlerFunc {
 		return func(c *gin.Context) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key, Accept-Language")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 			if c.Request.Method == "OPTIONS" {
 				c.AbortWithStatus(204)
@@ -83,24 +168,63 @@ func NewService() *Service {
 			c.Next()
 		}
 	}(),
+		midleware.LocalizeErrors(),
 	)
 
 	v1Api := s.Router.Group("/api/v1")
 	merchantApi := s.Router.Group("/api/merchant")
+	adminApi := s.Router.Group("/api/admin")
+	v1Api.Use(midleware.BearerUser(), midleware.Audit(model.AuditActorUser))
+	merchantApi.Use(midleware.Audit(model.AuditActorMerchant))
+	adminApi.Use(midleware.RequireRole(repoPG, utils.ADMIN_ROLE), midleware.Audit(model.AuditActorAdmin))
 	swaggerApi := s.Router.Group("/")
 
 	// swagger
 	swaggerApi.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler))
 
-	// auth
-	v1Api.POST("/user/login", ginext.WrapHandler(authHandler.Login))
-	v1Api.POST("/user/reset-password", ginext.WrapHandler(authHandler.ResetPassword))
+	// auth, limited per IP and per account against brute force and account enumeration
+	limits := ratelimit.NewMemoryStore()
+	loginLockout := &ratelimit.Lockout{Threshold: 5, Base: time.Minute, Max: time.Hour}
+	loginLimit := midleware.RateLimit(limits, midleware.RateLimitPolicy{
+		Name:         "user-login",
+		PerIP:        ratelimit.Limit{Burst: 20, Period: time.Minute},
+		PerAccount:   ratelimit.Limit{Burst: 10, Period: time.Minute},
+		AccountField: "user_name",
+		Lockout:      loginLockout,
+	})
+	resetPasswordLimit := midleware.RateLimit(limits, midleware.RateLimitPolicy{
+		Name:         "user-reset-password",
+		PerIP:        ratelimit.Limit{Burst: 5, Period: 15 * time.Minute},
+		PerAccount:   ratelimit.Limit{Burst: 3, Period: 15 * time.Minute},
+		AccountField: "user_name",
+	})
+	checkPhoneLimit := midleware.RateLimit(limits, midleware.RateLimitPolicy{
+		Name:         "user-check-phone",
+		PerIP:        ratelimit.Limit{Burst: 10, Period: time.Minute},
+		PerAccount:   ratelimit.Limit{Burst: 5, Period: time.Minute},
+		AccountField: "phone_number",
+	})
+	companyLoginLimit := midleware.RateLimit(limits, midleware.RateLimitPolicy{
+		Name:         "company-login",
+		PerIP:        ratelimit.Limit{Burst: 20, Period: time.Minute},
+		PerAccount:   ratelimit.Limit{Burst: 10, Period: time.Minute},
+		AccountField: "email",
+		Lockout:      loginLockout,
+	})
+	resendVerificationLimit := midleware.RateLimit(limits, midleware.RateLimitPolicy{
+		Name:         "company-resend-verification",
+		PerIP:        ratelimit.Limit{Burst: 5, Period: 15 * time.Minute},
+		PerAccount:   ratelimit.Limit{Burst: 3, Period: 15 * time.Minute},
+		AccountField: "email",
+	})
+	v1Api.POST("/user/login", loginLimit, ginext.WrapHandler(authHandler.Login))
+	v1Api.POST("/user/reset-password", resetPasswordLimit, ginext.WrapHandler(authHandler.ResetPassword))
 	//v1Api.POST("/user/create", ginext.WrapHandler(userHandler.))
 
 	//user
 	v1Api.GET("/user/:id", ginext.WrapHandler(userHandler.GetOneUserById))
 	v1Api.POST("/user/create", ginext.WrapHandler(userHandler.CreateUser))
-	v1Api.POST("/user/check-phone", ginext.WrapHandler(userHandler.CheckDuplicatePhone))
+	v1Api.POST("/user/check-phone", checkPhoneLimit, ginext.WrapHandler(userHandler.CheckDuplicatePhone))
 	v1Api.PUT("/user/update/:id", ginext.WrapHandler(userHandler.UpdateUser))
 	v1Api.DELETE("/user/:id", ginext.WrapHandler(userHandler.DeleteUser))
 
@@ -124,6 +248,7 @@ func NewService() *Service {
 	v1Api.POST("/parking-lot/create", ginext.WrapHandler(lotHandler.CreateParkingLot))
 	v1Api.GET("/parking-lot/get-one/:id", ginext.WrapHandler(lotHandler.GetOneParkingLot))
 	v1Api.GET("/parking-lot/get-list", ginext.WrapHandler(lotHandler.GetListParkingLot))
+	v1Api.GET("/parking-lot/search", ginext.WrapHandler(searchHandler.SearchParkingLot))
 	v1Api.PUT("/parking-lot/update/:id", ginext.WrapHandler(lotHandler.UpdateParkingLot))
 	v1Api.DELETE("/parking-lot/delete/:id", ginext.WrapHandler(lotHandler.DeleteParkingLot))
 
@@ -151,30 +276,175 @@ func NewService() *Service {
 	v1Api.DELETE("/vehicle/delete/:id", ginext.WrapHandler(vehicleHandler.DeleteVehicle))
 
 	//ticket
-	v1Api.POST("/ticket/create", ginext.WrapHandler(ticketHandler.CreateTicket))
+	idempotent := s.idempotency.Handler()
+	v1Api.POST("/ticket/create", idempotent, ginext.WrapHandler(ticketHandler.CreateTicket))
 	v1Api.GET("/ticket/get-all", ginext.WrapHandler(ticketHandler.GetAllTicket))
 	v1Api.GET("/ticket/get-one-with-extend/:id", ginext.WrapHandler(ticketHandler.GetOneTicketWithExtend))
-	v1Api.PUT("/ticket/cancel", ginext.WrapHandler(ticketHandler.CancelTicket))
-	v1Api.POST("/ticket/extend", ginext.WrapHandler(ticketHandler.ExtendTicket))
+	v1Api.PUT("/ticket/cancel", idempotent, ginext.WrapHandler(ticketHandler.CancelTicket))
+	v1Api.POST("/ticket/extend", idempotent, ginext.WrapHandler(ticketHandler.ExtendTicket))
 	v1Api.POST("/ticket/procedure", ginext.WrapHandler(ticketHandler.ProcedureWithTicket))
+	v1Api.PUT("/ticket/reassign", ginext.WrapHandler(ticketHandler.ReassignTicket))
+
+	// notification
+	v1Api.GET("/notification/get-list", ginext.WrapHandler(notificationHandler.GetListNotification))
+	v1Api.GET("/notification/preference", ginext.WrapHandler(notificationHandler.GetNotificationPreference))
+	v1Api.PUT("/notification/preference", ginext.WrapHandler(notificationHandler.UpdateNotificationPreference))
+	v1Api.POST("/notification/device-token", ginext.WrapHandler(notificationHandler.RegisterDeviceToken))
+	v1Api.DELETE("/notification/device-token", ginext.WrapHandler(notificationHandler.DeleteDeviceToken))
+
+	// slot hold
+	v1Api.POST("/slot-hold/create", ginext.WrapHandler(holdHandler.CreateSlotHold))
+	v1Api.DELETE("/slot-hold/delete/:id", ginext.WrapHandler(holdHandler.DeleteSlotHold))
+
+	// waitlist
+	v1Api.POST("/waitlist/create", ginext.WrapHandler(waitlistHandler.JoinWaitlist))
+	v1Api.GET("/waitlist/get-list", ginext.WrapHandler(waitlistHandler.GetListWaitlist))
+	v1Api.PUT("/waitlist/accept/:id", idempotent, ginext.WrapHandler(waitlistHandler.AcceptWaitlistOffer))
+	v1Api.PUT("/waitlist/cancel/:id", ginext.WrapHandler(waitlistHandler.CancelWaitlist))
 
 	// company
 	merchantApi.POST("/company/create", cors.Default(), ginext.WrapHandler(companyHanler.CreateCompany))
 	merchantApi.PUT("/company/update/:id", cors.Default(), ginext.WrapHandler(companyHanler.UpdateCompany))
-	merchantApi.POST("/company/login", cors.Default(), ginext.WrapHandler(companyHanler.Login))
+	merchantApi.POST("/company/login", cors.Default(), companyLoginLimit, ginext.WrapHandler(companyHanler.Login))
 	merchantApi.GET("/company/get-one/:id", cors.Default(), ginext.WrapHandler(companyHanler.GetOneCompany))
 	merchantApi.PUT("/company/update-password/:id", cors.Default(), ginext.WrapHandler(companyHanler.UpdateCompanyPassword))
+	merchantApi.GET("/company/verify-email", ginext.WrapHandler(companyHanler.VerifyCompanyEmail))
+	merchantApi.POST("/company/resend-verification", resendVerificationLimit, ginext.WrapHandler(companyHanler.ResendCompanyVerification))
+	merchantApi.POST("/company/document/create", ginext.WrapHandler(companyHanler.CreateCompanyDocument))
+	merchantApi.GET("/company/document/get-list", ginext.WrapHandler(companyHanler.GetListCompanyDocument))
+	merchantApi.DELETE("/company/document/delete/:id", ginext.WrapHandler(companyHanler.DeleteCompanyDocument))
 
 	merchantApi.GET("/parking-lot/get-list", ginext.WrapHandler(lotHandler.GetListParkingLotCompany))
 	merchantApi.GET("/parking-lot/get-one/:id", ginext.WrapHandler(lotHandler.GetOneParkingLot))
+	merchantApi.POST("/parking-lot/import-layout", ginext.WrapHandler(layoutHandler.ImportLayout))
 
 	merchantApi.GET("/block/get-list", ginext.WrapHandler(blockHandler.GetListBlock))
+	merchantApi.GET("/block/consistency-check", ginext.WrapHandler(blockHandler.GetBlockSlotDrift))
+
+	// slot downtime
+	merchantApi.POST("/slot-downtime/create", ginext.WrapHandler(downtimeHandler.CreateSlotDowntime))
+	merchantApi.GET("/slot-downtime/get-list", ginext.WrapHandler(downtimeHandler.GetListSlotDowntime))
+	merchantApi.PUT("/slot-downtime/update/:id", ginext.WrapHandler(downtimeHandler.UpdateSlotDowntime))
+	merchantApi.DELETE("/slot-downtime/delete/:id", ginext.WrapHandler(downtimeHandler.DeleteSlotDowntime))
+
+	// setting
+	merchantApi.GET("/setting/get-one", ginext.WrapHandler(settingHandler.GetSetting))
+	merchantApi.PUT("/setting/upsert", ginext.WrapHandler(settingHandler.UpsertSetting))
+
+	// webhook
+	merchantApi.POST("/webhook/create", ginext.WrapHandler(webhookHandler.CreateWebhookSubscription))
+	merchantApi.GET("/webhook/get-list", ginext.WrapHandler(webhookHandler.GetListWebhookSubscription))
+	merchantApi.PUT("/webhook/update/:id", ginext.WrapHandler(webhookHandler.UpdateWebhookSubscription))
+	merchantApi.DELETE("/webhook/delete/:id", ginext.WrapHandler(webhookHandler.DeleteWebhookSubscription))
+	merchantApi.GET("/webhook/deliveries", ginext.WrapHandler(webhookHandler.GetListWebhookDelivery))
+	merchantApi.POST("/webhook/replay/:id", ginext.WrapHandler(webhookHandler.ReplayWebhookDelivery))
+
+	// audit
+	merchantApi.GET("/audit-log/get-list", ginext.WrapHandler(auditHandler.GetListAuditLog))
 
 	merchantApi.GET("/time-frame/get-list", ginext.WrapHandler(timeFrameHandler.GetAllTimeFrame))
 	merchantApi.GET("/ticket/get-all", ginext.WrapHandler(ticketHandler.GetAllTicketCompany))
+	merchantApi.GET("/ticket/export", ginext.WrapHandler(exportHandler.ExportTicketCompany))
+	merchantApi.PUT("/ticket/reassign", ginext.WrapHandler(ticketHandler.ReassignTicketCompany))
 
-	// Migrate
-	migrateHandler := handlers.NewMigrationHandler(db)
-	s.Router.POST("/internal/migrate", migrateHandler.Migrate)
+	// report
+	merchantApi.GET("/reports/revenue", ginext.WrapHandler(reportHandler.GetRevenueReport))
+	merchantApi.GET("/reports/occupancy", ginext.WrapHandler(reportHandler.GetOccupancyReport))
+	merchantApi.GET("/reports/ticket-stats", ginext.WrapHandler(reportHandler.GetTicketStatsReport))
+	merchantApi.GET("/reports/top-time-frames", ginext.WrapHandler(reportHandler.GetTopTimeFrameReport))
+	merchantApi.GET("/reports/export", ginext.WrapHandler(exportHandler.ExportReport))
+
+	// admin
+	adminApi.GET("/company/get-list", ginext.WrapHandler(adminHandler.GetListCompany))
+	adminApi.PUT("/company/approve/:id", ginext.WrapHandler(adminHandler.ApproveCompany))
+	adminApi.PUT("/company/suspend/:id", ginext.WrapHandler(adminHandler.SuspendCompany))
+	adminApi.GET("/company/document/get-list", ginext.WrapHandler(companyHanler.GetListCompanyDocument))
+	adminApi.GET("/user/get-list", ginext.WrapHandler(adminHandler.GetListUser))
+	adminApi.POST("/user/impersonate/:id", ginext.WrapHandler(adminHandler.ImpersonateUser))
+	adminApi.GET("/stats", ginext.WrapHandler(adminHandler.GetPlatformStats))
+	adminApi.GET("/setting/get-list", ginext.WrapHandler(adminHandler.GetListGlobalSetting))
+	adminApi.PUT("/setting/upsert", ginext.WrapHandler(adminHandler.UpsertGlobalSetting))
+	adminApi.DELETE("/setting/delete/:key", ginext.WrapHandler(adminHandler.DeleteGlobalSetting))
 	return s
 }
+
+// Start runs the background jobs next to the http server.
+func (s *Service) Start(ctx context.Context) error {
+	go s.waitlist.Run(ctx)
+	go s.notification.Run(ctx)
+	go s.events.Run(ctx)
+	go s.webhook.Run(ctx)
+	go s.idempotency.Run(ctx)
+	return s.BaseApp.Start(ctx)
+}
+
+// Reindex rebuilds the parking lot search index, it backs the "reindex" command of the binary.
+func (s *Service) Reindex(ctx context.Context) error {
+	total, err := s.searchService.ReindexParkingLot(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Tag("Reindex").Infof("indexed %d parking lots", total)
+	return nil
+}
+
+// GrantAdmin gives the admin role to the user of the phone number, it backs the "grant-admin" command of the binary.
+func (s *Service) GrantAdmin(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: grant-admin <phone number>")
+	}
+	if err := s.admin.GrantAdmin(ctx, args[0]); err != nil {
+		return err
+	}
+	logger.Tag("GrantAdmin").Infof("granted the admin role to %s", args[0])
+	return nil
+}
+
+// Migrate backs the "migrate" command of the binary: up [n], down [n], status or create <name>.
+func (s *Service) Migrate(ctx context.Context, args []string) error {
+	usage := fmt.Errorf("usage: migrate up [n] | down [n] | status | create <name>")
+	if len(args) == 0 {
+		return usage
+	}
+	if args[0] == "create" {
+		files, err := migration.Create(conf.GetConfig().MigrationDir, strings.Join(args[1:], "_"))
+		if err != nil {
+			return err
+		}
+		logger.Tag("Migrate").Infof("created %s", strings.Join(files, ", "))
+		return nil
+	}
+
+	list, err := migration.Load(migrations.FS)
+	if err != nil {
+		return err
+	}
+	migrator := migration.NewMigrator(s.db, list)
+	steps := 0
+	if len(args) > 1 {
+		if steps, err = strconv.Atoi(args[1]); err != nil || steps < 0 {
+			return usage
+		}
+	}
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx, steps)
+	case "down":
+		return migrator.Down(ctx, steps)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range status {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", st.Version, st.Name, applied)
+		}
+		return nil
+	default:
+		return usage
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"gorm.io/gorm"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/audit"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"time"
+)
+
+const (
+	impersonationTTL        = 15 * time.Minute
+	defaultPlatformStatsAge = 30 * 24 * time.Hour
+)
+
+type AdminService struct {
+	repo     repo.PGInterface
+	search   SearchServiceInterface
+	notifier NotificationInterface
+}
+
+func NewAdminService(repo repo.PGInterface, search SearchServiceInterface, notifier NotificationInterface) AdminInterface {
+	return &AdminService{repo: repo, search: search, notifier: notifier}
+}
+
+type AdminInterface interface {
+	GetListCompany(ctx context.Context, req model.AdminListCompanyReq) (model.AdminListCompanyRes, error)
+	ApproveCompany(ctx context.Context, id uuid.UUID) (model.Company, error)
+	SuspendCompany(ctx context.Context, id uuid.UUID, req model.CompanyStatusReq) (model.Company, error)
+	GetListUser(ctx context.Context, req model.AdminListUserReq) (model.AdminListUserRes, error)
+	ImpersonateUser(ctx context.Context, adminID, userID uuid.UUID) (model.ImpersonateRes, error)
+	GrantAdmin(ctx context.Context, phoneNumber string) error
+	GetPlatformStats(ctx context.Context, req model.PlatformStatsReq) (model.PlatformStats, error)
+	GetListGlobalSetting(ctx context.Context) ([]model.Setting, error)
+	UpsertGlobalSetting(ctx context.Context, req model.GlobalSettingReq) (*model.Setting, error)
+	DeleteGlobalSetting(ctx context.Context, key string) error
+}
+
+func (s *AdminService) GetListCompany(ctx context.Context, req model.AdminListCompanyReq) (model.AdminListCompanyRes, error) {
+	return s.repo.GetListCompanyAdmin(ctx, req)
+}
+
+// ApproveCompany makes a company pending review active, or reinstates a suspended one. Its email must be
+// verified and a business license uploaded.
+func (s *AdminService) ApproveCompany(ctx context.Context, id uuid.UUID) (model.Company, error) {
+	company, err := s.repo.GetOneCompany(ctx, id)
+	if err != nil {
+		return company, err
+	}
+	if company.Status != model.CompanyStatusPendingReview && company.Status != model.CompanyStatusSuspended {
+		return company, apperror.New(apperror.CompanyStatus, company.Status)
+	}
+	if company.EmailVerifiedAt == nil {
+		return company, apperror.New(apperror.CompanyUnverified)
+	}
+	licenses, err := s.repo.CountCompanyDocument(ctx, id, model.CompanyDocumentBusinessLicense)
+	if err != nil {
+		return company, err
+	}
+	if licenses == 0 {
+		return company, apperror.New(apperror.CompanyNoDocument, model.CompanyDocumentBusinessLicense)
+	}
+	return s.setCompanyStatus(ctx, company, model.CompanyStatusActive, "", notification.EventCompanyApproved)
+}
+
+// SuspendCompany stops the company from logging in and hides its parking lots, until it is approved again.
+func (s *AdminService) SuspendCompany(ctx context.Context, id uuid.UUID, req model.CompanyStatusReq) (model.Company, error) {
+	company, err := s.repo.GetOneCompany(ctx, id)
+	if err != nil {
+		return company, err
+	}
+	if company.Status == model.CompanyStatusSuspended {
+		return company, apperror.New(apperror.CompanyStatus, company.Status)
+	}
+	return s.setCompanyStatus(ctx, company, model.CompanyStatusSuspended, valid.String(req.Reason), notification.EventCompanySuspended)
+}
+
+// setCompanyStatus changes the status of the company, brings its parking lots in or out of the search index
+// and tells it with event.
+func (s *AdminService) setCompanyStatus(ctx context.Context, company model.Company, status, reason, event string) (model.Company, error) {
+	if err := s.repo.UpdateCompanyStatus(ctx, company.ID, status, reason); err != nil {
+		return company, err
+	}
+	company.Status, company.StatusReason, company.Password = status, reason, ""
+
+	if err := s.search.IndexCompanyParkingLot(ctx, company.ID); err != nil {
+		logger.WithCtx(ctx, utils.GetCurrentCaller(s, 1)).WithError(err).Error("Failed to index parking lots of company")
+	}
+	s.notifier.NotifyCompany(ctx, company, event, notification.Data{Reason: reason})
+	return company, nil
+}
+
+func (s *AdminService) GetListUser(ctx context.Context, req model.AdminListUserReq) (model.AdminListUserRes, error) {
+	return s.repo.GetListUserAdmin(ctx, req)
+}
+
+// ImpersonateUser returns a short-lived token to act as the user for support, it is recorded in the audit log.
+// Admins cannot be impersonated.
+func (s *AdminService) ImpersonateUser(ctx context.Context, adminID, userID uuid.UUID) (res model.ImpersonateRes, err error) {
+	user, err := s.repo.GetOneUserById(ctx, userID, nil)
+	if err != nil {
+		return res, err
+	}
+	if user.HasRole(utils.ADMIN_ROLE) {
+		return res, apperror.New(apperror.Forbidden)
+	}
+
+	res.AccessToken, res.ExpiresAt, err = utils.GenerateImpersonationToken(userID.String(), adminID.String(), impersonationTTL)
+	if err != nil {
+		return res, apperror.Wrap(apperror.Internal, err)
+	}
+
+	actor := audit.ActorFrom(ctx)
+	entry := &model.AuditLog{
+		ActorId:    &adminID,
+		ActorType:  model.AuditActorAdmin,
+		Action:     model.AuditActionImpersonate,
+		EntityType: user.TableName(),
+		EntityId:   userID.String(),
+		Ip:         actor.IP,
+		RequestId:  actor.RequestID,
+	}
+	if err := s.repo.CreateAuditLog(ctx, entry); err != nil {
+		return model.ImpersonateRes{}, err
+	}
+
+	user.Password = ""
+	res.User = *user
+	return res, nil
+}
+
+// GrantAdmin gives the admin role to the user of the phone number, it backs the "grant-admin" command of the
+// binary to create the first admin.
+func (s *AdminService) GrantAdmin(ctx context.Context, phoneNumber string) error {
+	user, err := s.repo.GetOneUserByPhone(ctx, phoneNumber, nil)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.Wrap(apperror.NotFound, err)
+		}
+		return err
+	}
+	return s.repo.UpdateUserRole(ctx, user.ID, user.Role|utils.ADMIN_ROLE)
+}
+
+// GetPlatformStats counts the entities of every company, the tickets and revenue of the last 30 days unless
+// from and to are given. To is exclusive.
+func (s *AdminService) GetPlatformStats(ctx context.Context, req model.PlatformStatsReq) (model.PlatformStats, error) {
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.Add(-defaultPlatformStatsAge)
+	if req.From != nil {
+		from = *req.From
+	}
+	if !from.Before(to) {
+		return model.PlatformStats{}, apperror.New(apperror.InvalidTimeRange)
+	}
+	return s.repo.GetPlatformStats(ctx, from, to)
+}
+
+func (s *AdminService) GetListGlobalSetting(ctx context.Context) ([]model.Setting, error) {
+	return s.repo.GetListGlobalSetting(ctx)
+}
+
+// UpsertGlobalSetting stores the value of a setting for every company, the settings of a company or a parking
+// lot still take precedence.
+func (s *AdminService) UpsertGlobalSetting(ctx context.Context, req model.GlobalSettingReq) (*model.Setting, error) {
+	key := valid.String(req.Key)
+	check, ok := settingValidators[key]
+	if !ok {
+		return nil, apperror.New(apperror.InvalidParam, "key", key)
+	}
+	if err := check(req.Value); err != nil {
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+
+	setting := &model.Setting{Key: key}
+	if err := setting.Value.Set([]byte(req.Value)); err != nil {
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := s.repo.UpsertSetting(ctx, setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (s *AdminService) DeleteGlobalSetting(ctx context.Context, key string) error {
+	return s.repo.DeleteGlobalSetting(ctx, key)
+}
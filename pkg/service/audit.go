@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+)
+
+type AuditService struct {
+	repo repo.PGInterface
+}
+
+func NewAuditService(repo repo.PGInterface) AuditInterface {
+	return &AuditService{repo: repo}
+}
+
+type AuditInterface interface {
+	GetListAuditLog(ctx context.Context, req model.ListAuditLogReq) (model.ListAuditLogRes, error)
+}
+
+// GetListAuditLog returns the changes made to the entities of a company, the latest first. To is exclusive.
+func (s *AuditService) GetListAuditLog(ctx context.Context, req model.ListAuditLogReq) (model.ListAuditLogRes, error) {
+	return s.repo.GetListAuditLog(ctx, req)
+}
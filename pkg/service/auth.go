@@ -2,10 +2,9 @@ package service
 
 import (
 	"context"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"golang.org/x/crypto/bcrypt"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
@@ -35,7 +34,7 @@ func (s *AuthService) Login(ctx context.Context, req model.Credential) (interfac
 
 	//check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(valid.String(req.Password))); err != nil {
-		return nil, ginext.NewError(http.StatusBadRequest, "Mật khẩu không đúng!")
+		return nil, apperror.New(apperror.InvalidCredentials)
 	}
 
 	token, err := utils.GenerateToken(user.ID.String())
@@ -74,7 +73,7 @@ func (s *AuthService) ResetPassword(ctx context.Context, req model.Credential) e
 	hashPass, err := utils.Hash(valid.String(req.Password))
 	if err != nil {
 		log.WithError(err).Error("Failed to hash password")
-		return ginext.NewError(http.StatusInternalServerError, "Failed to hash password")
+		return apperror.New(apperror.Internal)
 	}
 	user.Password = hashPass
 
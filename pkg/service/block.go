@@ -2,19 +2,28 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"regexp"
 )
 
+const maxGeneratedSlots = 1000
+
+// slotNamePattern accepts a printf pattern with exactly one integer verb such as "A-%03d".
+var slotNamePattern = regexp.MustCompile(`^[^%]*%0?[0-9]*d[^%]*$`)
+
 type BlockService struct {
-	repo repo.PGInterface
+	repo     repo.PGInterface
+	notifier NotificationInterface
 }
 
-func NewBlockService(repo repo.PGInterface) BlockInterface {
-	return &BlockService{repo: repo}
+func NewBlockService(repo repo.PGInterface, notifier NotificationInterface) BlockInterface {
+	return &BlockService{repo: repo, notifier: notifier}
 }
 
 type BlockInterface interface {
@@ -22,20 +31,65 @@ type BlockInterface interface {
 	GetListBlock(ctx context.Context, req model.ListBlockReq) (model.ListBlockRes, error)
 	GetOneBlock(ctx context.Context, id uuid.UUID) (model.Block, error)
 	UpdateBlock(ctx context.Context, req model.BlockReq) (model.Block, error)
-	DeleteBlock(ctx context.Context, id uuid.UUID) error
+	DeleteBlock(ctx context.Context, id uuid.UUID, req model.DeleteReq) (model.DeleteRes, error)
+	GetBlockSlotDrift(ctx context.Context, req model.BlockSlotDriftReq) ([]model.BlockSlotDrift, error)
+}
+
+func generateSlots(req model.BlockReq) ([]model.ParkingSlot, error) {
+	count := valid.Int(req.Slot)
+	if count <= 0 || count > maxGeneratedSlots {
+		return nil, apperror.New(apperror.SlotCountOutOfRange, maxGeneratedSlots)
+	}
+	pattern := valid.String(req.SlotNamePattern)
+	if pattern == "" {
+		pattern = valid.String(req.Code) + "-%03d"
+	}
+	if !slotNamePattern.MatchString(pattern) {
+		return nil, apperror.New(apperror.InvalidParam, "slot_name_pattern", pattern)
+	}
+
+	slots := make([]model.ParkingSlot, 0, count)
+	for i := 1; i <= count; i++ {
+		slots = append(slots, model.ParkingSlot{
+			Name:        fmt.Sprintf(pattern, i),
+			VehicleType: valid.String(req.SlotVehicleType),
+		})
+	}
+	return slots, nil
 }
 
 func (s *BlockService) CreateBlock(ctx context.Context, req model.BlockReq) (*model.Block, error) {
 	block := &model.Block{
 		Code:         valid.String(req.Code),
 		Description:  valid.String(req.Description),
-		Slot:         valid.Int(req.Slot),
 		ParkingLotID: valid.UUID(req.ParkingLotID),
 	}
 
-	if err := s.repo.CreateBlock(ctx, block); err != nil {
+	if !valid.Bool(req.GenerateSlots) {
+		if err := s.repo.CreateBlock(ctx, block); err != nil {
+			return nil, err
+		}
+		return block, nil
+	}
+
+	slots, err := generateSlots(req)
+	if err != nil {
+		return nil, err
+	}
+	block.Slot = len(slots)
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.CreateBlock(ctx, block); err != nil {
+			return err
+		}
+		for i := range slots {
+			slots[i].BlockID = block.ID
+		}
+		return rp.CreateParkingSlots(ctx, slots)
+	})
+	if err != nil {
 		return nil, err
 	}
+	block.ParkingSLots = slots
 	return block, nil
 }
 
@@ -53,6 +107,8 @@ func (s *BlockService) UpdateBlock(ctx context.Context, req model.BlockReq) (mod
 		return block, err
 	}
 
+	// the slot count follows the slots of the block
+	req.Slot = nil
 	utils.Sync(req, &block)
 	if err := s.repo.UpdateBlock(ctx, &block); err != nil {
 		return block, err
@@ -61,6 +117,40 @@ func (s *BlockService) UpdateBlock(ctx context.Context, req model.BlockReq) (mod
 	return block, nil
 }
 
-func (s *BlockService) DeleteBlock(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteBlock(ctx, id)
+// DeleteBlock deletes the block with its slots, a forced delete moves their future tickets to other
+// blocks of the parking lot when there is room and cancels them otherwise.
+func (s *BlockService) DeleteBlock(ctx context.Context, id uuid.UUID, req model.DeleteReq) (res model.DeleteRes, err error) {
+	block, err := s.repo.GetOneBlock(ctx, id)
+	if err != nil {
+		return res, err
+	}
+
+	var changes []ticketChange
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// no ticket can be booked on the slots being freed, nor on the slot a ticket is moved to, until the
+		// delete is committed
+		if err := rp.LockParkingLot(ctx, block.ParkingLotID); err != nil {
+			return err
+		}
+		if res, changes, err = releaseTickets(ctx, rp, model.TicketScope{BlockID: &id}, releaseOptions{
+			force:      req.Force,
+			forceParam: "force",
+			relocate:   &model.FreeSlotReq{ExcludeBlockID: &id},
+		}); err != nil {
+			return err
+		}
+		if err := rp.DeleteParkingSlotByBlock(ctx, id); err != nil {
+			return err
+		}
+		return rp.DeleteBlock(ctx, id)
+	})
+	if err != nil {
+		return res, err
+	}
+	notifyTicketChanges(ctx, s.notifier, changes)
+	return res, nil
+}
+
+func (s *BlockService) GetBlockSlotDrift(ctx context.Context, req model.BlockSlotDriftReq) ([]model.BlockSlotDrift, error) {
+	return s.repo.GetBlockSlotDrift(ctx, req)
 }
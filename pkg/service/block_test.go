@@ -0,0 +1,81 @@
+package service
+
+import (
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func intPtr(i int) *int { return &i }
+
+func strPtr(s string) *string { return &s }
+
+func TestGenerateSlots(t *testing.T) {
+	tests := []struct {
+		pattern *string
+		want    []string
+	}{
+		{nil, []string{"A-001", "A-002", "A-003"}},
+		{strPtr(""), []string{"A-001", "A-002", "A-003"}},
+		{strPtr("B%d"), []string{"B1", "B2", "B3"}},
+		{strPtr("%02d-left"), []string{"01-left", "02-left", "03-left"}},
+		{strPtr("P %4d"), []string{"P    1", "P    2", "P    3"}},
+	}
+	for _, tt := range tests {
+		slots, err := generateSlots(model.BlockReq{Code: strPtr("A"), Slot: intPtr(3), SlotNamePattern: tt.pattern, SlotVehicleType: strPtr("car")})
+		if err != nil {
+			t.Errorf("generateSlots(%v) error = %v", tt.pattern, err)
+			continue
+		}
+		var names []string
+		for _, slot := range slots {
+			names = append(names, slot.Name)
+			if slot.VehicleType != "car" {
+				t.Errorf("generateSlots(%v) slot %s vehicle type = %q, want car", tt.pattern, slot.Name, slot.VehicleType)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("generateSlots(%v) = %v, want %v", tt.pattern, names, tt.want)
+		}
+	}
+}
+
+func TestGenerateSlotsRejectsPattern(t *testing.T) {
+	for _, pattern := range []string{
+		"A",        // no verb
+		"A-%s",     // not an integer
+		"%d-%d",    // two verbs
+		"A-%03x",   // not decimal
+		"100%%-%d", // an escaped percent is a second verb for the check
+		"A-%-3d",   // flags other than 0
+		"A-%v",
+	} {
+		_, err := generateSlots(model.BlockReq{Code: strPtr("A"), Slot: intPtr(3), SlotNamePattern: strPtr(pattern)})
+		if apperror.From(err).ErrorCode() != apperror.InvalidParam {
+			t.Errorf("generateSlots(%q) error = %v, want %s", pattern, err, apperror.InvalidParam)
+		}
+	}
+
+	// the default pattern takes the code as it is
+	_, err := generateSlots(model.BlockReq{Code: strPtr("50%"), Slot: intPtr(3)})
+	if apperror.From(err).ErrorCode() != apperror.InvalidParam {
+		t.Errorf("generateSlots(code 50%%) error = %v, want %s", err, apperror.InvalidParam)
+	}
+}
+
+func TestGenerateSlotsCount(t *testing.T) {
+	for _, slot := range []*int{nil, intPtr(0), intPtr(-1), intPtr(maxGeneratedSlots + 1)} {
+		_, err := generateSlots(model.BlockReq{Code: strPtr("A"), Slot: slot})
+		if apperror.From(err).ErrorCode() != apperror.SlotCountOutOfRange {
+			t.Errorf("generateSlots(slot %v) error = %v, want %s", slot, err, apperror.SlotCountOutOfRange)
+		}
+	}
+	slots, err := generateSlots(model.BlockReq{Code: strPtr("A"), Slot: intPtr(maxGeneratedSlots)})
+	if err != nil || len(slots) != maxGeneratedSlots {
+		t.Fatalf("generateSlots(slot %d) = %d slots, %v", maxGeneratedSlots, len(slots), err)
+	}
+	if last := slots[len(slots)-1].Name; last != "A-1000" {
+		t.Errorf("last slot = %s, want A-1000", last)
+	}
+}
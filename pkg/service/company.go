@@ -2,23 +2,36 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
+	"gitlab.com/goxp/cloud0/logger"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
+	"time"
 )
 
+// companyVerificationTTL is how long the email verification link of a company is valid.
+const companyVerificationTTL = 24 * time.Hour
+
 type CompanyService struct {
-	repo repo.PGInterface
+	repo      repo.PGInterface
+	notifier  NotificationInterface
+	verifyURL string
 }
 
-func NewCompanyService(repo repo.PGInterface) CompanyInterface {
-	return &CompanyService{repo: repo}
+// NewCompanyService creates the company service, verifyURL is the link sent to verify the email of a company,
+// the token is appended to it.
+func NewCompanyService(repo repo.PGInterface, notifier NotificationInterface, verifyURL string) CompanyInterface {
+	return &CompanyService{repo: repo, notifier: notifier, verifyURL: verifyURL}
 }
 
 type CompanyInterface interface {
@@ -28,8 +41,29 @@ type CompanyInterface interface {
 	GetOneCompany(ctx context.Context, id uuid.UUID) (model.Company, error)
 	UpdateCompany(ctx context.Context, id uuid.UUID, req model.CompanyReq) (model.Company, error)
 	UpdateCompanyPassword(ctx context.Context, id uuid.UUID, req model.PasswordChangeReq) (model.Company, error)
+	VerifyCompanyEmail(ctx context.Context, token string) (model.Company, error)
+	ResendCompanyVerification(ctx context.Context, email string) error
+	CreateCompanyDocument(ctx context.Context, req model.CompanyDocumentReq) (model.CompanyDocument, error)
+	GetListCompanyDocument(ctx context.Context, companyID uuid.UUID) ([]model.CompanyDocument, error)
+	DeleteCompanyDocument(ctx context.Context, id uuid.UUID) error
 }
 
+// checkCompanyActive rejects what a company can only do once an admin approved it.
+func checkCompanyActive(company model.Company) error {
+	switch company.Status {
+	case model.CompanyStatusActive:
+		return nil
+	case model.CompanyStatusPendingEmail:
+		return apperror.New(apperror.CompanyUnverified)
+	case model.CompanyStatusSuspended:
+		return apperror.New(apperror.CompanySuspended)
+	default:
+		return apperror.New(apperror.CompanyPending)
+	}
+}
+
+// CreateCompany registers a company pending the verification of its email, a link to verify it is sent to
+// the email.
 func (s *CompanyService) CreateCompany(ctx context.Context, req model.CompanyReq) (res model.Company, err error) {
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(valid.String(req.Password)), 14)
 	if err != nil {
@@ -41,41 +75,156 @@ func (s *CompanyService) CreateCompany(ctx context.Context, req model.CompanyReq
 		PhoneNumber: valid.String(req.PhoneNumber),
 		Email:       valid.String(req.Email),
 		Password:    string(hashPassword),
+		Status:      model.CompanyStatusPendingEmail,
 	}
 	if err := s.repo.CreateCompany(ctx, &company); err != nil {
 		return company, err
 	}
+	s.sendVerification(ctx, company)
 
 	return company, nil
 }
 
+// sendVerification emails a new verification link to the company, a failure is only logged as the company
+// can ask for another one.
+func (s *CompanyService) sendVerification(ctx context.Context, company model.Company) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(s, 1)).WithField("company_id", company.ID)
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.WithError(err).Error("Failed to generate verification token")
+		return
+	}
+	token := hex.EncodeToString(b)
+	verification := &model.CompanyVerificationToken{
+		CompanyId: company.ID,
+		TokenHash: hashVerificationToken(token),
+		ExpiresAt: time.Now().Add(companyVerificationTTL),
+	}
+	if err := s.repo.CreateCompanyVerificationToken(ctx, verification); err != nil {
+		log.WithError(err).Error("Failed to create verification token")
+		return
+	}
+	s.notifier.NotifyCompany(ctx, company, notification.EventCompanyVerify, notification.Data{
+		Link:     s.verifyURL + token,
+		Deadline: verification.ExpiresAt,
+	})
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoginCompany checks the password of the company. A company pending review can log in to upload its
+// documents, one with its email not verified or suspended cannot.
 func (s *CompanyService) LoginCompany(ctx context.Context, email string, password string) (model.Company, error) {
 	company, err := s.repo.GetCompanyByEmail(ctx, email)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return company, ginext.NewError(http.StatusUnauthorized, "Email not exists")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return company, apperror.New(apperror.InvalidCredentials)
 		}
 		return company, err
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(company.Password), []byte(password))
 	if err != nil {
-		return company, ginext.NewError(http.StatusUnauthorized, "Incorrect password")
+		return company, apperror.New(apperror.InvalidCredentials)
+	}
+	if company.Status != model.CompanyStatusPendingReview {
+		if err := checkCompanyActive(company); err != nil {
+			return company, err
+		}
 	}
 
 	return company, nil
 }
 
+// VerifyCompanyEmail uses the token sent to the company, it is then pending the review of an admin.
+func (s *CompanyService) VerifyCompanyEmail(ctx context.Context, token string) (company model.Company, err error) {
+	now := time.Now()
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		verification, err := rp.UseCompanyVerificationToken(ctx, hashVerificationToken(token), now)
+		if err != nil {
+			return err
+		}
+		if verification == nil {
+			return apperror.New(apperror.CompanyTokenInvalid)
+		}
+		if err := rp.VerifyCompanyEmail(ctx, verification.CompanyId, now); err != nil {
+			return err
+		}
+		company, err = rp.GetOneCompany(ctx, verification.CompanyId)
+		return err
+	})
+	company.Password = ""
+	return company, err
+}
+
+// ResendCompanyVerification sends a new verification link to the company of email. It answers the same
+// whether the email is registered or not so it cannot be used to find the companies.
+func (s *CompanyService) ResendCompanyVerification(ctx context.Context, email string) error {
+	company, err := s.repo.GetCompanyByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if company.EmailVerifiedAt == nil {
+		s.sendVerification(ctx, company)
+	}
+	return nil
+}
+
+// CreateCompanyDocument records the metadata of a document of the company, the file is uploaded to Url by the
+// client. A business license is required for an admin to approve the company.
+func (s *CompanyService) CreateCompanyDocument(ctx context.Context, req model.CompanyDocumentReq) (model.CompanyDocument, error) {
+	company, err := s.repo.GetOneCompany(ctx, valid.UUID(req.CompanyId))
+	if err != nil {
+		return model.CompanyDocument{}, err
+	}
+	if company.Status == model.CompanyStatusSuspended {
+		return model.CompanyDocument{}, apperror.New(apperror.CompanySuspended)
+	}
+
+	document := model.CompanyDocument{
+		CompanyId:   company.ID,
+		Type:        valid.String(req.Type),
+		Number:      valid.String(req.Number),
+		FileName:    valid.String(req.FileName),
+		ContentType: valid.String(req.ContentType),
+		Size:        valid.Int64(req.Size),
+		Url:         valid.String(req.Url),
+	}
+	if err := s.repo.CreateCompanyDocument(ctx, &document); err != nil {
+		return document, err
+	}
+	return document, nil
+}
+
+func (s *CompanyService) GetListCompanyDocument(ctx context.Context, companyID uuid.UUID) ([]model.CompanyDocument, error) {
+	return s.repo.GetListCompanyDocument(ctx, companyID)
+}
+
+func (s *CompanyService) DeleteCompanyDocument(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteCompanyDocument(ctx, id)
+}
+
 func (s *CompanyService) GetOneCompany(ctx context.Context, id uuid.UUID) (model.Company, error) {
 	return s.repo.GetOneCompany(ctx, id)
 }
 
+// UpdateCompany changes the name and phone number of the company. The email is kept, it was verified and is
+// the login, and the password is changed by UpdateCompanyPassword only.
 func (s *CompanyService) UpdateCompany(ctx context.Context, id uuid.UUID, req model.CompanyReq) (model.Company, error) {
 	company, err := s.repo.GetOneCompany(ctx, id)
 	if err != nil {
 		return company, err
 	}
 
+	email, password := company.Email, company.Password
 	utils.Sync(req, &company)
+	company.Email, company.Password = email, password
 
 	if err := s.repo.UpdateCompany(ctx, &company); err != nil {
 		return company, err
@@ -92,7 +241,7 @@ func (s *CompanyService) UpdateCompanyPassword(ctx context.Context, id uuid.UUID
 
 	err = bcrypt.CompareHashAndPassword([]byte(company.Password), []byte(valid.String(req.Old)))
 	if err != nil {
-		return company, ginext.NewError(http.StatusUnauthorized, "Incorrect password")
+		return company, apperror.New(apperror.IncorrectPassword)
 	}
 
 	newPassword, err := bcrypt.GenerateFromPassword([]byte(valid.String(req.New)), 14)
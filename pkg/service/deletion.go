@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/valid"
+)
+
+// ticketChange is a ticket moved or cancelled by a deletion, users are told once the deletion is committed.
+type ticketChange struct {
+	ticket model.Ticket
+	event  string
+}
+
+type releaseOptions struct {
+	force bool
+	// forceParam names the request field that sets force, for the conflict message
+	forceParam string
+	// relocate is the search for another slot of each ticket, without it the tickets are cancelled
+	relocate *model.FreeSlotReq
+	// keepUnplaced refuses the change when a ticket finds no other slot instead of cancelling it
+	keepUnplaced bool
+}
+
+// releaseTickets frees the slots in scope before they are deleted or closed. Parked cars always block
+// the change, future tickets block it unless force is set, in which case they are moved to another free
+// slot of the parking lot or cancelled. It runs inside the transaction of the change, which has to hold the
+// lock of the parking lot so no booking lands on the slots in scope or on the slots the tickets move to.
+func releaseTickets(ctx context.Context, rp repo.PGInterface, scope model.TicketScope, opts releaseOptions) (res model.DeleteRes, changes []ticketChange, err error) {
+	tickets, err := rp.GetUnfinishedTicket(ctx, scope)
+	if err != nil {
+		return res, nil, err
+	}
+
+	ongoing := 0
+	for _, ticket := range tickets {
+		if ticket.State == "ongoing" || ticket.EntryTime != nil {
+			ongoing++
+		}
+	}
+	if ongoing > 0 {
+		return res, nil, apperror.New(apperror.DeleteVehiclesParked, ongoing)
+	}
+	if len(tickets) > 0 && !opts.force {
+		return res, nil, apperror.New(apperror.DeleteUpcomingTickets, len(tickets), opts.forceParam)
+	}
+
+	// the extensions of a ticket follow it to the same slot when it is free
+	movedTo := map[uuid.UUID]uuid.UUID{}
+	for _, ticket := range tickets {
+		fromSlotID := valid.UUID(ticket.ParkingSlotId)
+		if opts.relocate != nil {
+			req := *opts.relocate
+			req.ParkingLotID = valid.UUID(ticket.ParkingLotId)
+			req.Start, req.End = valid.DayTime(ticket.StartTime), valid.DayTime(ticket.EndTime)
+			if ticket.VehicleId != nil {
+				if slotID, ok := movedTo[*ticket.VehicleId]; ok {
+					req.PreferSlotID = &slotID
+				}
+			}
+			slot, err := rp.GetFreeParkingSlot(ctx, req)
+			if err != nil {
+				return res, nil, err
+			}
+			if slot != nil {
+				ticket.ParkingSlotId = valid.UUIDPointer(slot.ID)
+				if err := rp.UpdateTicket(ctx, &ticket, nil); err != nil {
+					return res, nil, err
+				}
+				if ticket.VehicleId != nil {
+					movedTo[*ticket.VehicleId] = slot.ID
+				}
+				res.RelocatedTickets = append(res.RelocatedTickets, model.RelocatedTicket{TicketID: ticket.ID, FromSlotID: fromSlotID, ToSlotID: slot.ID})
+				changes = append(changes, ticketChange{ticket: ticket, event: notification.EventBookingRelocated})
+				continue
+			}
+		}
+		if opts.keepUnplaced {
+			return res, nil, apperror.New(apperror.RelocateNoFreeSlot, ticket.ID)
+		}
+
+		ticket.State = "cancel"
+		if err := rp.UpdateTicket(ctx, &ticket, nil); err != nil {
+			return res, nil, err
+		}
+		res.CancelledTickets = append(res.CancelledTickets, ticket.ID)
+		changes = append(changes, ticketChange{ticket: ticket, event: notification.EventBookingCancelled})
+	}
+	if err := emitTicketChanges(ctx, rp, changes); err != nil {
+		return res, nil, err
+	}
+	return res, changes, nil
+}
+
+// notifyTicketChanges tells the owners of the tickets moved or cancelled by a deletion.
+func notifyTicketChanges(ctx context.Context, notifier NotificationInterface, changes []ticketChange) {
+	for _, change := range changes {
+		notifier.NotifyTicket(ctx, change.ticket, change.event)
+	}
+}
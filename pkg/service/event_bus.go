@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/broker"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"time"
+)
+
+const (
+	// eventDispatchInterval is the period of the job that hands the outbox events to the subscribers.
+	eventDispatchInterval = 2 * time.Second
+	// eventBatchSize bounds the events claimed by one round of the job.
+	eventBatchSize = 100
+	// eventLease is how long a claimed event is kept from the other dispatchers while it is processed.
+	eventLease = time.Minute
+	// eventFirstRetry is the delay before an event that failed is tried again, it doubles after each
+	// failure up to eventMaxRetry.
+	eventFirstRetry = 10 * time.Second
+	eventMaxRetry   = time.Hour
+)
+
+// EventHandler reacts to a domain event. It runs in a transaction that also records the event as
+// processed by its consumer, so the writes made through rp happen once even when the event is delivered
+// again. Side effects outside the database must be idempotent on the event id.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, rp repo.PGInterface, event model.OutboxEvent) error
+}
+
+type EventHandlerFunc func(ctx context.Context, rp repo.PGInterface, event model.OutboxEvent) error
+
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, rp repo.PGInterface, event model.OutboxEvent) error {
+	return f(ctx, rp, event)
+}
+
+type eventSubscriber struct {
+	consumer   string
+	eventTypes map[string]bool
+	handler    EventHandler
+}
+
+// EventBus publishes the events of the outbox to the in-process subscribers, at least once.
+type EventBus struct {
+	repo        repo.PGInterface
+	subscribers []eventSubscriber
+}
+
+func NewEventBus(repo repo.PGInterface) *EventBus {
+	return &EventBus{repo: repo}
+}
+
+// Subscribe registers handler under the consumer name, which keys what it already processed and must stay
+// stable across releases. Without eventTypes the handler receives every event. Subscribe before Run.
+func (b *EventBus) Subscribe(consumer string, handler EventHandler, eventTypes ...string) {
+	sub := eventSubscriber{consumer: consumer, handler: handler}
+	if len(eventTypes) > 0 {
+		sub.eventTypes = map[string]bool{}
+		for _, t := range eventTypes {
+			sub.eventTypes[t] = true
+		}
+	}
+	b.subscribers = append(b.subscribers, sub)
+}
+
+// Run dispatches the outbox until ctx is done.
+func (b *EventBus) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventDispatchInterval)
+	defer ticker.Stop()
+	for {
+		b.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *EventBus) dispatch(ctx context.Context) {
+	log := logger.WithCtx(ctx, "EventBus.dispatch")
+	for {
+		events, err := b.repo.ClaimOutboxEvents(ctx, eventBatchSize, eventLease)
+		if err != nil {
+			log.WithError(err).Error("failed to claim outbox events")
+			return
+		}
+		for _, event := range events {
+			if err := b.process(ctx, event); err != nil {
+				log.WithError(err).WithField("event_id", event.ID).WithField("event_type", event.EventType).
+					Warn("failed to process event, it will be retried")
+				if err := b.repo.MarkOutboxEventFailed(ctx, event.ID, err.Error(), utils.Now().Add(eventRetryDelay(event.Attempts+1))); err != nil {
+					log.WithError(err).Error("failed to record event failure")
+				}
+				continue
+			}
+			if err := b.repo.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+				log.WithError(err).Error("failed to mark event published")
+			}
+		}
+		if len(events) < eventBatchSize {
+			return
+		}
+	}
+}
+
+// process hands event to every subscriber that did not process it yet, each in its own transaction so a
+// failing subscriber does not replay the others.
+func (b *EventBus) process(ctx context.Context, event model.OutboxEvent) error {
+	var failed []string
+	for _, sub := range b.subscribers {
+		if sub.eventTypes != nil && !sub.eventTypes[event.EventType] {
+			continue
+		}
+		err := b.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+			first, err := rp.MarkEventProcessed(ctx, sub.consumer, event.ID)
+			if err != nil || !first {
+				return err
+			}
+			return sub.handler.HandleEvent(ctx, rp, event)
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", sub.consumer, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%v", failed)
+	}
+	return nil
+}
+
+// eventRetryDelay is the wait after the attempt-th failed attempt.
+func eventRetryDelay(attempt int) time.Duration {
+	delay := eventFirstRetry
+	for i := 1; i < attempt && delay < eventMaxRetry; i++ {
+		delay *= 2
+	}
+	if delay > eventMaxRetry {
+		delay = eventMaxRetry
+	}
+	return delay
+}
+
+// BrokerPublisher is the subscriber that forwards the events to an external broker.
+type BrokerPublisher struct {
+	broker broker.Broker
+}
+
+func NewBrokerPublisher(b broker.Broker) *BrokerPublisher {
+	return &BrokerPublisher{broker: b}
+}
+
+func (p *BrokerPublisher) HandleEvent(ctx context.Context, _ repo.PGInterface, event model.OutboxEvent) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":            event.ID,
+		"type":          event.EventType,
+		"aggregateType": event.AggregateType,
+		"aggregateId":   event.AggregateId,
+		"companyId":     event.CompanyId,
+		"createdAt":     event.CreatedAt,
+		"data":          json.RawMessage(event.Payload.Bytes),
+	})
+	if err != nil {
+		return err
+	}
+	return p.broker.Publish(ctx, broker.Message{ID: event.ID.String(), Topic: event.EventType, Body: body})
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"strconv"
+	"time"
+)
+
+const exportCurrency = "VND"
+
+type ExportService struct {
+	repo   repo.PGInterface
+	report ReportInterface
+}
+
+func NewExportService(repo repo.PGInterface, report ReportInterface) ExportInterface {
+	return &ExportService{repo: repo, report: report}
+}
+
+type ExportInterface interface {
+	CheckFormat(format string) (string, error)
+	ExportTicketCompany(ctx context.Context, req model.ExportTicketReq, w utils.TableWriter) error
+	ExportReport(ctx context.Context, req model.ExportReportReq, w utils.TableWriter) error
+}
+
+// CheckFormat defaults format to csv, it is called before anything is written to the response.
+func (s *ExportService) CheckFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return utils.EXPORT_FORMAT_CSV, nil
+	case utils.EXPORT_FORMAT_CSV, utils.EXPORT_FORMAT_XLSX:
+		return format, nil
+	}
+	return "", apperror.New(apperror.InvalidParam, "format", format)
+}
+
+func exportTime(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(loc).Format(utils.TIME_FORMAT_FOR_QUERRY)
+}
+
+func exportMoney(v float64) string {
+	return utils.StrDelimitForSum(v, exportCurrency)
+}
+
+func exportRate(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 2, 64) + "%"
+}
+
+func (s *ExportService) ExportTicketCompany(ctx context.Context, req model.ExportTicketReq, w utils.TableWriter) error {
+	timezone, err := parkingLotTimezone(ctx, s.repo, req.ParkingLotID)
+	if err != nil {
+		return err
+	}
+	loc := utils.Location(timezone)
+	if err := w.WriteRow([]string{
+		"Mã vé", "Ngày tạo", "Trạng thái", "Bắt đầu", "Kết thúc", "Giờ vào", "Giờ ra",
+		"Xe", "Biển số", "Loại xe", "Khu", "Vị trí",
+		"Tiền vé", "Số lần gia hạn", "Tiền gia hạn", "Gia hạn đến", "Tổng tiền",
+	}); err != nil {
+		return err
+	}
+
+	if err := s.repo.ExportTicketCompany(ctx, req, func(row model.ExportTicketRow) error {
+		return w.WriteRow([]string{
+			row.ID.String(),
+			row.CreatedAt.In(loc).Format(utils.TIME_FORMAT_FOR_QUERRY),
+			row.State,
+			exportTime(row.StartTime, loc),
+			exportTime(row.EndTime, loc),
+			exportTime(row.EntryTime, loc),
+			exportTime(row.ExitTime, loc),
+			row.VehicleName,
+			row.VehicleNumber,
+			row.VehicleType,
+			row.BlockCode,
+			row.SlotName,
+			exportMoney(row.Total),
+			strconv.Itoa(row.ExtendCount),
+			exportMoney(row.ExtendTotal),
+			exportTime(row.ExtendedUntil, loc),
+			exportMoney(row.Total + row.ExtendTotal),
+		})
+	}); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (s *ExportService) ExportReport(ctx context.Context, req model.ExportReportReq, w utils.TableWriter) error {
+	timezone, err := parkingLotTimezone(ctx, s.repo, req.ParkingLotID)
+	if err != nil {
+		return err
+	}
+	loc := utils.Location(timezone)
+	var rows [][]string
+
+	switch req.Report {
+	case model.ReportRevenue:
+		items, err := s.report.GetRevenueReport(ctx, req.ReportReq)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{"Thời gian", "Bãi xe", "Khu", "Số vé", "Doanh thu"})
+		for _, item := range items {
+			rows = append(rows, []string{
+				item.Bucket.In(loc).Format(utils.TIME_FORMAT_FOR_QUERRY),
+				item.ParkingLotName,
+				item.BlockCode,
+				strconv.Itoa(item.TicketCount),
+				exportMoney(item.Revenue),
+			})
+		}
+	case model.ReportOccupancy:
+		items, err := s.report.GetOccupancyReport(ctx, req.ReportReq)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{"Thời gian", "Giờ đã đặt", "Giờ khả dụng", "Tỉ lệ lấp đầy"})
+		for _, item := range items {
+			rows = append(rows, []string{
+				item.Bucket.In(loc).Format(utils.TIME_FORMAT_FOR_QUERRY),
+				strconv.FormatFloat(item.BookedSeconds/3600, 'f', 2, 64),
+				strconv.FormatFloat(item.CapacitySeconds/3600, 'f', 2, 64),
+				exportRate(item.OccupancyRate),
+			})
+		}
+	case model.ReportTicketStats:
+		items, err := s.report.GetTicketStatsReport(ctx, req.ReportReq)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{"Thời gian", "Số vé", "Đã hủy", "Không đến", "Gia hạn",
+			"Thời gian đỗ TB (phút)", "Tỉ lệ hủy", "Tỉ lệ không đến", "Tỉ lệ gia hạn"})
+		for _, item := range items {
+			rows = append(rows, []string{
+				item.Bucket.In(loc).Format(utils.TIME_FORMAT_FOR_QUERRY),
+				strconv.Itoa(item.TotalTickets),
+				strconv.Itoa(item.CancelledTickets),
+				strconv.Itoa(item.NoShowTickets),
+				strconv.Itoa(item.ExtendedTickets),
+				strconv.FormatFloat(item.AvgStaySeconds/60, 'f', 0, 64),
+				exportRate(item.CancellationRate),
+				exportRate(item.NoShowRate),
+				exportRate(item.ExtensionRate),
+			})
+		}
+	case model.ReportTopTimeFrames:
+		items, err := s.report.GetTopTimeFrameReport(ctx, req.ReportReq)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{"Khung giờ", "Thời lượng", "Giá", "Số vé", "Doanh thu"})
+		for _, item := range items {
+			rows = append(rows, []string{
+				item.TimeFrameID.String(),
+				strconv.Itoa(item.Duration),
+				exportMoney(item.Cost),
+				strconv.Itoa(item.TicketCount),
+				exportMoney(item.Revenue),
+			})
+		}
+	default:
+		return apperror.New(apperror.InvalidParam, "report", req.Report)
+	}
+
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"io"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/valid"
+	"strings"
+)
+
+const maxLayoutErrors = 20
+
+type LayoutService struct {
+	repo repo.PGInterface
+}
+
+func NewLayoutService(repo repo.PGInterface) LayoutInterface {
+	return &LayoutService{repo: repo}
+}
+
+type LayoutInterface interface {
+	ParseLayout(format string, data []byte) (model.Layout, error)
+	ImportLayout(ctx context.Context, req model.ImportLayoutReq, layout model.Layout) (model.ImportLayoutRes, error)
+}
+
+// ParseLayout reads a layout file. A csv file has one row per slot with the header
+// block_code,block_description,slot_name,slot_description,vehicle_type, a row with an empty
+// slot_name declares a block without slots.
+func (s *LayoutService) ParseLayout(format string, data []byte) (layout model.Layout, err error) {
+	switch format {
+	case model.LayoutFormatJSON:
+		err = json.Unmarshal(data, &layout)
+	case model.LayoutFormatYAML:
+		err = yaml.Unmarshal(data, &layout)
+	case model.LayoutFormatCSV:
+		layout, err = parseLayoutCSV(data)
+	default:
+		return layout, apperror.New(apperror.InvalidParam, "format", format)
+	}
+	if err != nil {
+		return layout, apperror.New(apperror.InvalidLayout, err.Error())
+	}
+
+	if err := checkLayout(&layout); err != nil {
+		return layout, err
+	}
+	return layout, nil
+}
+
+func parseLayoutCSV(data []byte) (layout model.Layout, err error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return layout, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["block_code"]; !ok {
+		return layout, fmt.Errorf("missing column block_code")
+	}
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	blockIndex := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return layout, err
+		}
+		code := get(record, "block_code")
+		i, ok := blockIndex[code]
+		if !ok {
+			i = len(layout.Blocks)
+			blockIndex[code] = i
+			layout.Blocks = append(layout.Blocks, model.LayoutBlock{Code: code})
+		}
+		if description := get(record, "block_description"); description != "" {
+			layout.Blocks[i].Description = description
+		}
+		if name := get(record, "slot_name"); name != "" {
+			layout.Blocks[i].Slots = append(layout.Blocks[i].Slots, model.LayoutSlot{
+				Name:        name,
+				Description: get(record, "slot_description"),
+				VehicleType: get(record, "vehicle_type"),
+			})
+		}
+	}
+	return layout, nil
+}
+
+// checkLayout trims the names of layout and reports every missing or duplicated code and name at once.
+func checkLayout(layout *model.Layout) error {
+	var errs []string
+	if len(layout.Blocks) == 0 {
+		errs = append(errs, "layout has no blocks")
+	}
+
+	codes := map[string]bool{}
+	for i := range layout.Blocks {
+		block := &layout.Blocks[i]
+		block.Code = strings.TrimSpace(block.Code)
+		switch {
+		case block.Code == "":
+			errs = append(errs, fmt.Sprintf("blocks[%d]: code is required", i))
+		case codes[block.Code]:
+			errs = append(errs, fmt.Sprintf("blocks[%d]: duplicated code %s", i, block.Code))
+		}
+		codes[block.Code] = true
+
+		names := map[string]bool{}
+		for j := range block.Slots {
+			slot := &block.Slots[j]
+			slot.Name = strings.TrimSpace(slot.Name)
+			slot.VehicleType = strings.TrimSpace(slot.VehicleType)
+			switch {
+			case slot.Name == "":
+				errs = append(errs, fmt.Sprintf("blocks[%d].slots[%d]: name is required", i, j))
+			case names[slot.Name]:
+				errs = append(errs, fmt.Sprintf("blocks[%d].slots[%d]: duplicated name %s in block %s", i, j, slot.Name, block.Code))
+			}
+			names[slot.Name] = true
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxLayoutErrors {
+		errs = append(errs[:maxLayoutErrors], fmt.Sprintf("and %d more", len(errs)-maxLayoutErrors))
+	}
+	return apperror.New(apperror.InvalidLayout, strings.Join(errs, "; "))
+}
+
+// layoutPlan is what has to change for a parking lot to match an imported layout.
+type layoutPlan struct {
+	createBlocks []model.Block
+	updateBlocks []model.Block
+	createSlots  []model.ParkingSlot
+	updateSlots  []model.ParkingSlot
+	deleteSlots  []model.ParkingSlot
+	deleteBlocks []model.Block
+	changes      []model.LayoutChange
+}
+
+func slotSummary(description, vehicleType string) *string {
+	s := fmt.Sprintf("description=%q vehicle_type=%q", description, vehicleType)
+	return &s
+}
+
+func planLayout(parkingLotID uuid.UUID, current []model.Block, layout model.Layout, prune bool) layoutPlan {
+	plan := layoutPlan{}
+	existing := map[string]model.Block{}
+	for _, block := range current {
+		existing[block.Code] = block
+	}
+
+	for _, in := range layout.Blocks {
+		block, ok := existing[in.Code]
+		if !ok {
+			newBlock := model.Block{Code: in.Code, Description: in.Description, Slot: len(in.Slots), ParkingLotID: parkingLotID}
+			for _, slot := range in.Slots {
+				newBlock.ParkingSLots = append(newBlock.ParkingSLots, model.ParkingSlot{Name: slot.Name, Description: slot.Description, VehicleType: slot.VehicleType})
+			}
+			plan.createBlocks = append(plan.createBlocks, newBlock)
+			plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionCreate, Type: model.LayoutTypeBlock, BlockCode: in.Code})
+			for _, slot := range in.Slots {
+				plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionCreate, Type: model.LayoutTypeSlot,
+					BlockCode: in.Code, SlotName: slot.Name, After: slotSummary(slot.Description, slot.VehicleType)})
+			}
+			continue
+		}
+		delete(existing, in.Code)
+
+		slots := map[string]model.ParkingSlot{}
+		for _, slot := range block.ParkingSLots {
+			slots[slot.Name] = slot
+		}
+		for _, slot := range in.Slots {
+			old, ok := slots[slot.Name]
+			if !ok {
+				plan.createSlots = append(plan.createSlots, model.ParkingSlot{Name: slot.Name, Description: slot.Description, VehicleType: slot.VehicleType, BlockID: block.ID})
+				plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionCreate, Type: model.LayoutTypeSlot,
+					BlockCode: in.Code, SlotName: slot.Name, After: slotSummary(slot.Description, slot.VehicleType)})
+				continue
+			}
+			delete(slots, slot.Name)
+			if old.Description != slot.Description || old.VehicleType != slot.VehicleType {
+				updated := old
+				updated.Description, updated.VehicleType = slot.Description, slot.VehicleType
+				plan.updateSlots = append(plan.updateSlots, updated)
+				plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionUpdate, Type: model.LayoutTypeSlot, ID: valid.UUIDPointer(old.ID),
+					BlockCode: in.Code, SlotName: slot.Name, Before: slotSummary(old.Description, old.VehicleType), After: slotSummary(slot.Description, slot.VehicleType)})
+			}
+		}
+
+		slotCount := len(in.Slots) + len(slots)
+		if prune {
+			slotCount = len(in.Slots)
+			for _, slot := range block.ParkingSLots {
+				if _, ok := slots[slot.Name]; !ok {
+					continue
+				}
+				plan.deleteSlots = append(plan.deleteSlots, slot)
+				plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionDelete, Type: model.LayoutTypeSlot, ID: valid.UUIDPointer(slot.ID),
+					BlockCode: in.Code, SlotName: slot.Name})
+			}
+		}
+
+		if block.Description != in.Description || block.Slot != slotCount {
+			if block.Description != in.Description {
+				plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionUpdate, Type: model.LayoutTypeBlock, ID: valid.UUIDPointer(block.ID),
+					BlockCode: in.Code, Before: valid.StringPointer(block.Description), After: valid.StringPointer(in.Description)})
+			}
+			block.Description, block.Slot, block.ParkingSLots = in.Description, slotCount, nil
+			plan.updateBlocks = append(plan.updateBlocks, block)
+		}
+	}
+
+	if prune {
+		for _, block := range current {
+			if _, ok := existing[block.Code]; !ok {
+				continue
+			}
+			plan.deleteSlots = append(plan.deleteSlots, block.ParkingSLots...)
+			block.ParkingSLots = nil
+			plan.deleteBlocks = append(plan.deleteBlocks, block)
+			plan.changes = append(plan.changes, model.LayoutChange{Action: model.LayoutActionDelete, Type: model.LayoutTypeBlock, ID: valid.UUIDPointer(block.ID), BlockCode: block.Code})
+		}
+	}
+	return plan
+}
+
+// ImportLayout diffs layout against the current blocks and slots of the parking lot and, unless it is a
+// dry run, applies the diff in one transaction. Slots that still have tickets to serve are never pruned.
+func (s *LayoutService) ImportLayout(ctx context.Context, req model.ImportLayoutReq, layout model.Layout) (model.ImportLayoutRes, error) {
+	res := model.ImportLayoutRes{DryRun: req.DryRun}
+
+	parkingLotID, err := uuid.Parse(valid.String(req.ParkingLotID))
+	if err != nil {
+		return res, apperror.New(apperror.InvalidID)
+	}
+	parkingLot, err := s.repo.GetOneParkingLot(ctx, parkingLotID)
+	if err != nil {
+		return res, err
+	}
+	current, err := s.repo.GetParkingLotLayout(ctx, parkingLot.ID)
+	if err != nil {
+		return res, err
+	}
+
+	plan := planLayout(parkingLot.ID, current, layout, req.Prune)
+	res.Changes = plan.changes
+	for _, change := range plan.changes {
+		switch change.Action {
+		case model.LayoutActionCreate:
+			res.Created++
+		case model.LayoutActionUpdate:
+			res.Updated++
+		case model.LayoutActionDelete:
+			res.Deleted++
+		}
+	}
+
+	if req.DryRun {
+		return res, checkSlotsNotBusy(ctx, s.repo, plan.deleteSlots)
+	}
+	if len(plan.changes) == 0 && len(plan.updateBlocks) == 0 {
+		return res, nil
+	}
+
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// no ticket can be booked on a slot to delete between the check and the delete
+		if err := rp.LockParkingLot(ctx, parkingLot.ID); err != nil {
+			return err
+		}
+		if err := checkSlotsNotBusy(ctx, rp, plan.deleteSlots); err != nil {
+			return err
+		}
+		for _, block := range plan.createBlocks {
+			slots := block.ParkingSLots
+			block.ParkingSLots = nil
+			if err := rp.CreateBlock(ctx, &block); err != nil {
+				return err
+			}
+			for i := range slots {
+				slots[i].BlockID = block.ID
+			}
+			if err := rp.CreateParkingSlots(ctx, slots); err != nil {
+				return err
+			}
+		}
+		for i := range plan.updateBlocks {
+			if err := rp.UpdateBlock(ctx, &plan.updateBlocks[i]); err != nil {
+				return err
+			}
+		}
+		if err := rp.CreateParkingSlots(ctx, plan.createSlots); err != nil {
+			return err
+		}
+		for i := range plan.updateSlots {
+			if err := rp.UpdateParkingSlot(ctx, &plan.updateSlots[i]); err != nil {
+				return err
+			}
+		}
+		for _, slot := range plan.deleteSlots {
+			if err := rp.DeleteParkingSlot(ctx, slot.ID); err != nil {
+				return err
+			}
+		}
+		for _, block := range plan.deleteBlocks {
+			if err := rp.DeleteBlock(ctx, block.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// checkSlotsNotBusy fails with the names of the slots that still have upcoming tickets.
+func checkSlotsNotBusy(ctx context.Context, r repo.PGInterface, slots []model.ParkingSlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	slotIDs := make([]uuid.UUID, 0, len(slots))
+	for _, slot := range slots {
+		slotIDs = append(slotIDs, slot.ID)
+	}
+	busy, err := r.GetSlotIDsWithUpcomingTicket(ctx, slotIDs)
+	if err != nil {
+		return err
+	}
+	if len(busy) == 0 {
+		return nil
+	}
+	busySet := map[uuid.UUID]bool{}
+	for _, id := range busy {
+		busySet[id] = true
+	}
+	var names []string
+	for _, slot := range slots {
+		if busySet[slot.ID] {
+			names = append(names, slot.Name)
+		}
+	}
+	return apperror.New(apperror.LayoutSlotsInUse, strings.Join(names, ", "))
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"time"
+)
+
+const (
+	// bookingEndingNotice is how long before the end of a ticket its owner is reminded.
+	bookingEndingNotice = 15 * time.Minute
+	// overstayWindow bounds how late an overstay is still reported.
+	overstayWindow = 24 * time.Hour
+	// notificationSweepInterval is the period of the job that sends the reminders.
+	notificationSweepInterval = time.Minute
+)
+
+type NotificationService struct {
+	repo      repo.PGInterface
+	providers map[string]notification.Provider
+}
+
+func NewNotificationService(repo repo.PGInterface, providers map[string]notification.Provider) NotificationInterface {
+	return &NotificationService{repo: repo, providers: providers}
+}
+
+type NotificationInterface interface {
+	NotifyTicket(ctx context.Context, ticket model.Ticket, event string)
+	NotifyWaitlist(ctx context.Context, entry model.Waitlist, event string)
+	NotifyCompany(ctx context.Context, company model.Company, event string, data notification.Data)
+	GetNotificationPreference(ctx context.Context, userID uuid.UUID) (model.NotificationPreference, error)
+	UpdateNotificationPreference(ctx context.Context, req model.NotificationPreferenceReq) (model.NotificationPreference, error)
+	RegisterDeviceToken(ctx context.Context, req model.DeviceTokenReq) (model.DeviceToken, error)
+	DeleteDeviceToken(ctx context.Context, req model.DeviceTokenReq) error
+	GetListNotification(ctx context.Context, req model.ListNotificationReq) (model.ListNotificationRes, error)
+	Run(ctx context.Context)
+}
+
+// NotifyTicket tells the owner of ticket about event. Failures are logged and recorded, they never fail
+// the operation that triggered the notification.
+func (s *NotificationService) NotifyTicket(ctx context.Context, ticket model.Ticket, event string) {
+	if ticket.UserId == nil {
+		return
+	}
+	data := notification.Data{
+		Start: valid.DayTime(ticket.StartTime),
+		End:   valid.DayTime(ticket.EndTime),
+	}
+	s.fillParkingLot(ctx, ticket.ParkingLotId, &data)
+	if ticket.ParkingSlotId != nil {
+		if slot, err := s.repo.GetOneParkingSlot(ctx, *ticket.ParkingSlotId); err == nil {
+			data.Slot = slot.Name
+		}
+	}
+	s.notify(ctx, *ticket.UserId, &ticket.ID, event, data)
+}
+
+func (s *NotificationService) NotifyWaitlist(ctx context.Context, entry model.Waitlist, event string) {
+	if entry.UserId == nil {
+		return
+	}
+	data := notification.Data{
+		Start:    entry.StartTime,
+		End:      entry.EndTime,
+		Deadline: valid.DayTime(entry.OfferExpiresAt),
+	}
+	s.fillParkingLot(ctx, entry.ParkingLotId, &data)
+	if entry.ParkingSlotId != nil {
+		if slot, err := s.repo.GetOneParkingSlot(ctx, *entry.ParkingSlotId); err == nil {
+			data.Slot = slot.Name
+		}
+	}
+	s.notify(ctx, *entry.UserId, nil, event, data)
+}
+
+// NotifyCompany emails event to the company. Companies have no notification preference nor user, so the message
+// is only logged when it fails and not recorded.
+func (s *NotificationService) NotifyCompany(ctx context.Context, company model.Company, event string, data notification.Data) {
+	log := logger.WithCtx(ctx, "NotificationService.NotifyCompany").WithField("company_id", company.ID).WithField("event", event)
+
+	data.Company = company.Name
+	title, body, err := notification.Render(event, notification.LangVI, data)
+	if err != nil {
+		log.WithError(err).Error("failed to render notification")
+		return
+	}
+	provider, ok := s.providers[notification.ChannelEmail]
+	if !ok || company.Email == "" {
+		return
+	}
+	msg := notification.Message{Channel: notification.ChannelEmail, To: company.Email, Title: title, Body: body}
+	if err := provider.Send(ctx, msg); err != nil {
+		log.WithError(err).Error("failed to send notification")
+	}
+}
+
+// fillParkingLot sets the name of the parking lot and the zone its times are shown in.
+func (s *NotificationService) fillParkingLot(ctx context.Context, id *uuid.UUID, data *notification.Data) {
+	if id == nil {
+		return
+	}
+	lot, err := s.repo.GetOneParkingLot(ctx, *id)
+	if err != nil {
+		return
+	}
+	data.ParkingLot = lot.Name
+	data.Location = utils.Location(lot.Timezone)
+}
+
+// notify sends event on every channel the user enabled and records one notification per message, or a
+// skipped one when the user cannot be reached so reminders are not retried forever.
+func (s *NotificationService) notify(ctx context.Context, userID uuid.UUID, ticketID *uuid.UUID, event string, data notification.Data) {
+	log := logger.WithCtx(ctx, "NotificationService.notify").WithField("user_id", userID).WithField("event", event)
+
+	pref, err := s.GetNotificationPreference(ctx, userID)
+	if err != nil {
+		log.WithError(err).Error("failed to get notification preference")
+		return
+	}
+	title, body, err := notification.Render(event, pref.Language, data)
+	if err != nil {
+		log.WithError(err).Error("failed to render notification")
+		return
+	}
+
+	var messages []notification.Message
+	if pref.Push {
+		tokens, err := s.repo.GetDeviceTokens(ctx, userID)
+		if err != nil {
+			log.WithError(err).Error("failed to get device tokens")
+		}
+		for _, token := range tokens {
+			messages = append(messages, notification.Message{Channel: notification.ChannelPush, To: token.Token})
+		}
+	}
+	if pref.SMS || pref.Email {
+		user, err := s.repo.GetOneUserById(ctx, userID, nil)
+		if err != nil {
+			log.WithError(err).Error("failed to get user")
+		} else {
+			if pref.SMS && user.PhoneNumber != "" {
+				messages = append(messages, notification.Message{Channel: notification.ChannelSMS, To: user.PhoneNumber})
+			}
+			if pref.Email && user.Email != "" {
+				messages = append(messages, notification.Message{Channel: notification.ChannelEmail, To: user.Email})
+			}
+		}
+	}
+
+	record := func(channel, status string, err error) {
+		n := &model.Notification{UserId: userID, TicketId: ticketID, Event: event, Channel: channel, Title: title, Body: body, Status: status}
+		if err != nil {
+			n.Error = err.Error()
+		}
+		if err := s.repo.CreateNotification(ctx, n); err != nil {
+			log.WithError(err).Error("failed to record notification")
+		}
+	}
+	if len(messages) == 0 {
+		record("", model.NotificationStatusSkipped, nil)
+		return
+	}
+	for _, msg := range messages {
+		msg.Title, msg.Body = title, body
+		provider, ok := s.providers[msg.Channel]
+		if !ok {
+			continue
+		}
+		if err := provider.Send(ctx, msg); err != nil {
+			log.WithError(err).WithField("channel", msg.Channel).Error("failed to send notification")
+			record(msg.Channel, model.NotificationStatusFailed, err)
+			continue
+		}
+		record(msg.Channel, model.NotificationStatusSent, nil)
+	}
+}
+
+// GetNotificationPreference returns the preference of the user, the default one when they never set it.
+func (s *NotificationService) GetNotificationPreference(ctx context.Context, userID uuid.UUID) (model.NotificationPreference, error) {
+	pref, err := s.repo.GetNotificationPreference(ctx, userID)
+	if err != nil {
+		return model.NotificationPreference{}, err
+	}
+	if pref == nil {
+		return model.NotificationPreference{UserId: userID, Push: true, Email: true, Language: notification.LangVI}, nil
+	}
+	return *pref, nil
+}
+
+func (s *NotificationService) UpdateNotificationPreference(ctx context.Context, req model.NotificationPreferenceReq) (model.NotificationPreference, error) {
+	if req.Language != nil && *req.Language != notification.LangVI && *req.Language != notification.LangEN {
+		return model.NotificationPreference{}, apperror.New(apperror.InvalidParam, "language", *req.Language)
+	}
+	pref, err := s.GetNotificationPreference(ctx, valid.UUID(req.UserId))
+	if err != nil {
+		return pref, err
+	}
+
+	if req.Push != nil {
+		pref.Push = *req.Push
+	}
+	if req.SMS != nil {
+		pref.SMS = *req.SMS
+	}
+	if req.Email != nil {
+		pref.Email = *req.Email
+	}
+	if req.Language != nil {
+		pref.Language = *req.Language
+	}
+	if err := s.repo.SaveNotificationPreference(ctx, &pref); err != nil {
+		return pref, err
+	}
+	return pref, nil
+}
+
+func (s *NotificationService) RegisterDeviceToken(ctx context.Context, req model.DeviceTokenReq) (model.DeviceToken, error) {
+	token := model.DeviceToken{
+		UserId:   valid.UUID(req.UserId),
+		Token:    valid.String(req.Token),
+		Platform: valid.String(req.Platform),
+	}
+	if err := s.repo.SaveDeviceToken(ctx, &token); err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func (s *NotificationService) DeleteDeviceToken(ctx context.Context, req model.DeviceTokenReq) error {
+	return s.repo.DeleteDeviceToken(ctx, valid.UUID(req.UserId), valid.String(req.Token))
+}
+
+func (s *NotificationService) GetListNotification(ctx context.Context, req model.ListNotificationReq) (model.ListNotificationRes, error) {
+	return s.repo.GetListNotification(ctx, req)
+}
+
+// Run reminds the drivers whose ticket ends soon or is over until ctx is done.
+func (s *NotificationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(notificationSweepInterval)
+	defer ticker.Stop()
+	for {
+		now := utils.Now()
+		s.remind(ctx, notification.EventBookingEnding, now, now.Add(bookingEndingNotice))
+		s.remind(ctx, notification.EventOverstay, now.Add(-overstayWindow), now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *NotificationService) remind(ctx context.Context, event string, from, to time.Time) {
+	tickets, err := s.repo.GetTicketToNotify(ctx, event, from, to)
+	if err != nil {
+		logger.WithCtx(ctx, "NotificationService.remind").WithField("event", event).WithError(err).Error("failed to get tickets to notify")
+		return
+	}
+	for _, ticket := range tickets {
+		ticket.EndTime = valid.DayTimePointer(ticket.DueTime)
+		s.NotifyTicket(ctx, ticket.Ticket, event)
+	}
+}
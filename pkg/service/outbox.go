@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+)
+
+// ticketEventTypes maps the notification sent for a ticket change to the event written to the outbox.
+var ticketEventTypes = map[string]string{
+	notification.EventBookingConfirmed: model.EventTicketCreated,
+	notification.EventBookingCancelled: model.EventTicketCancelled,
+	notification.EventBookingRelocated: model.EventTicketRelocated,
+}
+
+// emitEvent writes a domain event about an aggregate to the outbox. It is meant to run in the transaction
+// of the change so the event is recorded if and only if the change is.
+func emitEvent(ctx context.Context, rp repo.PGInterface, event model.OutboxEvent, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	if err := event.Payload.Set(data); err != nil {
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	return rp.CreateOutboxEvent(ctx, &event)
+}
+
+// emitTicketEvent writes eventType about ticket to the outbox, on behalf of the company of its parking lot.
+func emitTicketEvent(ctx context.Context, rp repo.PGInterface, ticket model.Ticket, eventType string) error {
+	event := model.OutboxEvent{
+		AggregateType: "ticket",
+		AggregateId:   ticket.ID,
+		EventType:     eventType,
+	}
+	if ticket.ParkingLotId != nil {
+		lot, err := rp.GetOneParkingLot(ctx, *ticket.ParkingLotId)
+		if err != nil {
+			return err
+		}
+		event.CompanyId = &lot.CompanyID
+	}
+	return emitEvent(ctx, rp, event, ticket)
+}
+
+// emitTicketChanges writes the events of the tickets moved or cancelled by a change to the outbox.
+func emitTicketChanges(ctx context.Context, rp repo.PGInterface, changes []ticketChange) error {
+	for _, change := range changes {
+		eventType, ok := ticketEventTypes[change.event]
+		if !ok {
+			continue
+		}
+		if err := emitTicketEvent(ctx, rp, change.ticket, eventType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
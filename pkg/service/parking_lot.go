@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
@@ -10,11 +12,13 @@ import (
 )
 
 type ParkingLotService struct {
-	repo repo.PGInterface
+	repo     repo.PGInterface
+	search   SearchServiceInterface
+	notifier NotificationInterface
 }
 
-func NewParkingLotService(repo repo.PGInterface) ParkingLotInterface {
-	return &ParkingLotService{repo: repo}
+func NewParkingLotService(repo repo.PGInterface, search SearchServiceInterface, notifier NotificationInterface) ParkingLotInterface {
+	return &ParkingLotService{repo: repo, search: search, notifier: notifier}
 }
 
 type ParkingLotInterface interface {
@@ -22,7 +26,7 @@ type ParkingLotInterface interface {
 	GetListParkingLot(ctx context.Context, req model.ListParkingLotReq) (model.ListParkingLotRes, error)
 	GetOneParkingLot(ctx context.Context, id uuid.UUID) (model.ParkingLot, error)
 	UpdateParkingLot(ctx context.Context, req model.ParkingLotReq) (model.ParkingLot, error)
-	DeleteParkingLot(ctx context.Context, id uuid.UUID) error
+	DeleteParkingLot(ctx context.Context, id uuid.UUID, req model.DeleteReq) (model.DeleteRes, error)
 	GetListParkingLotCompany(ctx context.Context, req model.GetListParkingLotReq) (model.ListParkingLotRes, error)
 }
 
@@ -30,7 +34,26 @@ func (s *ParkingLotService) GetListParkingLotCompany(ctx context.Context, req mo
 	return s.repo.GetListParkingLotCompany(ctx, req)
 }
 
+// checkTimezone rejects a time zone that is not in the IANA database, an empty one keeps the default.
+func checkTimezone(timezone *string) error {
+	if timezone != nil && !utils.ValidTimezone(*timezone) {
+		return apperror.New(apperror.InvalidParam, "timezone", *timezone)
+	}
+	return nil
+}
+
+// CreateParkingLot creates a parking lot of a company, only once an admin approved the company.
 func (s *ParkingLotService) CreateParkingLot(ctx context.Context, req model.ParkingLotReq) (*model.ParkingLot, error) {
+	if err := checkTimezone(req.Timezone); err != nil {
+		return nil, err
+	}
+	company, err := s.repo.GetOneCompany(ctx, valid.UUID(req.CompanyID))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCompanyActive(company); err != nil {
+		return nil, err
+	}
 	ParkingLot := &model.ParkingLot{
 		Name:        valid.String(req.Name),
 		Description: valid.String(req.Description),
@@ -40,11 +63,17 @@ func (s *ParkingLotService) CreateParkingLot(ctx context.Context, req model.Park
 		Lat:         valid.Float64(req.Lat),
 		Long:        valid.Float64(req.Long),
 		CompanyID:   valid.UUID(req.CompanyID),
+		Amenities:   valid.StringSlice(req.Amenities),
+		Timezone:    utils.TIMEZONE_VN,
+	}
+	if req.Timezone != nil {
+		ParkingLot.Timezone = *req.Timezone
 	}
 
 	if err := s.repo.CreateParkingLot(ctx, ParkingLot); err != nil {
 		return nil, err
 	}
+	s.syncSearchIndex(ctx, ParkingLot.ID)
 	return ParkingLot, nil
 }
 
@@ -57,6 +86,9 @@ func (s *ParkingLotService) GetOneParkingLot(ctx context.Context, id uuid.UUID)
 }
 
 func (s *ParkingLotService) UpdateParkingLot(ctx context.Context, req model.ParkingLotReq) (model.ParkingLot, error) {
+	if err := checkTimezone(req.Timezone); err != nil {
+		return model.ParkingLot{}, err
+	}
 	ParkingLot, err := s.repo.GetOneParkingLot(ctx, valid.UUID(req.ID))
 	if err != nil {
 		return ParkingLot, err
@@ -66,10 +98,53 @@ func (s *ParkingLotService) UpdateParkingLot(ctx context.Context, req model.Park
 	if err := s.repo.UpdateParkingLot(ctx, &ParkingLot); err != nil {
 		return ParkingLot, err
 	}
+	s.syncSearchIndex(ctx, ParkingLot.ID)
 
 	return ParkingLot, nil
 }
 
-func (s *ParkingLotService) DeleteParkingLot(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteParkingLot(ctx, id)
+// DeleteParkingLot deletes the parking lot with its blocks, slots and time frames. Its future tickets
+// cannot be moved elsewhere so a forced delete cancels them.
+func (s *ParkingLotService) DeleteParkingLot(ctx context.Context, id uuid.UUID, req model.DeleteReq) (res model.DeleteRes, err error) {
+	if _, err := s.repo.GetOneParkingLot(ctx, id); err != nil {
+		return res, err
+	}
+
+	var changes []ticketChange
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// no ticket can be booked in the parking lot between the check of its tickets and the delete
+		if err := rp.LockParkingLot(ctx, id); err != nil {
+			return err
+		}
+		if res, changes, err = releaseTickets(ctx, rp, model.TicketScope{ParkingLotID: &id}, releaseOptions{force: req.Force, forceParam: "force"}); err != nil {
+			return err
+		}
+		if err := rp.DeleteParkingSlotByParkingLot(ctx, id); err != nil {
+			return err
+		}
+		if err := rp.DeleteBlockByParkingLot(ctx, id); err != nil {
+			return err
+		}
+		if err := rp.DeleteTimeFrameByParkingLotID(ctx, id.String(), nil); err != nil {
+			return err
+		}
+		return rp.DeleteParkingLot(ctx, id)
+	})
+	if err != nil {
+		return res, err
+	}
+	notifyTicketChanges(ctx, s.notifier, changes)
+
+	if err := s.search.RemoveParkingLot(ctx, id); err != nil {
+		logger.WithCtx(ctx, utils.GetCurrentCaller(s, 0)).WithError(err).Error("Failed to remove parking lot from search index")
+	}
+	return res, nil
+}
+
+// syncSearchIndex pushes the parking lot to the search index, a failure only leaves the index
+// stale until the next reindex so it must not fail the request.
+func (s *ParkingLotService) syncSearchIndex(ctx context.Context, id uuid.UUID) {
+	if err := s.search.IndexParkingLot(ctx, id); err != nil {
+		logger.WithCtx(ctx, utils.GetCurrentCaller(s, 1)).WithError(err).Error("Failed to index parking lot")
+	}
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
@@ -10,11 +11,12 @@ import (
 )
 
 type ParkingSlotService struct {
-	repo repo.PGInterface
+	repo     repo.PGInterface
+	notifier NotificationInterface
 }
 
-func NewParkingSlotService(repo repo.PGInterface) ParkingSlotInterface {
-	return &ParkingSlotService{repo: repo}
+func NewParkingSlotService(repo repo.PGInterface, notifier NotificationInterface) ParkingSlotInterface {
+	return &ParkingSlotService{repo: repo, notifier: notifier}
 }
 
 type ParkingSlotInterface interface {
@@ -23,17 +25,25 @@ type ParkingSlotInterface interface {
 	GetAvailableParkingSlot(ctx context.Context, req model.AvailableParkingSlotReq) (model.ListBlockRes, error)
 	GetOneParkingSlot(ctx context.Context, id uuid.UUID) (model.ParkingSlot, error)
 	UpdateParkingSlot(ctx context.Context, req model.ParkingSlotReq) (model.ParkingSlot, error)
-	DeleteParkingSlot(ctx context.Context, id uuid.UUID) error
+	DeleteParkingSlot(ctx context.Context, id uuid.UUID, req model.DeleteReq) (model.DeleteRes, error)
 }
 
 func (s *ParkingSlotService) CreateParkingSlot(ctx context.Context, req model.ParkingSlotReq) (*model.ParkingSlot, error) {
 	ParkingSlot := &model.ParkingSlot{
-		Name:        valid.String(req.Name),
-		Description: valid.String(req.Description),
-		BlockID:     valid.UUID(req.BlockID),
+		Name:               valid.String(req.Name),
+		Description:        valid.String(req.Description),
+		VehicleType:        valid.String(req.VehicleType),
+		DistanceToEntrance: valid.Float64(req.DistanceToEntrance),
+		BlockID:            valid.UUID(req.BlockID),
 	}
 
-	if err := s.repo.CreateParkingSlot(ctx, ParkingSlot); err != nil {
+	err := s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.CreateParkingSlot(ctx, ParkingSlot); err != nil {
+			return err
+		}
+		return rp.RefreshBlockSlotCount(ctx, ParkingSlot.BlockID)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return ParkingSlot, nil
@@ -42,7 +52,15 @@ func (s *ParkingSlotService) CreateParkingSlot(ctx context.Context, req model.Pa
 func (s *ParkingSlotService) GetListParkingSlot(ctx context.Context, req model.ListParkingSlotReq) (model.ListParkingSlotRes, error) {
 	return s.repo.GetListParkingSlot(ctx, req)
 }
+
+// GetAvailableParkingSlot lists the slots free for the whole half-open range [start, end), a booking
+// ending when another starts does not overlap it.
 func (s *ParkingSlotService) GetAvailableParkingSlot(ctx context.Context, req model.AvailableParkingSlotReq) (model.ListBlockRes, error) {
+	if !valid.DayTime(req.Start).Before(valid.DayTime(req.End)) {
+		return model.ListBlockRes{}, apperror.New(apperror.InvalidTimeRange)
+	}
+	req.Start = valid.DayTimePointer(req.Start.UTC())
+	req.End = valid.DayTimePointer(req.End.UTC())
 	res, err := s.repo.GetAvailableParkingSlot(ctx, req)
 	if err != nil {
 		return model.ListBlockRes{}, err
@@ -77,14 +95,58 @@ func (s *ParkingSlotService) UpdateParkingSlot(ctx context.Context, req model.Pa
 		return ParkingSlot, err
 	}
 
+	oldBlockID := ParkingSlot.BlockID
 	utils.Sync(req, &ParkingSlot)
-	if err := s.repo.UpdateParkingSlot(ctx, &ParkingSlot); err != nil {
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.UpdateParkingSlot(ctx, &ParkingSlot); err != nil {
+			return err
+		}
+		if oldBlockID == ParkingSlot.BlockID {
+			return nil
+		}
+		if err := rp.RefreshBlockSlotCount(ctx, oldBlockID); err != nil {
+			return err
+		}
+		return rp.RefreshBlockSlotCount(ctx, ParkingSlot.BlockID)
+	})
+	if err != nil {
 		return ParkingSlot, err
 	}
 
 	return ParkingSlot, nil
 }
 
-func (s *ParkingSlotService) DeleteParkingSlot(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteParkingSlot(ctx, id)
+// DeleteParkingSlot deletes the slot, a forced delete moves its future tickets to another free slot
+// of the parking lot, preferably for the same vehicle type, and cancels them otherwise.
+func (s *ParkingSlotService) DeleteParkingSlot(ctx context.Context, id uuid.UUID, req model.DeleteReq) (res model.DeleteRes, err error) {
+	ParkingSlot, err := s.repo.GetOneParkingSlot(ctx, id)
+	if err != nil {
+		return res, err
+	}
+
+	block, err := s.repo.GetOneBlock(ctx, ParkingSlot.BlockID)
+	if err != nil {
+		return res, err
+	}
+
+	var changes []ticketChange
+	relocate := &model.FreeSlotReq{VehicleType: ParkingSlot.VehicleType, ExcludeSlotIDs: []uuid.UUID{id}}
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// nothing is booked on the slot, or on the slots its tickets move to, until it is deleted
+		if err := rp.LockParkingLot(ctx, block.ParkingLotID); err != nil {
+			return err
+		}
+		if res, changes, err = releaseTickets(ctx, rp, model.TicketScope{ParkingSlotIDs: []uuid.UUID{id}}, releaseOptions{force: req.Force, forceParam: "force", relocate: relocate}); err != nil {
+			return err
+		}
+		if err := rp.DeleteParkingSlot(ctx, id); err != nil {
+			return err
+		}
+		return rp.RefreshBlockSlotCount(ctx, ParkingSlot.BlockID)
+	})
+	if err != nil {
+		return res, err
+	}
+	notifyTicketChanges(ctx, s.notifier, changes)
+	return res, nil
 }
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+)
+
+const (
+	defaultReportLimit = 10
+	maxReportLimit     = 100
+)
+
+type ReportService struct {
+	repo repo.PGInterface
+}
+
+func NewReportService(repo repo.PGInterface) ReportInterface {
+	return &ReportService{repo: repo}
+}
+
+type ReportInterface interface {
+	GetRevenueReport(ctx context.Context, req model.ReportReq) ([]model.RevenueReportItem, error)
+	GetOccupancyReport(ctx context.Context, req model.ReportReq) ([]model.OccupancyReportItem, error)
+	GetTicketStatsReport(ctx context.Context, req model.ReportReq) ([]model.TicketStatsReportItem, error)
+	GetTopTimeFrameReport(ctx context.Context, req model.ReportReq) ([]model.TimeFrameReportItem, error)
+}
+
+// checkReportReq fills the defaults of req and rejects the ranges and options the report queries do not support.
+func checkReportReq(ctx context.Context, rp repo.PGInterface, req *model.ReportReq) error {
+	if req.CompanyID == nil && req.ParkingLotID == nil {
+		return apperror.New(apperror.ReportScopeRequired)
+	}
+	if !req.From.Before(*req.To) {
+		return apperror.New(apperror.InvalidTimeRange)
+	}
+
+	switch req.Granularity {
+	case "":
+		req.Granularity = model.ReportGranularityDay
+	case model.ReportGranularityDay, model.ReportGranularityWeek, model.ReportGranularityMonth:
+	default:
+		return apperror.New(apperror.InvalidParam, "granularity", req.Granularity)
+	}
+
+	switch req.GroupBy {
+	case "", model.ReportGroupByParkingLot, model.ReportGroupByBlock:
+	default:
+		return apperror.New(apperror.InvalidParam, "group_by", req.GroupBy)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultReportLimit
+	}
+	if req.Limit > maxReportLimit {
+		req.Limit = maxReportLimit
+	}
+
+	var err error
+	req.Timezone, err = parkingLotTimezone(ctx, rp, req.ParkingLotID)
+	return err
+}
+
+// parkingLotTimezone returns the zone of the parking lot, the default one when there is no parking lot.
+func parkingLotTimezone(ctx context.Context, rp repo.PGInterface, parkingLotID *string) (string, error) {
+	if parkingLotID == nil {
+		return utils.TIMEZONE_VN, nil
+	}
+	id, err := uuid.Parse(*parkingLotID)
+	if err != nil {
+		return "", apperror.New(apperror.InvalidID)
+	}
+	lot, err := rp.GetOneParkingLot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return lot.Timezone, nil
+}
+
+func rate(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total
+}
+
+func (s *ReportService) GetRevenueReport(ctx context.Context, req model.ReportReq) ([]model.RevenueReportItem, error) {
+	if err := checkReportReq(ctx, s.repo, &req); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetRevenueReport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.Location(req.Timezone)
+	for i := range res {
+		res[i].Bucket = res[i].Bucket.In(loc)
+	}
+	return res, nil
+}
+
+func (s *ReportService) GetOccupancyReport(ctx context.Context, req model.ReportReq) ([]model.OccupancyReportItem, error) {
+	if err := checkReportReq(ctx, s.repo, &req); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetOccupancyReport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.Location(req.Timezone)
+	for i := range res {
+		res[i].Bucket = res[i].Bucket.In(loc)
+		res[i].OccupancyRate = rate(res[i].BookedSeconds, res[i].CapacitySeconds)
+	}
+	return res, nil
+}
+
+func (s *ReportService) GetTicketStatsReport(ctx context.Context, req model.ReportReq) ([]model.TicketStatsReportItem, error) {
+	if err := checkReportReq(ctx, s.repo, &req); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetTicketStatsReport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.Location(req.Timezone)
+	for i := range res {
+		total := float64(res[i].TotalTickets)
+		res[i].Bucket = res[i].Bucket.In(loc)
+		res[i].CancellationRate = rate(float64(res[i].CancelledTickets), total)
+		res[i].NoShowRate = rate(float64(res[i].NoShowTickets), total)
+		res[i].ExtensionRate = rate(float64(res[i].ExtendedTickets), total)
+	}
+	return res, nil
+}
+
+func (s *ReportService) GetTopTimeFrameReport(ctx context.Context, req model.ReportReq) ([]model.TimeFrameReportItem, error) {
+	if err := checkReportReq(ctx, s.repo, &req); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTopTimeFrameReport(ctx, req)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+)
+
+const reindexBatchSize = 500
+
+type SearchService struct {
+	repo repo.PGInterface
+	es   repo.ESInterface
+}
+
+// NewSearchService creates the parking lot search service, es may be nil when Elasticsearch is disabled
+// in which case searches are served by Postgres and indexing is a no-op.
+func NewSearchService(repo repo.PGInterface, es repo.ESInterface) SearchServiceInterface {
+	return &SearchService{repo: repo, es: es}
+}
+
+type SearchServiceInterface interface {
+	SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (model.SearchParkingLotRes, error)
+	IndexParkingLot(ctx context.Context, id uuid.UUID) error
+	IndexCompanyParkingLot(ctx context.Context, companyID uuid.UUID) error
+	RemoveParkingLot(ctx context.Context, id uuid.UUID) error
+	ReindexParkingLot(ctx context.Context) (int, error)
+}
+
+func (s *SearchService) SearchParkingLot(ctx context.Context, req model.SearchParkingLotReq) (model.SearchParkingLotRes, error) {
+	log := logger.WithCtx(ctx, utils.GetCurrentCaller(s, 0))
+
+	if s.es != nil {
+		res, err := s.es.SearchParkingLot(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+		log.WithError(err).Warn("Elasticsearch search failed, fallback to Postgres")
+	}
+	return s.repo.SearchParkingLot(ctx, req)
+}
+
+// IndexParkingLot pushes the parking lot to the index, or removes it when its company is not active.
+func (s *SearchService) IndexParkingLot(ctx context.Context, id uuid.UUID) error {
+	if s.es == nil {
+		return nil
+	}
+	item, err := s.repo.GetOneParkingLotSearchItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item.CompanyStatus != model.CompanyStatusActive {
+		return s.es.DeleteParkingLot(ctx, id)
+	}
+	return s.es.IndexParkingLot(ctx, item)
+}
+
+// IndexCompanyParkingLot brings the parking lots of the company in or out of the index after its status changed.
+func (s *SearchService) IndexCompanyParkingLot(ctx context.Context, companyID uuid.UUID) error {
+	if s.es == nil {
+		return nil
+	}
+	ids, err := s.repo.GetParkingLotIDsByCompany(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.IndexParkingLot(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SearchService) RemoveParkingLot(ctx context.Context, id uuid.UUID) error {
+	if s.es == nil {
+		return nil
+	}
+	return s.es.DeleteParkingLot(ctx, id)
+}
+
+// ReindexParkingLot rebuilds the parking lot index from Postgres and returns the number of indexed lots.
+func (s *SearchService) ReindexParkingLot(ctx context.Context) (int, error) {
+	if s.es == nil {
+		return 0, apperror.New(apperror.SearchDisabled)
+	}
+	if err := s.es.RecreateParkingLotIndex(ctx); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for page := 1; ; page++ {
+		items, err := s.repo.GetListParkingLotSearchItem(ctx, page, reindexBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if err := s.es.BulkIndexParkingLot(ctx, items); err != nil {
+			return total, err
+		}
+		total += len(items)
+		if len(items) < reindexBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/valid"
+)
+
+type SettingService struct {
+	repo repo.PGInterface
+}
+
+func NewSettingService(repo repo.PGInterface) SettingInterface {
+	return &SettingService{repo: repo}
+}
+
+type SettingInterface interface {
+	GetSetting(ctx context.Context, req model.GetSettingReq) (*model.Setting, error)
+	UpsertSetting(ctx context.Context, req model.SettingReq) (*model.Setting, error)
+}
+
+// settingValidators lists the keys a merchant can set on a parking lot, or an admin for every company, with the
+// check of their value.
+var settingValidators = map[string]func(value json.RawMessage) error{
+	model.SettingSlotAssignment: func(value json.RawMessage) error {
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil {
+			return fmt.Errorf("value must be a string")
+		}
+		if _, ok := slotAssigners[name]; !ok {
+			return fmt.Errorf("unknown slot assignment strategy %s", name)
+		}
+		return nil
+	},
+	model.SettingSlotHoldTTL: func(value json.RawMessage) error {
+		var seconds int
+		if err := json.Unmarshal(value, &seconds); err != nil || seconds < 30 || seconds > 3600 {
+			return fmt.Errorf("value must be a number of seconds between 30 and 3600")
+		}
+		return nil
+	},
+}
+
+// settingInt reads an integer setting in effect for the parking lot, def when it is not set.
+func settingInt(ctx context.Context, rp repo.PGInterface, key string, lot model.ParkingLot, def int) (int, error) {
+	setting, err := rp.GetSetting(ctx, key, &lot.CompanyID, &lot.ID)
+	if err != nil || setting == nil {
+		return def, err
+	}
+	var value int
+	if err := json.Unmarshal(setting.Value.Bytes, &value); err != nil {
+		return def, nil
+	}
+	return value, nil
+}
+
+// GetSetting returns the setting in effect for the parking lot, nil when none is stored.
+func (s *SettingService) GetSetting(ctx context.Context, req model.GetSettingReq) (*model.Setting, error) {
+	id, err := uuid.Parse(valid.String(req.ParkingLotId))
+	if err != nil {
+		return nil, apperror.New(apperror.InvalidID)
+	}
+	lot, err := s.repo.GetOneParkingLot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSetting(ctx, valid.String(req.Key), &lot.CompanyID, &lot.ID)
+}
+
+func (s *SettingService) UpsertSetting(ctx context.Context, req model.SettingReq) (*model.Setting, error) {
+	key := valid.String(req.Key)
+	check, ok := settingValidators[key]
+	if !ok {
+		return nil, apperror.New(apperror.InvalidParam, "key", key)
+	}
+	if err := check(req.Value); err != nil {
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	lot, err := s.repo.GetOneParkingLot(ctx, valid.UUID(req.ParkingLotId))
+	if err != nil {
+		return nil, err
+	}
+
+	setting := &model.Setting{CompanyId: &lot.CompanyID, ParkingLotId: &lot.ID, Key: key}
+	if err := setting.Value.Set([]byte(req.Value)); err != nil {
+		return nil, apperror.Wrap(apperror.InvalidInput, err)
+	}
+	if err := s.repo.UpsertSetting(ctx, setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
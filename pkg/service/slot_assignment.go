@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+// SlotAssigner picks the slot of a booking among the free slots of its parking lot. Candidates are never
+// empty and are sorted by block code and slot name.
+type SlotAssigner interface {
+	PickSlot(candidates []model.SlotCandidate, vehicleType string) model.SlotCandidate
+}
+
+type SlotAssignerFunc func(candidates []model.SlotCandidate, vehicleType string) model.SlotCandidate
+
+func (f SlotAssignerFunc) PickSlot(candidates []model.SlotCandidate, vehicleType string) model.SlotCandidate {
+	return f(candidates, vehicleType)
+}
+
+var slotAssigners = map[string]SlotAssigner{
+	// fills the blocks one after another
+	model.SlotAssignFillByBlock: SlotAssignerFunc(func(candidates []model.SlotCandidate, _ string) model.SlotCandidate {
+		return candidates[0]
+	}),
+	model.SlotAssignNearestEntrance: SlotAssignerFunc(func(candidates []model.SlotCandidate, _ string) model.SlotCandidate {
+		return minSlotCandidate(candidates, func(c model.SlotCandidate) float64 { return c.DistanceToEntrance })
+	}),
+	// spreads the bookings over the slots that were used the least lately
+	model.SlotAssignBalanceWear: SlotAssignerFunc(func(candidates []model.SlotCandidate, _ string) model.SlotCandidate {
+		return minSlotCandidate(candidates, func(c model.SlotCandidate) float64 { return float64(c.UsageCount) })
+	}),
+	// keeps the general slots for the vehicles without a slot of their own type
+	model.SlotAssignVehicleTypeFit: SlotAssignerFunc(func(candidates []model.SlotCandidate, vehicleType string) model.SlotCandidate {
+		for _, c := range candidates {
+			if c.VehicleType != "" && c.VehicleType == vehicleType {
+				return c
+			}
+		}
+		return candidates[0]
+	}),
+}
+
+// RegisterSlotAssigner makes a strategy available to the parking lots under name.
+func RegisterSlotAssigner(name string, assigner SlotAssigner) {
+	slotAssigners[name] = assigner
+}
+
+func minSlotCandidate(candidates []model.SlotCandidate, key func(model.SlotCandidate) float64) model.SlotCandidate {
+	res := candidates[0]
+	for _, c := range candidates[1:] {
+		if key(c) < key(res) {
+			res = c
+		}
+	}
+	return res
+}
+
+// parkingLotSlotAssigner returns the strategy set for the parking lot, fill_by_block by default.
+func parkingLotSlotAssigner(ctx context.Context, rp repo.PGInterface, lot model.ParkingLot) (SlotAssigner, error) {
+	setting, err := rp.GetSetting(ctx, model.SettingSlotAssignment, &lot.CompanyID, &lot.ID)
+	if err != nil || setting == nil {
+		return slotAssigners[model.SlotAssignFillByBlock], err
+	}
+
+	var name string
+	if err := json.Unmarshal(setting.Value.Bytes, &name); err == nil {
+		if assigner, ok := slotAssigners[name]; ok {
+			return assigner, nil
+		}
+	}
+	logger.WithCtx(ctx, "parkingLotSlotAssigner").WithField("parking_lot_id", lot.ID).
+		Warnf("unknown slot assignment strategy %s, falling back to %s", setting.Value.Bytes, model.SlotAssignFillByBlock)
+	return slotAssigners[model.SlotAssignFillByBlock], nil
+}
+
+// assignSlot picks a slot free for req with the strategy of the parking lot, nil when the parking lot is
+// full. It is meant to run in a transaction holding the lock of the parking lot.
+func assignSlot(ctx context.Context, rp repo.PGInterface, req model.FreeSlotReq) (*model.ParkingSlot, error) {
+	lot, err := rp.GetOneParkingLot(ctx, req.ParkingLotID)
+	if err != nil {
+		return nil, err
+	}
+	assigner, err := parkingLotSlotAssigner(ctx, rp, lot)
+	if err != nil {
+		return nil, err
+	}
+
+	req.StrictVehicleType = true
+	candidates, err := rp.GetSlotCandidates(ctx, req)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	slot := assigner.PickSlot(candidates, req.VehicleType).ParkingSlot
+	return &slot, nil
+}
+
+// createAssignedTicket books the slot chosen by the strategy of the parking lot for ticket.
+func (s *TicketService) createAssignedTicket(ctx context.Context, ticket *model.Ticket) error {
+	req := model.FreeSlotReq{
+		ParkingLotID: valid.UUID(ticket.ParkingLotId),
+		Start:        valid.DayTime(ticket.StartTime),
+		End:          valid.DayTime(ticket.EndTime),
+		HolderID:     ticket.UserId,
+	}
+	if ticket.VehicleId != nil {
+		vehicle, err := s.repo.GetOneVehicle(ctx, *ticket.VehicleId)
+		if err != nil {
+			return err
+		}
+		req.VehicleType = vehicle.Type
+	}
+
+	return s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, req.ParkingLotID); err != nil {
+			return err
+		}
+		slot, err := assignSlot(ctx, rp, req)
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return apperror.New(apperror.TicketNoFreeSlot)
+		}
+		ticket.ParkingSlotId = valid.UUIDPointer(slot.ID)
+		if err := rp.CreateTicket(ctx, ticket, nil); err != nil {
+			return err
+		}
+		if err := releaseSlotHolds(ctx, rp, ticket); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, *ticket, model.EventTicketCreated)
+	})
+}
+
+// ReassignTicket moves a ticket that has not started yet, with its extensions, to another slot of its
+// parking lot. The slot is req.ParkingSlotId when set, the one chosen by the strategy of the lot otherwise.
+// An extension ticket is resolved to its origin ticket so the booking moves as a whole.
+func (s *TicketService) ReassignTicket(ctx context.Context, req model.ReassignTicketReq) (res []model.RelocatedTicket, err error) {
+	ticket, err := s.repo.GetOneTicket(ctx, valid.UUID(req.TicketId).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.State == "extend" {
+		if ticket, err = s.repo.GetOriginTicketByExtend(ctx, ticket.ID.String(), nil); err != nil {
+			return nil, err
+		}
+	}
+	if req.UserId != nil && valid.UUID(ticket.UserId) != *req.UserId {
+		return nil, apperror.New(apperror.NotFound)
+	}
+	if req.CompanyId != nil {
+		lot, err := s.repo.GetOneParkingLot(ctx, valid.UUID(ticket.ParkingLotId))
+		if err != nil {
+			return nil, err
+		}
+		if lot.CompanyID != *req.CompanyId {
+			return nil, apperror.New(apperror.NotFound)
+		}
+	}
+	if (ticket.State != "new" && ticket.State != "extend") || ticket.EntryTime != nil ||
+		ticket.StartTime == nil || !ticket.StartTime.After(utils.Now()) {
+		return nil, apperror.New(apperror.TicketAlreadyStarted)
+	}
+	tickets := []model.Ticket{ticket}
+	if ticket.IsExtend {
+		extends, err := s.repo.GetListExtendTicketByOrigin(ctx, ticket.ID.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, extends...)
+	}
+
+	free := model.FreeSlotReq{
+		ParkingLotID: valid.UUID(ticket.ParkingLotId),
+		Start:        valid.DayTime(ticket.StartTime),
+		End:          valid.DayTime(ticket.EndTime),
+		HolderID:     ticket.UserId,
+	}
+	for _, t := range tickets {
+		if t.EndTime != nil && t.EndTime.After(free.End) {
+			free.End = *t.EndTime
+		}
+	}
+	if ticket.ParkingSlotId != nil {
+		if req.ParkingSlotId != nil && *req.ParkingSlotId == *ticket.ParkingSlotId {
+			return nil, apperror.New(apperror.TicketSameSlot)
+		}
+		free.ExcludeSlotIDs = append(free.ExcludeSlotIDs, *ticket.ParkingSlotId)
+	}
+	if ticket.VehicleId != nil {
+		vehicle, err := s.repo.GetOneVehicle(ctx, *ticket.VehicleId)
+		if err != nil {
+			return nil, err
+		}
+		free.VehicleType = vehicle.Type
+	}
+
+	var changes []ticketChange
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, free.ParkingLotID); err != nil {
+			return err
+		}
+
+		var slot *model.ParkingSlot
+		if req.ParkingSlotId != nil {
+			candidates, err := rp.GetSlotCandidates(ctx, free)
+			if err != nil {
+				return err
+			}
+			for _, c := range candidates {
+				if c.ID == *req.ParkingSlotId {
+					slot = &c.ParkingSlot
+					break
+				}
+			}
+			if slot == nil {
+				return apperror.New(apperror.TicketSlotTaken)
+			}
+		} else {
+			var err error
+			if slot, err = assignSlot(ctx, rp, free); err != nil {
+				return err
+			}
+			if slot == nil {
+				return apperror.New(apperror.TicketNoFreeSlot)
+			}
+		}
+
+		for _, t := range tickets {
+			from := valid.UUID(t.ParkingSlotId)
+			t.ParkingSlotId = valid.UUIDPointer(slot.ID)
+			if err := rp.UpdateTicket(ctx, &t, nil); err != nil {
+				return err
+			}
+			res = append(res, model.RelocatedTicket{TicketID: t.ID, FromSlotID: from, ToSlotID: slot.ID})
+			changes = append(changes, ticketChange{ticket: t, event: notification.EventBookingRelocated})
+		}
+		return emitTicketChanges(ctx, rp, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyTicketChanges(ctx, s.notifier, changes)
+	return res, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+)
+
+type SlotDowntimeService struct {
+	repo     repo.PGInterface
+	notifier NotificationInterface
+}
+
+func NewSlotDowntimeService(repo repo.PGInterface, notifier NotificationInterface) SlotDowntimeInterface {
+	return &SlotDowntimeService{repo: repo, notifier: notifier}
+}
+
+type SlotDowntimeInterface interface {
+	CreateSlotDowntime(ctx context.Context, req model.SlotDowntimeReq) (model.SlotDowntimeRes, error)
+	GetListSlotDowntime(ctx context.Context, req model.ListSlotDowntimeReq) (model.ListSlotDowntimeRes, error)
+	UpdateSlotDowntime(ctx context.Context, req model.SlotDowntimeReq) (model.SlotDowntimeRes, error)
+	DeleteSlotDowntime(ctx context.Context, id uuid.UUID) error
+}
+
+// resolveSlotDowntime checks the window and target of downtime, stores the window in UTC and fills in its
+// parking lot.
+func (s *SlotDowntimeService) resolveSlotDowntime(ctx context.Context, downtime *model.SlotDowntime) error {
+	if (downtime.ParkingSlotID == nil) == (downtime.BlockID == nil) {
+		return apperror.New(apperror.DowntimeTarget)
+	}
+	if !downtime.StartTime.Before(downtime.EndTime) {
+		return apperror.New(apperror.InvalidTimeRange)
+	}
+	if !downtime.EndTime.After(utils.Now()) {
+		return apperror.New(apperror.TimeInPast, "end_time")
+	}
+	downtime.StartTime, downtime.EndTime = downtime.StartTime.UTC(), downtime.EndTime.UTC()
+
+	blockID := downtime.BlockID
+	if downtime.ParkingSlotID != nil {
+		slot, err := s.repo.GetOneParkingSlot(ctx, *downtime.ParkingSlotID)
+		if err != nil {
+			return err
+		}
+		blockID = &slot.BlockID
+	}
+	block, err := s.repo.GetOneBlock(ctx, *blockID)
+	if err != nil {
+		return err
+	}
+	downtime.ParkingLotID = block.ParkingLotID
+	return nil
+}
+
+// saveSlotDowntime stores downtime and clears the bookings it overlaps. Relocated tickets go to another
+// slot of the parking lot, when one of them does not fit the whole change is refused.
+func (s *SlotDowntimeService) saveSlotDowntime(ctx context.Context, downtime *model.SlotDowntime, relocate bool, save func(rp repo.PGInterface) error) (res model.SlotDowntimeRes, err error) {
+	if err := s.resolveSlotDowntime(ctx, downtime); err != nil {
+		return res, err
+	}
+
+	scope := model.TicketScope{Start: &downtime.StartTime, End: &downtime.EndTime}
+	free := &model.FreeSlotReq{}
+	if downtime.ParkingSlotID != nil {
+		slot, err := s.repo.GetOneParkingSlot(ctx, *downtime.ParkingSlotID)
+		if err != nil {
+			return res, err
+		}
+		scope.ParkingSlotIDs = []uuid.UUID{slot.ID}
+		free.ExcludeSlotIDs, free.VehicleType = []uuid.UUID{slot.ID}, slot.VehicleType
+	} else {
+		scope.BlockID, free.ExcludeBlockID = downtime.BlockID, downtime.BlockID
+	}
+
+	var released model.DeleteRes
+	var changes []ticketChange
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// no booking slips into the window, or onto a slot a ticket is moved to, before it is committed
+		if err := rp.LockParkingLot(ctx, downtime.ParkingLotID); err != nil {
+			return err
+		}
+		if err := save(rp); err != nil {
+			return err
+		}
+		released, changes, err = releaseTickets(ctx, rp, scope, releaseOptions{force: relocate, forceParam: "relocate", relocate: free, keepUnplaced: true})
+		return err
+	})
+	if err != nil {
+		return res, err
+	}
+	notifyTicketChanges(ctx, s.notifier, changes)
+
+	res.SlotDowntime = *downtime
+	res.RelocatedTickets = released.RelocatedTickets
+	return res, nil
+}
+
+func (s *SlotDowntimeService) CreateSlotDowntime(ctx context.Context, req model.SlotDowntimeReq) (model.SlotDowntimeRes, error) {
+	downtime := &model.SlotDowntime{
+		BlockID:       req.BlockID,
+		ParkingSlotID: req.ParkingSlotID,
+		StartTime:     valid.DayTime(req.StartTime),
+		EndTime:       valid.DayTime(req.EndTime),
+		Reason:        valid.String(req.Reason),
+	}
+
+	return s.saveSlotDowntime(ctx, downtime, valid.Bool(req.Relocate), func(rp repo.PGInterface) error {
+		return rp.CreateSlotDowntime(ctx, downtime)
+	})
+}
+
+func (s *SlotDowntimeService) GetListSlotDowntime(ctx context.Context, req model.ListSlotDowntimeReq) (model.ListSlotDowntimeRes, error) {
+	return s.repo.GetListSlotDowntime(ctx, req)
+}
+
+func (s *SlotDowntimeService) UpdateSlotDowntime(ctx context.Context, req model.SlotDowntimeReq) (model.SlotDowntimeRes, error) {
+	downtime, err := s.repo.GetOneSlotDowntime(ctx, valid.UUID(req.ID))
+	if err != nil {
+		return model.SlotDowntimeRes{}, err
+	}
+
+	if req.ParkingSlotID != nil {
+		downtime.ParkingSlotID, downtime.BlockID = req.ParkingSlotID, nil
+	} else if req.BlockID != nil {
+		downtime.ParkingSlotID, downtime.BlockID = nil, req.BlockID
+	}
+	if req.StartTime != nil {
+		downtime.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		downtime.EndTime = *req.EndTime
+	}
+	if req.Reason != nil {
+		downtime.Reason = *req.Reason
+	}
+
+	return s.saveSlotDowntime(ctx, &downtime, valid.Bool(req.Relocate), func(rp repo.PGInterface) error {
+		return rp.UpdateSlotDowntime(ctx, &downtime)
+	})
+}
+
+func (s *SlotDowntimeService) DeleteSlotDowntime(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteSlotDowntime(ctx, id)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"time"
+)
+
+type SlotHoldService struct {
+	repo repo.PGInterface
+}
+
+func NewSlotHoldService(repo repo.PGInterface) SlotHoldInterface {
+	return &SlotHoldService{repo: repo}
+}
+
+type SlotHoldInterface interface {
+	CreateSlotHold(ctx context.Context, req model.SlotHoldReq) (model.SlotHold, error)
+	DeleteSlotHold(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// CreateSlotHold keeps req.ParkingSlotId, or the slot chosen by the strategy of the parking lot, for the
+// user during the slot_hold_ttl of the lot. A new hold replaces the other holds of the user in the lot.
+func (s *SlotHoldService) CreateSlotHold(ctx context.Context, req model.SlotHoldReq) (hold model.SlotHold, err error) {
+	free := model.FreeSlotReq{
+		ParkingLotID: valid.UUID(req.ParkingLotId),
+		Start:        valid.DayTime(req.StartTime).UTC(),
+		End:          valid.DayTime(req.EndTime).UTC(),
+		HolderID:     req.UserId,
+	}
+	if !free.Start.Before(free.End) {
+		return hold, apperror.New(apperror.InvalidTimeRange)
+	}
+	if !free.End.After(utils.Now()) {
+		return hold, apperror.New(apperror.TimeInPast, "endTime")
+	}
+	lot, err := s.repo.GetOneParkingLot(ctx, free.ParkingLotID)
+	if err != nil {
+		return hold, err
+	}
+	ttl, err := settingInt(ctx, s.repo, model.SettingSlotHoldTTL, lot, model.DefaultSlotHoldTTL)
+	if err != nil {
+		return hold, err
+	}
+	if req.VehicleId != nil {
+		vehicle, err := s.repo.GetOneVehicle(ctx, *req.VehicleId)
+		if err != nil {
+			return hold, err
+		}
+		free.VehicleType = vehicle.Type
+	}
+
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, lot.ID); err != nil {
+			return err
+		}
+		if err := rp.ReleaseUserSlotHold(ctx, valid.UUID(req.UserId), lot.ID); err != nil {
+			return err
+		}
+
+		var slotID *uuid.UUID
+		if req.ParkingSlotId != nil {
+			candidates, err := rp.GetSlotCandidates(ctx, free)
+			if err != nil {
+				return err
+			}
+			for _, c := range candidates {
+				if c.ID == *req.ParkingSlotId {
+					slotID = valid.UUIDPointer(c.ID)
+					break
+				}
+			}
+			if slotID == nil {
+				return apperror.New(apperror.TicketSlotTaken)
+			}
+		} else {
+			slot, err := assignSlot(ctx, rp, free)
+			if err != nil {
+				return err
+			}
+			if slot == nil {
+				return apperror.New(apperror.TicketNoFreeSlot)
+			}
+			slotID = valid.UUIDPointer(slot.ID)
+		}
+
+		hold = model.SlotHold{
+			BaseModel:     model.BaseModel{CreatorID: req.UserId, UpdaterID: req.UserId},
+			UserId:        req.UserId,
+			ParkingLotId:  lot.ID,
+			ParkingSlotId: *slotID,
+			StartTime:     free.Start,
+			EndTime:       free.End,
+			ExpiresAt:     utils.Now().Add(time.Duration(ttl) * time.Second),
+		}
+		return rp.CreateSlotHold(ctx, &hold)
+	})
+	return hold, err
+}
+
+// DeleteSlotHold gives the slot back when the user leaves the checkout.
+func (s *SlotHoldService) DeleteSlotHold(ctx context.Context, id, userID uuid.UUID) error {
+	hold, err := s.repo.GetOneSlotHold(ctx, id)
+	if err != nil {
+		return err
+	}
+	if valid.UUID(hold.UserId) != userID {
+		return apperror.New(apperror.NotFound)
+	}
+	if hold.TicketId != nil {
+		return apperror.New(apperror.SlotHoldBooked)
+	}
+	return s.repo.DeleteSlotHold(ctx, id)
+}
+
+// createHeldTicket books ticket on the slot the user holds, the hold has to cover the ticket.
+func (s *TicketService) createHeldTicket(ctx context.Context, ticket *model.Ticket, holdID uuid.UUID) error {
+	hold, err := s.repo.GetOneSlotHold(ctx, holdID)
+	if err != nil {
+		return err
+	}
+	if valid.UUID(hold.UserId) != valid.UUID(ticket.UserId) || hold.ParkingLotId != valid.UUID(ticket.ParkingLotId) {
+		return apperror.New(apperror.SlotHoldMismatch)
+	}
+	if hold.TicketId != nil || !hold.ExpiresAt.After(utils.Now()) {
+		return apperror.New(apperror.SlotHoldExpired)
+	}
+	if ticket.StartTime == nil || ticket.EndTime == nil || ticket.StartTime.Before(hold.StartTime) || ticket.EndTime.After(hold.EndTime) {
+		return apperror.New(apperror.SlotHoldOutsideTime)
+	}
+
+	ticket.ParkingSlotId = valid.UUIDPointer(hold.ParkingSlotId)
+	return s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, hold.ParkingLotId); err != nil {
+			return err
+		}
+		if err := rp.CreateTicket(ctx, ticket, nil); err != nil {
+			return err
+		}
+		// the hold read above may have been booked or expired since, only one ticket takes it
+		if err := rp.BookSlotHold(ctx, hold.ID, ticket.ID); err != nil {
+			return err
+		}
+		if err := releaseSlotHolds(ctx, rp, ticket); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, *ticket, model.EventTicketCreated)
+	})
+}
+
+// releaseSlotHolds drops the holds the owner of a new ticket still has in its parking lot.
+func releaseSlotHolds(ctx context.Context, rp repo.PGInterface, ticket *model.Ticket) error {
+	if ticket.UserId == nil || ticket.ParkingLotId == nil {
+		return nil
+	}
+	return rp.ReleaseUserSlotHold(ctx, *ticket.UserId, *ticket.ParkingLotId)
+}
@@ -2,35 +2,82 @@ package service
 
 import (
 	"context"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
 	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
 	"parkar-server/pkg/valid"
 	"time"
 )
 
 type TicketService struct {
-	repo repo.PGInterface
+	repo     repo.PGInterface
+	notifier NotificationInterface
 }
 
-func NewTicketService(repo repo.PGInterface) TicketServiceInterface {
-	return &TicketService{repo: repo}
+func NewTicketService(repo repo.PGInterface, notifier NotificationInterface) TicketServiceInterface {
+	return &TicketService{repo: repo, notifier: notifier}
 }
 
 type TicketServiceInterface interface {
 	CreateTicket(ctx context.Context, req *model.TicketReq) (*model.Ticket, error)
 	ProcedureWithTicket(ctx context.Context, req *model.ProcedureReq) (bool, error)
 	ExtendTicket(ctx context.Context, req *model.ExtendTicketReq) (*model.TicketExtend, error)
-	GetAllTicket(ctx context.Context, req model.GetListTicketParam) ([]model.Ticket, error)
+	GetAllTicket(ctx context.Context, req model.GetListTicketParam) (model.ListTicketRes, error)
 	GetOneTicketWithExtend(ctx context.Context, id string) (model.TicketResponse, error)
 	CancelTicket(ctx context.Context, id string) error
-	GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) ([]model.GetListTicketRes, error)
+	GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (model.ListTicketCompanyRes, error)
+	ReassignTicket(ctx context.Context, req model.ReassignTicketReq) ([]model.RelocatedTicket, error)
 }
 
-func (s *TicketService) GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) ([]model.GetListTicketRes, error) {
-	return s.repo.GetAllTicketCompany(ctx, req)
+func (s *TicketService) GetAllTicketCompany(ctx context.Context, req model.GetListTicketReq) (model.ListTicketCompanyRes, error) {
+	res, err := s.repo.GetAllTicketCompany(ctx, req)
+	if err != nil {
+		return res, err
+	}
+	for i := range res.Data {
+		t := &res.Data[i]
+		inParkingLotTime(t.ParkingLot, &t.StartTime, &t.EndTime, &t.EntryTime, &t.ExitTime)
+	}
+	return res, nil
+}
+
+// inParkingLotTime shows times in the zone of lot, they are stored in UTC. It does nothing when the parking
+// lot was not loaded.
+func inParkingLotTime(lot *model.ParkingLot, times ...**time.Time) {
+	if lot == nil {
+		return
+	}
+	loc := utils.Location(lot.Timezone)
+	for _, t := range times {
+		if *t != nil {
+			*t = valid.DayTimePointer((*t).In(loc))
+		}
+	}
+}
+
+func ticketInParkingLotTime(t *model.Ticket) {
+	inParkingLotTime(t.ParkingLot, &t.StartTime, &t.EndTime, &t.EntryTime, &t.ExitTime)
+}
+
+// checkTicketTime normalises the range of a ticket to UTC and rejects an empty or reversed one. The range is
+// half-open, a ticket ending when another starts does not overlap it.
+func checkTicketTime(start, end *time.Time) (*time.Time, *time.Time, error) {
+	if start == nil || end == nil {
+		return nil, nil, apperror.New(apperror.TimeRequired)
+	}
+	if !start.Before(*end) {
+		return nil, nil, apperror.New(apperror.InvalidTimeRange)
+	}
+	return valid.DayTimePointer(start.UTC()), valid.DayTimePointer(end.UTC()), nil
 }
 
 func (s *TicketService) CreateTicket(ctx context.Context, req *model.TicketReq) (*model.Ticket, error) {
+	var err error
+	if req.StartTime, req.EndTime, err = checkTicketTime(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
 	ticket := &model.Ticket{
 		BaseModel: model.BaseModel{
 			CreatorID: req.UserId,
@@ -47,32 +94,83 @@ func (s *TicketService) CreateTicket(ctx context.Context, req *model.TicketReq)
 		Total:         valid.Float64(req.Total),
 	}
 
-	if req.IsLongTerm {
-		longTermTicket := &model.LongTermTicket{
-			BaseModel: model.BaseModel{
-				CreatorID: req.UserId,
-				UpdaterID: req.UserId,
-			},
-			StartTime:     req.StartTime,
-			EndTime:       req.EndTime,
-			VehicleId:     req.VehicleId,
-			ParkingLotId:  req.ParkingLotId,
-			ParkingSlotId: req.ParkingSlotId,
-			TimeFrameId:   req.TimeFrameId,
-		}
-		s.repo.CreateLongTermTicket(ctx, longTermTicket, nil)
-
-		//create ticket normal
-		switch req.Type {
-		case "DAILY":
-		case "CYCLE":
-		case "CUSTOM":
+	if req.SlotHoldId != nil || req.ParkingSlotId == nil {
+		if req.IsLongTerm {
+			return nil, apperror.New(apperror.TicketSlotRequired)
+		}
+		if req.SlotHoldId != nil {
+			err = s.createHeldTicket(ctx, ticket, *req.SlotHoldId)
+		} else {
+			err = s.createAssignedTicket(ctx, ticket)
 		}
+		if err != nil {
+			return nil, err
+		}
+		s.notifier.NotifyTicket(ctx, *ticket, notification.EventBookingConfirmed)
+		return ticket, nil
+	}
+
+	free := model.FreeSlotReq{
+		ParkingLotID: valid.UUID(req.ParkingLotId),
+		Start:        valid.DayTime(req.StartTime),
+		End:          valid.DayTime(req.EndTime),
+		HolderID:     req.UserId,
+		SlotID:       req.ParkingSlotId,
 	}
-	err := s.repo.CreateTicket(ctx, ticket, nil)
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, free.ParkingLotID); err != nil {
+			return err
+		}
+		hold, err := rp.GetSlotHoldConflict(ctx, *req.ParkingSlotId, free.Start, free.End, req.UserId)
+		if err != nil {
+			return err
+		}
+		if hold != nil {
+			return apperror.New(apperror.SlotHeld)
+		}
+		// the same checks as an assigned slot: no overlapping ticket, downtime or offer on it
+		slot, err := rp.GetFreeParkingSlot(ctx, free)
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return apperror.New(apperror.TicketSlotTaken)
+		}
+
+		if req.IsLongTerm {
+			longTermTicket := &model.LongTermTicket{
+				BaseModel: model.BaseModel{
+					CreatorID: req.UserId,
+					UpdaterID: req.UserId,
+				},
+				StartTime:     req.StartTime,
+				EndTime:       req.EndTime,
+				VehicleId:     req.VehicleId,
+				ParkingLotId:  req.ParkingLotId,
+				ParkingSlotId: req.ParkingSlotId,
+				TimeFrameId:   req.TimeFrameId,
+			}
+			rp.CreateLongTermTicket(ctx, longTermTicket, nil)
+
+			//create ticket normal
+			switch req.Type {
+			case "DAILY":
+			case "CYCLE":
+			case "CUSTOM":
+			}
+		}
+		if err := rp.CreateTicket(ctx, ticket, nil); err != nil {
+			return err
+		}
+		if err := releaseSlotHolds(ctx, rp, ticket); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, *ticket, model.EventTicketCreated)
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.notifier.NotifyTicket(ctx, *ticket, notification.EventBookingConfirmed)
 	return ticket, nil
 }
 func (s *TicketService) ExtendTicket(ctx context.Context, req *model.ExtendTicketReq) (*model.TicketExtend, error) {
@@ -80,6 +178,9 @@ func (s *TicketService) ExtendTicket(ctx context.Context, req *model.ExtendTicke
 	if err != nil {
 		return nil, err
 	}
+	if req.StartTime, req.EndTime, err = checkTicketTime(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
 	extendTicket := &model.Ticket{
 		BaseModel: model.BaseModel{
 			CreatorID: ticket.CreatorID,
@@ -96,26 +197,60 @@ func (s *TicketService) ExtendTicket(ctx context.Context, req *model.ExtendTicke
 		Total:         valid.Float64(req.Total),
 	}
 	ticket.IsExtend = true
-	if err := s.repo.UpdateTicket(ctx, &ticket, nil); err != nil {
-		return nil, err
-	}
-	if err := s.repo.CreateTicket(ctx, extendTicket, nil); err != nil {
-		return nil, err
+	ticketEx := &model.TicketExtend{}
+	free := model.FreeSlotReq{
+		ParkingLotID: valid.UUID(ticket.ParkingLotId),
+		Start:        valid.DayTime(req.StartTime),
+		End:          valid.DayTime(req.EndTime),
+		HolderID:     ticket.UserId,
+		SlotID:       ticket.ParkingSlotId,
 	}
-	//create extend ticket table
-	ticketEx := &model.TicketExtend{
-		TicketExtendId: extendTicket.ID,
-		TicketId:       ticket.ID,
-	}
-	if err := s.repo.CreateTicketExtend(ctx, ticketEx, nil); err != nil {
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		// the slot has to be free over the extension as for a new ticket on it
+		if err := rp.LockParkingLot(ctx, free.ParkingLotID); err != nil {
+			return err
+		}
+		hold, err := rp.GetSlotHoldConflict(ctx, valid.UUID(ticket.ParkingSlotId), free.Start, free.End, ticket.UserId)
+		if err != nil {
+			return err
+		}
+		if hold != nil {
+			return apperror.New(apperror.SlotHeld)
+		}
+		slot, err := rp.GetFreeParkingSlot(ctx, free)
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return apperror.New(apperror.TicketSlotTaken)
+		}
+
+		if err := rp.UpdateTicket(ctx, &ticket, nil); err != nil {
+			return err
+		}
+		if err := rp.CreateTicket(ctx, extendTicket, nil); err != nil {
+			return err
+		}
+		//create extend ticket table
+		ticketEx.TicketExtendId = extendTicket.ID
+		ticketEx.TicketId = ticket.ID
+		if err := rp.CreateTicketExtend(ctx, ticketEx, nil); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, *extendTicket, model.EventTicketExtended)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return ticketEx, nil
 }
-func (s *TicketService) GetAllTicket(ctx context.Context, req model.GetListTicketParam) ([]model.Ticket, error) {
+func (s *TicketService) GetAllTicket(ctx context.Context, req model.GetListTicketParam) (model.ListTicketRes, error) {
 	res, err := s.repo.GetAllTicket(ctx, req, nil)
 	if err != nil {
-		return nil, err
+		return model.ListTicketRes{}, err
+	}
+	for i := range res.Data {
+		ticketInParkingLotTime(&res.Data[i])
 	}
 	return res, nil
 }
@@ -128,6 +263,12 @@ func (s *TicketService) GetOneTicketWithExtend(ctx context.Context, id string) (
 	if err != nil {
 		return model.TicketResponse{}, err
 	}
+	ticketInParkingLotTime(&ticket)
+	for i := range ticketExtend {
+		// the extensions are in the parking lot of the ticket, which is not loaded with them
+		t := &ticketExtend[i]
+		inParkingLotTime(ticket.ParkingLot, &t.StartTime, &t.EndTime, &t.EntryTime, &t.ExitTime)
+	}
 	ticketRes := model.TicketResponse{
 		Ticket:       ticket,
 		TicketExtend: ticketExtend,
@@ -140,9 +281,19 @@ func (s *TicketService) CancelTicket(ctx context.Context, id string) error {
 		return err
 	}
 	ticket.State = "cancel"
-	if err := s.repo.UpdateTicket(ctx, &ticket, nil); err != nil {
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.UpdateTicket(ctx, &ticket, nil); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, ticket, model.EventTicketCancelled)
+	})
+	if err != nil {
 		return err
 	}
+	s.notifier.NotifyTicket(ctx, ticket, notification.EventBookingCancelled)
+	if ticket.ParkingLotId != nil {
+		offerWaitlist(ctx, s.repo, s.notifier, *ticket.ParkingLotId)
+	}
 	return nil
 }
 func (s *TicketService) ProcedureWithTicket(ctx context.Context, req *model.ProcedureReq) (bool, error) {
@@ -150,15 +301,30 @@ func (s *TicketService) ProcedureWithTicket(ctx context.Context, req *model.Proc
 	if err != nil {
 		return false, err
 	}
+	var eventType string
 	switch req.Type {
 	case "check_in":
+		if ticket.State == "no_show" {
+			return false, apperror.New(apperror.TicketNoShow)
+		}
 		ticket.State = "ongoing"
-		ticket.EntryTime = valid.DayTimePointer(time.Now())
+		ticket.EntryTime = valid.DayTimePointer(utils.Now())
+		eventType = model.EventTicketCheckedIn
 	case "check_out":
 		ticket.State = "completed"
-		ticket.ExitTime = valid.DayTimePointer(time.Now())
+		ticket.ExitTime = valid.DayTimePointer(utils.Now())
+		eventType = model.EventTicketCheckedOut
 	}
-	if err := s.repo.UpdateTicket(ctx, &ticket, nil); err != nil {
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.UpdateTicket(ctx, &ticket, nil); err != nil {
+			return err
+		}
+		if eventType == "" {
+			return nil
+		}
+		return emitTicketEvent(ctx, rp, ticket, eventType)
+	})
+	if err != nil {
 		return false, err
 	}
 	return true, nil
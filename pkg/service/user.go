@@ -3,10 +3,9 @@ package service
 import (
 	"context"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"gitlab.com/goxp/cloud0/logger"
 	"gorm.io/gorm"
-	"net/http"
+	"parkar-server/pkg/apperror"
 	"parkar-server/pkg/model"
 	"parkar-server/pkg/repo"
 	"parkar-server/pkg/utils"
@@ -34,7 +33,7 @@ func (s *UserService) CreateUser(ctx context.Context, req model.CreateUserReq) (
 	hashPass, err := utils.Hash(valid.String(req.Password))
 	if err != nil {
 		log.WithError(err).Error("Failed to hash password")
-		return nil, ginext.NewError(http.StatusInternalServerError, "Failed to hash password")
+		return nil, apperror.New(apperror.Internal)
 	}
 	user := &model.User{
 		DisplayName: valid.String(req.DisplayName),
@@ -49,7 +48,7 @@ func (s *UserService) CreateUser(ctx context.Context, req model.CreateUserReq) (
 		return nil, err
 	}
 	if oldUser != nil {
-		return nil, ginext.NewError(http.StatusBadRequest, "Số điện thoại đã tồn tại ")
+		return nil, apperror.New(apperror.PhoneNumberTaken)
 	}
 
 	//create
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/notification"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"time"
+)
+
+const (
+	// waitlistOfferTTL is how long a waiting user has to accept an offered slot.
+	waitlistOfferTTL = 10 * time.Minute
+	// noShowGrace is how long after its start a ticket without check in keeps its slot.
+	noShowGrace = 30 * time.Minute
+	// waitlistSweepInterval is the period of the job that releases no-shows and hands out offers.
+	waitlistSweepInterval = time.Minute
+)
+
+type WaitlistService struct {
+	repo     repo.PGInterface
+	notifier NotificationInterface
+}
+
+func NewWaitlistService(repo repo.PGInterface, notifier NotificationInterface) WaitlistInterface {
+	return &WaitlistService{repo: repo, notifier: notifier}
+}
+
+type WaitlistInterface interface {
+	JoinWaitlist(ctx context.Context, req model.WaitlistReq) (model.Waitlist, error)
+	GetListWaitlist(ctx context.Context, req model.ListWaitlistReq) (model.ListWaitlistRes, error)
+	AcceptWaitlistOffer(ctx context.Context, req model.AcceptWaitlistReq) (model.Ticket, error)
+	CancelWaitlist(ctx context.Context, id, userID uuid.UUID) error
+	Run(ctx context.Context)
+}
+
+func (s *WaitlistService) JoinWaitlist(ctx context.Context, req model.WaitlistReq) (model.Waitlist, error) {
+	entry := model.Waitlist{
+		BaseModel:    model.BaseModel{CreatorID: req.UserId, UpdaterID: req.UserId},
+		UserId:       req.UserId,
+		VehicleId:    req.VehicleId,
+		ParkingLotId: req.ParkingLotId,
+		TimeFrameId:  req.TimeFrameId,
+		StartTime:    valid.DayTime(req.StartTime).UTC(),
+		EndTime:      valid.DayTime(req.EndTime).UTC(),
+		State:        model.WaitlistStateWaiting,
+	}
+	if !entry.StartTime.Before(entry.EndTime) {
+		return entry, apperror.New(apperror.InvalidTimeRange)
+	}
+	if !entry.StartTime.After(utils.Now()) {
+		return entry, apperror.New(apperror.TimeInPast, "startTime")
+	}
+	if _, err := s.repo.GetOneParkingLot(ctx, valid.UUID(req.ParkingLotId)); err != nil {
+		return entry, err
+	}
+
+	if err := s.repo.CreateWaitlist(ctx, &entry); err != nil {
+		return entry, err
+	}
+	// a slot may have freed up since the user saw the parking lot full
+	offerWaitlist(ctx, s.repo, s.notifier, *entry.ParkingLotId)
+	return s.repo.GetOneWaitlist(ctx, entry.ID)
+}
+
+func (s *WaitlistService) GetListWaitlist(ctx context.Context, req model.ListWaitlistReq) (model.ListWaitlistRes, error) {
+	return s.repo.GetListWaitlist(ctx, req)
+}
+
+// AcceptWaitlistOffer books the slot offered to the user as a new ticket.
+func (s *WaitlistService) AcceptWaitlistOffer(ctx context.Context, req model.AcceptWaitlistReq) (ticket model.Ticket, err error) {
+	entry, err := s.repo.GetOneWaitlist(ctx, valid.UUID(req.ID))
+	if err != nil {
+		return ticket, err
+	}
+	if valid.UUID(entry.UserId) != valid.UUID(req.UserId) {
+		return ticket, apperror.New(apperror.NotFound)
+	}
+	if entry.State != model.WaitlistStateOffered || entry.OfferExpiresAt == nil || !entry.OfferExpiresAt.After(utils.Now()) {
+		return ticket, apperror.New(apperror.WaitlistOfferGone)
+	}
+
+	ticket = model.Ticket{
+		BaseModel:     model.BaseModel{CreatorID: entry.UserId, UpdaterID: entry.UserId},
+		UserId:        entry.UserId,
+		VehicleId:     entry.VehicleId,
+		ParkingLotId:  entry.ParkingLotId,
+		ParkingSlotId: entry.ParkingSlotId,
+		TimeFrameId:   entry.TimeFrameId,
+		StartTime:     valid.DayTimePointer(entry.StartTime),
+		EndTime:       valid.DayTimePointer(entry.EndTime),
+		State:         "new",
+		Total:         valid.Float64(req.Total),
+	}
+	err = s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, valid.UUID(entry.ParkingLotId)); err != nil {
+			return err
+		}
+		if err := rp.CreateTicket(ctx, &ticket, nil); err != nil {
+			return err
+		}
+		// the offer may have expired or been cancelled since it was read
+		if err := rp.BookWaitlistOffer(ctx, entry.ID, ticket.ID); err != nil {
+			return err
+		}
+		return emitTicketEvent(ctx, rp, ticket, model.EventTicketCreated)
+	})
+	if err != nil {
+		return ticket, err
+	}
+	s.notifier.NotifyTicket(ctx, ticket, notification.EventBookingConfirmed)
+	return ticket, nil
+}
+
+func (s *WaitlistService) CancelWaitlist(ctx context.Context, id, userID uuid.UUID) error {
+	entry, err := s.repo.GetOneWaitlist(ctx, id)
+	if err != nil {
+		return err
+	}
+	if valid.UUID(entry.UserId) != userID {
+		return apperror.New(apperror.NotFound)
+	}
+	if entry.State != model.WaitlistStateWaiting && entry.State != model.WaitlistStateOffered {
+		return apperror.New(apperror.WaitlistClosed)
+	}
+
+	offered := entry.State == model.WaitlistStateOffered
+	entry.State = model.WaitlistStateCancelled
+	if err := s.repo.UpdateWaitlist(ctx, &entry); err != nil {
+		return err
+	}
+	if offered {
+		offerWaitlist(ctx, s.repo, s.notifier, valid.UUID(entry.ParkingLotId))
+	}
+	return nil
+}
+
+// Run releases the no-show tickets and expired slot holds, expires the offers that were not taken and
+// offers the freed slots to the waiting users until ctx is done.
+func (s *WaitlistService) Run(ctx context.Context) {
+	ticker := time.NewTicker(waitlistSweepInterval)
+	defer ticker.Stop()
+	for {
+		s.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WaitlistService) sweep(ctx context.Context) {
+	log := logger.WithCtx(ctx, "WaitlistService.sweep")
+
+	var noShows []model.Ticket
+	err := s.repo.Transaction(ctx, func(rp repo.PGInterface) error {
+		var err error
+		if noShows, err = rp.MarkNoShowTicket(ctx, utils.Now().Add(-noShowGrace)); err != nil {
+			return err
+		}
+		for _, ticket := range noShows {
+			if err := emitTicketEvent(ctx, rp, ticket, model.EventTicketNoShow); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to release no-show tickets")
+	} else if len(noShows) > 0 {
+		log.Infof("released %d no-show tickets", len(noShows))
+	}
+
+	if dropped, err := s.repo.DeleteExpiredSlotHold(ctx); err != nil {
+		log.WithError(err).Error("failed to drop expired slot holds")
+	} else if dropped > 0 {
+		log.Infof("dropped %d expired slot holds", dropped)
+	}
+
+	expired, err := s.repo.ExpireWaitlist(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to expire waitlist")
+	}
+	for _, entry := range expired {
+		if entry.ParkingSlotId != nil {
+			s.notifier.NotifyWaitlist(ctx, entry, notification.EventWaitlistExpired)
+		}
+	}
+
+	lotIDs, err := s.repo.GetWaitlistParkingLotIDs(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to get waitlist parking lots")
+		return
+	}
+	for _, id := range lotIDs {
+		offerWaitlist(ctx, s.repo, s.notifier, id)
+	}
+}
+
+// offerWaitlist offers the free slots of a parking lot to its waiting users, oldest entry first. Failures
+// are only logged as the next sweep tries again.
+func offerWaitlist(ctx context.Context, r repo.PGInterface, notifier NotificationInterface, parkingLotID uuid.UUID) {
+	var offers []model.Waitlist
+	err := r.Transaction(ctx, func(rp repo.PGInterface) error {
+		if err := rp.LockParkingLot(ctx, parkingLotID); err != nil {
+			return err
+		}
+		entries, err := rp.GetWaitingWaitlist(ctx, parkingLotID)
+		if err != nil {
+			return err
+		}
+
+		vehicleTypes := map[uuid.UUID]string{}
+		for _, entry := range entries {
+			req := model.FreeSlotReq{ParkingLotID: parkingLotID, Start: entry.StartTime, End: entry.EndTime}
+			if entry.VehicleId != nil {
+				vehicleType, ok := vehicleTypes[*entry.VehicleId]
+				if !ok {
+					vehicle, err := rp.GetOneVehicle(ctx, *entry.VehicleId)
+					if err != nil {
+						return err
+					}
+					vehicleType, vehicleTypes[*entry.VehicleId] = vehicle.Type, vehicle.Type
+				}
+				req.VehicleType = vehicleType
+			}
+
+			slot, err := assignSlot(ctx, rp, req)
+			if err != nil {
+				return err
+			}
+			if slot == nil {
+				continue
+			}
+			entry.State = model.WaitlistStateOffered
+			entry.ParkingSlotId = valid.UUIDPointer(slot.ID)
+			entry.OfferExpiresAt = valid.DayTimePointer(utils.Now().Add(waitlistOfferTTL))
+			if err := rp.UpdateWaitlist(ctx, &entry); err != nil {
+				return err
+			}
+			offers = append(offers, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithCtx(ctx, "offerWaitlist").WithField("parking_lot_id", parkingLotID).WithError(err).Error("failed to offer waitlist")
+		return
+	}
+	for _, entry := range offers {
+		notifier.NotifyWaitlist(ctx, entry, notification.EventWaitlistOffered)
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"gitlab.com/goxp/cloud0/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"parkar-server/pkg/repo"
+	"parkar-server/pkg/utils"
+	"parkar-server/pkg/valid"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// webhookSweepInterval is the period of the job that sends the deliveries.
+	webhookSweepInterval = 5 * time.Second
+	// webhookBatchSize bounds the deliveries sent by one round of the job.
+	webhookBatchSize = 100
+	// webhookLease is how long a claimed delivery is kept from the other workers while it is sent.
+	webhookLease = time.Minute
+	// webhookTimeout bounds one call to a merchant endpoint.
+	webhookTimeout = 10 * time.Second
+	// webhookMaxAttempts is the number of attempts after which a delivery is given up.
+	webhookMaxAttempts = 10
+	// webhookFirstRetry is the delay before the first retry, it doubles after each failure up to webhookMaxRetry.
+	webhookFirstRetry = 30 * time.Second
+	webhookMaxRetry   = 6 * time.Hour
+)
+
+type WebhookService struct {
+	repo   repo.PGInterface
+	client *http.Client
+}
+
+func NewWebhookService(repo repo.PGInterface) WebhookInterface {
+	return &WebhookService{repo: repo, client: newWebhookClient()}
+}
+
+// newWebhookClient returns the client calling the merchant endpoints. It connects to public addresses only,
+// checked after the name is resolved so a name pointing to the internal network is refused too, and does not
+// follow redirects, which would bypass that check.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicIP tells whether ip can be reached from the internet, loopback, private and link-local addresses
+// cannot.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+type WebhookInterface interface {
+	EventHandler
+	CreateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionReq) (model.WebhookSubscription, error)
+	GetListWebhookSubscription(ctx context.Context, req model.ListWebhookSubscriptionReq) ([]model.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionReq) (model.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id, companyID uuid.UUID) error
+	GetListWebhookDelivery(ctx context.Context, req model.ListWebhookDeliveryReq) (model.ListWebhookDeliveryRes, error)
+	ReplayWebhookDelivery(ctx context.Context, id, companyID uuid.UUID) (model.WebhookDelivery, error)
+	Run(ctx context.Context)
+}
+
+// CreateWebhookSubscription subscribes a company to events, the secret is generated when not given and is
+// only returned here.
+func (s *WebhookService) CreateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionReq) (model.WebhookSubscription, error) {
+	sub := model.WebhookSubscription{
+		CompanyId:  valid.UUID(req.CompanyId),
+		Url:        valid.String(req.Url),
+		Secret:     valid.String(req.Secret),
+		EventTypes: req.EventTypes,
+		Active:     true,
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return sub, err
+		}
+		sub.Secret = secret
+	}
+	if err := validateWebhookSubscription(sub); err != nil {
+		return sub, err
+	}
+	if _, err := s.repo.GetOneCompany(ctx, sub.CompanyId); err != nil {
+		return sub, err
+	}
+
+	if err := s.repo.CreateWebhookSubscription(ctx, &sub); err != nil {
+		return sub, err
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) GetListWebhookSubscription(ctx context.Context, req model.ListWebhookSubscriptionReq) ([]model.WebhookSubscription, error) {
+	companyID, err := uuid.Parse(valid.String(req.CompanyId))
+	if err != nil {
+		return nil, apperror.New(apperror.InvalidID)
+	}
+	subs, err := s.repo.GetListWebhookSubscription(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// UpdateWebhookSubscription changes the url, events or state of a subscription, a secret in req rotates it.
+func (s *WebhookService) UpdateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionReq) (model.WebhookSubscription, error) {
+	sub, err := s.repo.GetOneWebhookSubscription(ctx, valid.UUID(req.ID))
+	if err != nil {
+		return sub, err
+	}
+	if sub.CompanyId != valid.UUID(req.CompanyId) {
+		return sub, apperror.New(apperror.NotFound)
+	}
+
+	if req.Url != nil {
+		sub.Url = *req.Url
+	}
+	if req.Secret != nil && *req.Secret != "" {
+		sub.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		sub.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err := validateWebhookSubscription(sub); err != nil {
+		return sub, err
+	}
+
+	if err := s.repo.UpdateWebhookSubscription(ctx, &sub); err != nil {
+		return sub, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// DeleteWebhookSubscription deletes a subscription of companyID.
+func (s *WebhookService) DeleteWebhookSubscription(ctx context.Context, id, companyID uuid.UUID) error {
+	sub, err := s.repo.GetOneWebhookSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	if sub.CompanyId != companyID {
+		return apperror.New(apperror.NotFound)
+	}
+	return s.repo.DeleteWebhookSubscription(ctx, id)
+}
+
+func (s *WebhookService) GetListWebhookDelivery(ctx context.Context, req model.ListWebhookDeliveryReq) (model.ListWebhookDeliveryRes, error) {
+	return s.repo.GetListWebhookDelivery(ctx, req)
+}
+
+// ReplayWebhookDelivery sends a delivery again on the next round of the job, with a fresh set of attempts.
+func (s *WebhookService) ReplayWebhookDelivery(ctx context.Context, id, companyID uuid.UUID) (model.WebhookDelivery, error) {
+	delivery, err := s.repo.GetOneWebhookDelivery(ctx, id)
+	if err != nil {
+		return delivery, err
+	}
+	sub, err := s.repo.GetOneWebhookSubscription(ctx, delivery.SubscriptionId)
+	if err != nil {
+		return delivery, err
+	}
+	if sub.CompanyId != companyID {
+		return model.WebhookDelivery{}, apperror.New(apperror.NotFound)
+	}
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = utils.Now()
+	if err := s.repo.UpdateWebhookDelivery(ctx, &delivery); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func validateWebhookSubscription(sub model.WebhookSubscription) error {
+	u, err := url.Parse(sub.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apperror.New(apperror.InvalidParam, "url", sub.Url)
+	}
+	// names are checked when connecting, see newWebhookClient
+	if ip := net.ParseIP(u.Hostname()); u.Hostname() == "localhost" || (ip != nil && !publicIP(ip)) {
+		return apperror.New(apperror.InvalidParam, "url", sub.Url)
+	}
+	for _, eventType := range sub.EventTypes {
+		known := false
+		for _, t := range model.TicketEventTypes {
+			known = known || t == eventType
+		}
+		if !known {
+			return apperror.New(apperror.InvalidParam, "eventTypes", eventType)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", apperror.Wrap(apperror.Internal, err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HandleEvent queues one delivery of event per matching subscription of its company, it is the webhook
+// subscriber of the event bus.
+func (s *WebhookService) HandleEvent(ctx context.Context, rp repo.PGInterface, event model.OutboxEvent) error {
+	if event.CompanyId == nil {
+		return nil
+	}
+	subs, err := rp.GetWebhookSubscriptionsForEvent(ctx, *event.CompanyId, event.EventType)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]model.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionId: sub.ID,
+			EventId:        event.ID,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  utils.Now(),
+		})
+	}
+	return rp.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+// Run sends the deliveries due until ctx is done.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookSweepInterval)
+	defer ticker.Stop()
+	for {
+		s.deliver(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context) {
+	log := logger.WithCtx(ctx, "WebhookService.deliver")
+
+	deliveries, err := s.repo.ClaimDueWebhookDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		log.WithError(err).Error("failed to claim webhook deliveries")
+		return
+	}
+	subs := map[uuid.UUID]*model.WebhookSubscription{}
+	for _, delivery := range deliveries {
+		sub, ok := subs[delivery.SubscriptionId]
+		if !ok {
+			res, err := s.repo.GetOneWebhookSubscription(ctx, delivery.SubscriptionId)
+			if err == nil {
+				sub = &res
+			} else if apperror.From(err).ErrorCode() != apperror.NotFound {
+				// the delivery stays pending, it is claimed again when its lease ends
+				log.WithError(err).WithField("delivery_id", delivery.ID).Error("failed to get webhook subscription")
+				continue
+			}
+			subs[delivery.SubscriptionId] = sub
+		}
+
+		delivery.Attempts++
+		if sub == nil || !sub.Active {
+			delivery.Status, delivery.LastError = model.WebhookDeliveryFailed, "subscription removed or disabled"
+		} else if code, err := s.send(ctx, *sub, delivery); err != nil {
+			delivery.LastStatusCode, delivery.LastError = code, err.Error()
+			if delivery.Attempts >= webhookMaxAttempts {
+				delivery.Status = model.WebhookDeliveryFailed
+			} else {
+				delivery.NextAttemptAt = utils.Now().Add(webhookRetryDelay(delivery.Attempts))
+			}
+		} else {
+			delivery.Status, delivery.LastStatusCode, delivery.LastError = model.WebhookDeliverySucceeded, code, ""
+			delivery.DeliveredAt = valid.DayTimePointer(utils.Now())
+		}
+		if err := s.repo.UpdateWebhookDelivery(ctx, &delivery); err != nil {
+			log.WithError(err).WithField("delivery_id", delivery.ID).Error("failed to update webhook delivery")
+		}
+	}
+}
+
+// webhookRetryDelay is the wait after the attempt-th failed attempt.
+func webhookRetryDelay(attempt int) time.Duration {
+	delay := webhookFirstRetry
+	for i := 1; i < attempt && delay < webhookMaxRetry; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetry {
+		delay = webhookMaxRetry
+	}
+	return delay
+}
+
+// send posts the event of delivery to the subscription. The body is signed with the secret of the
+// subscription as hex(hmac_sha256(secret, timestamp + "." + body)) in X-Parkar-Signature, timestamp
+// being the unix time in X-Parkar-Timestamp, so receivers can check it and refuse replayed calls.
+func (s *WebhookService) send(ctx context.Context, sub model.WebhookSubscription, delivery model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":        delivery.EventId,
+		"type":      delivery.EventType,
+		"createdAt": delivery.CreatedAt,
+		"data":      json.RawMessage(delivery.Payload.Bytes),
+	})
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(sub.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Parkar-Event", delivery.EventType)
+	req.Header.Set("X-Parkar-Delivery", delivery.ID.String())
+	req.Header.Set("X-Parkar-Timestamp", timestamp)
+	req.Header.Set("X-Parkar-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -3,7 +3,6 @@ package utils
 import (
 	"database/sql/driver"
 	"fmt"
-	"github.com/astaxie/beego/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -126,21 +125,6 @@ func GetCurrentCaller(caller interface{}, level int) string {
 	return CurrentFunctionName(1)
 }
 
-func CheckRequireValid(ob interface{}) error {
-	validator := validation.Validation{RequiredFirst: true}
-	passed, err := validator.Valid(ob)
-	if err != nil {
-		return err
-	}
-	if !passed {
-		var err string
-		for _, e := range validator.Errors {
-			err += fmt.Sprintf("[%s: %s] ", e.Field, e.Message)
-		}
-		return fmt.Errorf(err)
-	}
-	return nil
-}
 func Hash(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 10)
 	return string(bytes), err
@@ -12,6 +12,8 @@ const (
 
 const TIME_FORMAT_FOR_QUERRY = "2006-01-02 15:04:05"
 
+const TIMEZONE_VN = "Asia/Ho_Chi_Minh"
+
 const SHOPEE = "Shopee"
 
 const ENV_DEV = "dev"
@@ -5,9 +5,9 @@ import (
 	"log"
 )
 
-func CreateESClient() (esClient *elasticsearch.Client, err error) {
+func CreateESClient(addresses ...string) (esClient *elasticsearch.Client, err error) {
 
-	clientES, err := elasticsearch.NewDefaultClient()
+	clientES, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: addresses})
 	if err != nil {
 		log.Printf("Error creating the client: %s", err)
 	} else {
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strings"
+)
+
+const (
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_XLSX = "xlsx"
+)
+
+// TableWriter writes an export row by row, Close must be called once all rows are written and Abort
+// instead when the export fails.
+type TableWriter interface {
+	WriteRow(row []string) error
+	Close() error
+	Abort()
+}
+
+// escapeFormula prefixes with a quote the cells a spreadsheet would run as a formula, the values come from
+// users and merchants.
+func escapeFormula(row []string) []string {
+	var res []string
+	for i, v := range row {
+		if v == "" || !strings.ContainsAny(v[:1], "=+-@\t\r") {
+			continue
+		}
+		if res == nil {
+			res = append([]string(nil), row...)
+		}
+		res[i] = "'" + v
+	}
+	if res == nil {
+		return row
+	}
+	return res
+}
+
+// NewTableWriter returns the writer for format, csv or xlsx.
+func NewTableWriter(w io.Writer, format string) TableWriter {
+	if format == EXPORT_FORMAT_XLSX {
+		return &xlsxWriter{w: w}
+	}
+	return &csvWriter{w: w}
+}
+
+func ExportContentType(format string) string {
+	if format == EXPORT_FORMAT_XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w       io.Writer
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	written int
+}
+
+// WriteRow flushes every few hundred rows so the response is streamed, the rows also reach w each
+// time the buffer fills up. An error before any byte is out can still be answered with a proper
+// status. The UTF-8 BOM goes first, Excel needs it to read Vietnamese text correctly.
+func (c *csvWriter) WriteRow(row []string) error {
+	if c.csv == nil {
+		c.buf = bufio.NewWriter(c.w)
+		if _, err := c.buf.WriteString("\xEF\xBB\xBF"); err != nil {
+			return err
+		}
+		c.csv = csv.NewWriter(c.buf)
+	}
+	if err := c.csv.Write(escapeFormula(row)); err != nil {
+		return err
+	}
+	c.written++
+	if c.written%500 == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *csvWriter) flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return c.buf.Flush()
+}
+
+func (c *csvWriter) Close() error {
+	if c.csv == nil {
+		return nil
+	}
+	return c.flush()
+}
+
+// Abort drops the buffered rows, the csv writer holds nothing else.
+func (c *csvWriter) Abort() {}
+
+// xlsxWriter uses the excelize stream writer which spills rows to a temporary file instead of
+// keeping the whole sheet in memory, the workbook is written to w on Close.
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (x *xlsxWriter) WriteRow(row []string) error {
+	if x.stream == nil {
+		x.file = excelize.NewFile()
+		stream, err := x.file.NewStreamWriter("Sheet1")
+		if err != nil {
+			return err
+		}
+		x.stream = stream
+	}
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	row = escapeFormula(row)
+	values := make([]interface{}, len(row))
+	for i := range row {
+		values[i] = row[i]
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	if x.stream == nil {
+		return nil
+	}
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
+
+// Abort removes the temporary file the rows were spilled to.
+func (x *xlsxWriter) Abort() {
+	if x.file != nil {
+		_ = x.file.Close()
+	}
+}
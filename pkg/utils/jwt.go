@@ -1,14 +1,51 @@
 package utils
 
 import (
+	"errors"
 	jwt2 "github.com/golang-jwt/jwt/v4"
 	"os"
+	"time"
 )
 
+var ErrNoJWTSecret = errors.New("jwt secret is not set")
+
 func GenerateToken(userId string) (string, error) {
 	claims := jwt2.MapClaims{}
 	claims["id"] = userId
-	claims["exp"] = EXPIRTE_TIME
+	claims["exp"] = time.Now().Add(EXPIRTE_TIME * time.Second).Unix()
 	token := jwt2.NewWithClaims(jwt2.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv(JWT_SECRET_KEY)))
 }
+
+// GenerateImpersonationToken returns a token of the user valid for ttl, its "act" claim is the admin acting as
+// the user.
+func GenerateImpersonationToken(userId, adminId string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := jwt2.MapClaims{}
+	claims["id"] = userId
+	claims["act"] = map[string]string{"sub": adminId}
+	claims["exp"] = expiresAt.Unix()
+	token := jwt2.NewWithClaims(jwt2.SigningMethodHS256, claims)
+	res, err := token.SignedString([]byte(os.Getenv(JWT_SECRET_KEY)))
+	return res, expiresAt, err
+}
+
+// ParseToken verifies the signature and the expiry of a token made by GenerateToken or
+// GenerateImpersonationToken and returns its claims. Without a secret no token is valid.
+func ParseToken(token string) (jwt2.MapClaims, error) {
+	secret := os.Getenv(JWT_SECRET_KEY)
+	if secret == "" {
+		return nil, ErrNoJWTSecret
+	}
+	claims := jwt2.MapClaims{}
+	_, err := jwt2.ParseWithClaims(token, claims, func(*jwt2.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt2.WithValidMethods([]string{jwt2.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, jwt2.ErrTokenExpired
+	}
+	return claims, nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+var locations sync.Map
+
+// Location returns the IANA time zone name, TIMEZONE_VN when name is empty or unknown. Loaded zones are
+// cached as LoadLocation reads the zone database on every call.
+func Location(name string) *time.Location {
+	if name == "" {
+		name = TIMEZONE_VN
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if name != TIMEZONE_VN {
+			return Location(TIMEZONE_VN)
+		}
+		loc = time.FixedZone(TIMEZONE_VN, 7*60*60)
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// ValidTimezone tells whether name is an IANA time zone such as Asia/Ho_Chi_Minh.
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// Now is the current time in UTC, the zone every time is stored in.
+func Now() time.Time {
+	return time.Now().UTC()
+}
@@ -14,9 +14,9 @@ import (
 
 	"github.com/astaxie/beego/logs"
 	"github.com/google/uuid"
-	"gitlab.com/goxp/cloud0/ginext"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
+	"parkar-server/pkg/apperror"
 )
 
 type ConsumerRequest struct {
@@ -60,16 +60,6 @@ func UUID(req *uuid.UUID) uuid.UUID {
 	return *req
 }
 
-func ConvertTimestampVN(dateTimeFrom *time.Time, dateTimeTo *time.Time) (string, string) {
-	dateTimeFromStr := dateTimeFrom.Format("2006-01-02")
-	dateTimeToStr := dateTimeTo.Format("2006-01-02")
-
-	dateTimeFromStr = dateTimeFromStr + " 00:00:00+07"
-	dateTimeToStr = dateTimeToStr + " 23:59:59+07"
-
-	return dateTimeFromStr, dateTimeToStr
-}
-
 func TransformString(in string, uppercase bool) string {
 	in = strings.TrimSpace(in)
 	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMn), norm.NFC)
@@ -117,12 +107,12 @@ func RemoveSpace(str string) string {
 func ValidTimeRequest(inStartTime string, inEndTime string) (outStartTime string, outEndTime string, err error) {
 	t1, err := time.Parse(time.RFC3339, inStartTime)
 	if err != nil {
-		return "", "", ginext.NewError(http.StatusBadRequest, "Lỗi sai định dạng ngày bắt đầu")
+		return "", "", apperror.New(apperror.InvalidParam, "startTime", inStartTime)
 	}
 	outStartTime = t1.UTC().Format(TIME_FORMAT_FOR_QUERRY)
 	t2, err := time.Parse(time.RFC3339, inEndTime)
 	if err != nil {
-		return "", "", ginext.NewError(http.StatusBadRequest, "Lỗi sai định dạng ngày kết thúc")
+		return "", "", apperror.New(apperror.InvalidParam, "endTime", inEndTime)
 	}
 	outEndTime = t2.UTC().Format(TIME_FORMAT_FOR_QUERRY)
 	return outStartTime, outEndTime, nil
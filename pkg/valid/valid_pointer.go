@@ -76,6 +76,13 @@ func DayTime(in *time.Time) time.Time {
 	return *in
 }
 
+func StringSlice(in *[]string) []string {
+	if in == nil {
+		return nil
+	}
+	return *in
+}
+
 //------------------------------------POINTER------------------------------------------------------------------------------------
 
 // String returns pointer to s.
@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/utils"
+	"reflect"
+	"strings"
+)
+
+// TagName is the struct tag of the rules, such as valid:"required,gt=0".
+const TagName = "valid"
+
+var validate = newValidator()
+
+// newValidator returns the go-playground validator with the domain rules: phone, a Vietnamese or E.164 phone
+// number, email and timezone, an IANA time zone.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName(TagName)
+	v.RegisterTagNameFunc(fieldName)
+	rules := map[string]func(string) bool{
+		"phone":    utils.ValidPhoneFormat,
+		"email":    utils.ValidateEmail,
+		"timezone": utils.ValidTimezone,
+	}
+	for tag, fn := range rules {
+		fn := fn
+		if err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return fn(fl.Field().String())
+		}); err != nil {
+			panic(err)
+		}
+	}
+	return v
+}
+
+// Struct checks the valid tags of s and returns an INVALID_INPUT error listing every field that fails.
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return apperror.Wrap(apperror.Internal, err)
+	}
+	t := reflect.Indirect(reflect.ValueOf(s)).Type()
+	fields := make([]apperror.FieldError, 0, len(errs))
+	for _, e := range errs {
+		param := e.Param()
+		if strings.HasSuffix(e.Tag(), "field") {
+			param = structFieldName(t, param)
+		}
+		// the namespace is the path from the struct, such as ListTimeFrameReq.data[0].cost
+		field := e.Field()
+		if i := strings.Index(e.Namespace(), "."); i >= 0 {
+			field = e.Namespace()[i+1:]
+		}
+		fields = append(fields, apperror.FieldError{Field: field, Rule: e.Tag(), Param: param})
+	}
+	return apperror.Invalid(fields...)
+}
+
+// fieldName names a field as the client sends it, by its json tag or else its form tag.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// structFieldName returns the client name of the field name of t, the param of the rules comparing fields.
+func structFieldName(t reflect.Type, name string) string {
+	if t.Kind() != reflect.Struct {
+		return name
+	}
+	if f, ok := t.FieldByName(name); ok {
+		if res := fieldName(f); res != "" {
+			return res
+		}
+	}
+	return name
+}
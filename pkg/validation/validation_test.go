@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"parkar-server/pkg/apperror"
+	"parkar-server/pkg/model"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func strPtr(s string) *string { return &s }
+
+// fieldErrors returns the fields of the INVALID_INPUT error of s, nil when s is valid.
+func fieldErrors(t *testing.T, s interface{}) []apperror.FieldError {
+	t.Helper()
+	err := Struct(s)
+	if err == nil {
+		return nil
+	}
+	e := apperror.From(err)
+	if e.ErrorCode() != apperror.InvalidInput {
+		t.Fatalf("Struct(%T) error = %v, want %s", s, err, apperror.InvalidInput)
+	}
+	return e.Fields()
+}
+
+func TestStruct(t *testing.T) {
+	id := uuid.New()
+	start := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	before := start.Add(-time.Hour)
+	total := -1.0
+
+	tests := []struct {
+		name string
+		req  interface{}
+		want []apperror.FieldError
+	}{
+		{"ticket without fields", &model.TicketReq{}, []apperror.FieldError{
+			{Field: "vehicleId", Rule: "required"},
+			{Field: "userId", Rule: "required"},
+			{Field: "parkingLotId", Rule: "required"},
+			{Field: "timeFrameId", Rule: "required"},
+			{Field: "startTime", Rule: "required"},
+			{Field: "endTime", Rule: "required"},
+		}},
+		{"ticket ending before its start", &model.TicketReq{
+			VehicleId: &id, UserId: &id, ParkingLotId: &id, TimeFrameId: &id, StartTime: &start, EndTime: &before, Total: &total,
+		}, []apperror.FieldError{
+			{Field: "endTime", Rule: "gtfield", Param: "startTime"},
+			{Field: "total", Rule: "gte", Param: "0"},
+		}},
+		{"valid ticket", &model.TicketReq{
+			VehicleId: &id, UserId: &id, ParkingLotId: &id, TimeFrameId: &id, StartTime: &start, EndTime: &end,
+		}, nil},
+		{"extension ending before its start", &model.ExtendTicketReq{
+			TicketOriginId: &id, TimeFrameId: &id, StartTime: &start, EndTime: &start,
+		}, []apperror.FieldError{
+			{Field: "endTime", Rule: "gtfield", Param: "startTime"},
+		}},
+		{"unknown procedure", &model.ProcedureReq{Type: "park", TicketId: "1"}, []apperror.FieldError{
+			{Field: "type", Rule: "oneof", Param: "check_in check_out"},
+			{Field: "ticketId", Rule: "uuid"},
+		}},
+		{"check in", &model.ProcedureReq{Type: "check_in", TicketId: id.String()}, nil},
+		{"time frames", &model.ListTimeFrameReq{Data: []model.TimeFrameReq{
+			{Duration: 60, Cost: 10, ParkingLotId: id},
+			{Duration: 60, Cost: -5, ParkingLotId: id},
+		}}, []apperror.FieldError{
+			{Field: "data[1].cost", Rule: "gt", Param: "0"},
+		}},
+		{"status out of the list", &model.AdminListCompanyReq{Status: strPtr("deleted")}, []apperror.FieldError{
+			{Field: "status", Rule: "oneof", Param: "pending_email pending_review active suspended"},
+		}},
+		{"no status", &model.AdminListCompanyReq{}, nil},
+		{"invalid phone and email", &model.UserReq{ID: &id, PhoneNumber: strPtr("12345"), Email: strPtr("a@")}, []apperror.FieldError{
+			{Field: "phoneNumber", Rule: "phone"},
+			{Field: "email", Rule: "email"},
+		}},
+		{"valid phone and email", &model.UserReq{ID: &id, PhoneNumber: strPtr("0912345678"), Email: strPtr("a@b.vn")}, nil},
+		{"international phone", &model.CheckPhoneReq{PhoneNumber: "+14155552671"}, nil},
+		{"invalid time zone", &model.ParkingLotReq{Name: strPtr("lot"), Timezone: strPtr("Mars/Base")}, []apperror.FieldError{
+			{Field: "timezone", Rule: "timezone"},
+		}},
+		{"local time zone", &model.ParkingLotReq{Name: strPtr("lot"), Timezone: strPtr("Local")}, []apperror.FieldError{
+			{Field: "timezone", Rule: "timezone"},
+		}},
+		{"valid time zone", &model.ParkingLotReq{Name: strPtr("lot"), Timezone: strPtr("Asia/Ho_Chi_Minh")}, nil},
+	}
+	for _, tt := range tests {
+		if got := fieldErrors(t, tt.req); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: fields = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStructFieldNames(t *testing.T) {
+	type req struct {
+		JSON   *string `json:"jsonName,omitempty" valid:"required"`
+		Form   *string `form:"formName" valid:"required"`
+		Plain  *string `valid:"required"`
+		Hidden *string `json:"-" form:"hidden" valid:"required"`
+		From   int     `json:"from"`
+		To     int     `json:"to" valid:"gtefield=From"`
+	}
+	want := []apperror.FieldError{
+		{Field: "jsonName", Rule: "required"},
+		{Field: "formName", Rule: "required"},
+		{Field: "Plain", Rule: "required"},
+		// a field the client does not send keeps its go name
+		{Field: "Hidden", Rule: "required"},
+		{Field: "to", Rule: "gtefield", Param: "from"},
+	}
+	if got := fieldErrors(t, req{From: 2, To: 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %+v, want %+v", got, want)
+	}
+}
+
+func TestStructNotStruct(t *testing.T) {
+	if err := Struct("not a struct"); apperror.From(err).ErrorCode() != apperror.Internal {
+		t.Errorf("Struct(string) error = %v, want %s", err, apperror.Internal)
+	}
+}
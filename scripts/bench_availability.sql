@@ -0,0 +1,43 @@
+-- Seed of the availability benchmark (BenchmarkGetAvailableParkingSlot in pkg/repo) on a scratch database.
+--
+--   go run . migrate up
+--   psql "host=... dbname=..." -v ON_ERROR_STOP=1 -f scripts/bench_availability.sql
+--   BENCH_DATABASE_DSN="host=... dbname=..." go test ./pkg/repo -run '^$' -bench GetAvailableParkingSlot -v
+--
+-- It seeds 50 parking lots of 4 blocks of 50 slots and 1M tickets spread over a year. The benchmark logs
+-- the plan of the query of the repo for one lot and one hour with explain analyze, and times it. Compare
+-- both with the indexes of 0002_hot_path_indexes and without them (migrate down to 1, then up again).
+
+begin;
+
+create temporary table bench_lot on commit drop as
+  select uuid_generate_v4() as id, n from generate_series(1, 50) n;
+insert into parking_lot (id, name) select id, 'bench lot ' || n from bench_lot;
+
+create temporary table bench_block on commit drop as
+  select uuid_generate_v4() as id, l.id as parking_lot_id, b as n from bench_lot l, generate_series(1, 4) b;
+insert into block (id, code, slot, parking_lot_id)
+  select id, chr(64 + n), 50, parking_lot_id from bench_block;
+
+create temporary table bench_slot on commit drop as
+  select uuid_generate_v4() as id, b.id as block_id, b.parking_lot_id, row_number() over () as n
+  from bench_block b, generate_series(1, 50) s;
+insert into parking_slot (id, name, block_id) select id, 'S' || n, block_id from bench_slot;
+
+-- 1M tickets of 1 to 4 hours on random slots over the year from now, a fifth of them finished
+insert into ticket (parking_lot_id, parking_slot_id, start_time, end_time, state, total)
+  select s.parking_lot_id, s.id, t.start_time, t.start_time + (1 + t.n % 4) * interval '1 hour',
+         case when t.n % 5 = 0 then 'completed' else 'new' end, 10000
+  from (select n, date_trunc('hour', now()) + (random() * 365 * 24) * interval '1 hour' as start_time,
+               1 + (random() * 9999)::int as slot_n
+        from generate_series(1, 1000000) n) t
+  join bench_slot s on s.n = t.slot_n;
+
+analyze parking_lot;
+analyze block;
+analyze parking_slot;
+analyze ticket;
+
+commit;
+
+select count(*) as tickets from ticket;